@@ -0,0 +1,776 @@
+package fontcatalog
+
+import (
+	"sort"
+	"unicode"
+	"unicode/utf8"
+)
+
+// bidiClass is a bidirectional character type of UAX #9.
+type bidiClass uint8
+
+const (
+	bidiL bidiClass = iota
+	bidiR
+	bidiAL
+	bidiEN
+	bidiES
+	bidiET
+	bidiAN
+	bidiCS
+	bidiNSM
+	bidiBN
+	bidiB
+	bidiS
+	bidiWS
+	bidiON
+	bidiLRE
+	bidiLRO
+	bidiRLE
+	bidiRLO
+	bidiPDF
+	bidiLRI
+	bidiRLI
+	bidiFSI
+	bidiPDI
+)
+
+// bidiClasses lists the code points whose type does not follow from their
+// general category or script.
+var bidiClasses = []struct {
+	class bidiClass
+	table *unicode.RangeTable
+}{
+	{bidiEN, &unicode.RangeTable{R16: []unicode.Range16{{0x0030, 0x0039, 1}, {0x00b2, 0x00b3, 1}, {0x00b9, 0x00b9, 1}, {0x06f0, 0x06f9, 1},
+		{0x2070, 0x2070, 1}, {0x2074, 0x2079, 1}, {0x2080, 0x2089, 1}, {0x2488, 0x249b, 1}, {0xff10, 0xff19, 1}},
+		R32: []unicode.Range32{{0x1d7ce, 0x1d7ff, 1}}}},
+	{bidiAN, &unicode.RangeTable{R16: []unicode.Range16{{0x0600, 0x0605, 1}, {0x0660, 0x0669, 1}, {0x066b, 0x066c, 1}, {0x06dd, 0x06dd, 1},
+		{0x0890, 0x0891, 1}, {0x08e2, 0x08e2, 1}}, R32: []unicode.Range32{{0x10d30, 0x10d39, 1}, {0x10e60, 0x10e7e, 1}}}},
+	{bidiES, &unicode.RangeTable{R16: []unicode.Range16{{0x002b, 0x002b, 1}, {0x002d, 0x002d, 1}, {0x207a, 0x207b, 1}, {0x208a, 0x208b, 1},
+		{0x2212, 0x2212, 1}, {0xfb29, 0xfb29, 1}, {0xfe62, 0xfe63, 1}, {0xff0b, 0xff0b, 1}, {0xff0d, 0xff0d, 1}}}},
+	{bidiET, &unicode.RangeTable{R16: []unicode.Range16{{0x0023, 0x0025, 1}, {0x00b0, 0x00b1, 1}, {0x0609, 0x060a, 1}, {0x066a, 0x066a, 1},
+		{0x2030, 0x2034, 1}, {0x212e, 0x212e, 1}, {0x2213, 0x2213, 1}, {0xfe5f, 0xfe5f, 1}, {0xfe69, 0xfe6a, 1}, {0xff03, 0xff05, 1}}}},
+	{bidiCS, &unicode.RangeTable{R16: []unicode.Range16{{0x002c, 0x002c, 1}, {0x002e, 0x002f, 1}, {0x003a, 0x003a, 1}, {0x00a0, 0x00a0, 1},
+		{0x060c, 0x060c, 1}, {0x202f, 0x202f, 1}, {0x2044, 0x2044, 1}, {0xfe50, 0xfe50, 1}, {0xfe52, 0xfe52, 1}, {0xfe55, 0xfe55, 1},
+		{0xff0c, 0xff0c, 1}, {0xff0e, 0xff0f, 1}, {0xff1a, 0xff1a, 1}}}},
+	{bidiB, &unicode.RangeTable{R16: []unicode.Range16{{0x000a, 0x000a, 1}, {0x000d, 0x000d, 1}, {0x001c, 0x001e, 1}, {0x0085, 0x0085, 1},
+		{0x2029, 0x2029, 1}}}},
+	{bidiS, &unicode.RangeTable{R16: []unicode.Range16{{0x0009, 0x0009, 1}, {0x000b, 0x000b, 1}, {0x001f, 0x001f, 1}}}},
+	{bidiWS, &unicode.RangeTable{R16: []unicode.Range16{{0x000c, 0x000c, 1}, {0x0020, 0x0020, 1}, {0x1680, 0x1680, 1}, {0x2000, 0x200a, 1},
+		{0x2028, 0x2028, 1}, {0x205f, 0x205f, 1}, {0x3000, 0x3000, 1}}}},
+	{bidiL, &unicode.RangeTable{R16: []unicode.Range16{{0x200e, 0x200e, 1}}}},
+	{bidiR, &unicode.RangeTable{R16: []unicode.Range16{{0x200f, 0x200f, 1}}}},
+	{bidiAL, &unicode.RangeTable{R16: []unicode.Range16{{0x061c, 0x061c, 1}}}},
+	{bidiLRE, &unicode.RangeTable{R16: []unicode.Range16{{0x202a, 0x202a, 1}}}},
+	{bidiRLE, &unicode.RangeTable{R16: []unicode.Range16{{0x202b, 0x202b, 1}}}},
+	{bidiPDF, &unicode.RangeTable{R16: []unicode.Range16{{0x202c, 0x202c, 1}}}},
+	{bidiLRO, &unicode.RangeTable{R16: []unicode.Range16{{0x202d, 0x202d, 1}}}},
+	{bidiRLO, &unicode.RangeTable{R16: []unicode.Range16{{0x202e, 0x202e, 1}}}},
+	{bidiLRI, &unicode.RangeTable{R16: []unicode.Range16{{0x2066, 0x2066, 1}}}},
+	{bidiRLI, &unicode.RangeTable{R16: []unicode.Range16{{0x2067, 0x2067, 1}}}},
+	{bidiFSI, &unicode.RangeTable{R16: []unicode.Range16{{0x2068, 0x2068, 1}}}},
+	{bidiPDI, &unicode.RangeTable{R16: []unicode.Range16{{0x2069, 0x2069, 1}}}},
+}
+
+// bidiRightToLeft and bidiArabic hold the blocks whose letters are R and AL.
+var bidiRightToLeft = &unicode.RangeTable{
+	R16: []unicode.Range16{{0x0590, 0x05ff, 1}, {0x07c0, 0x085f, 1}, {0xfb1d, 0xfb4f, 1}},
+	R32: []unicode.Range32{{0x10800, 0x10cff, 1}, {0x10e80, 0x10eff, 1}, {0x1e800, 0x1ec6f, 1}},
+}
+
+var bidiArabic = &unicode.RangeTable{
+	R16: []unicode.Range16{{0x0600, 0x07bf, 1}, {0x0860, 0x08ff, 1}, {0xfb50, 0xfdcf, 1}, {0xfdf0, 0xfdff, 1}, {0xfe70, 0xfeff, 1}},
+	R32: []unicode.Range32{{0x10d00, 0x10d3f, 1}, {0x10f30, 0x10f6f, 1}, {0x1ec70, 0x1ecbf, 1}, {0x1ed00, 0x1ed4f, 1}, {0x1ee00, 0x1eeff, 1}},
+}
+
+func bidiClassOf(r rune) bidiClass {
+	for _, c := range bidiClasses {
+		if unicode.Is(c.table, r) {
+			return c.class
+		}
+	}
+	switch {
+	case unicode.In(r, unicode.Mn, unicode.Me):
+		return bidiNSM
+	case unicode.In(r, unicode.Cc, unicode.Cf):
+		return bidiBN
+	case unicode.Is(bidiArabic, r):
+		return bidiAL
+	case unicode.Is(bidiRightToLeft, r):
+		return bidiR
+	case unicode.Is(unicode.Sc, r):
+		return bidiET
+	case unicode.In(r, unicode.P, unicode.S, unicode.No, unicode.Zs):
+		return bidiON
+	}
+	return bidiL
+}
+
+// bidiBrackets maps the opening brackets of Bidi_Paired_Bracket to their
+// closing bracket.
+var bidiBrackets = map[rune]rune{
+	'(': ')', '[': ']', '{': '}', 0x0f3a: 0x0f3b, 0x0f3c: 0x0f3d, 0x169b: 0x169c, 0x2045: 0x2046, 0x207d: 0x207e, 0x208d: 0x208e,
+	0x2308: 0x2309, 0x230a: 0x230b, 0x2329: 0x232a, 0x2768: 0x2769, 0x276a: 0x276b, 0x276c: 0x276d, 0x276e: 0x276f, 0x2770: 0x2771,
+	0x2772: 0x2773, 0x2774: 0x2775, 0x27c5: 0x27c6, 0x27e6: 0x27e7, 0x27e8: 0x27e9, 0x27ea: 0x27eb, 0x27ec: 0x27ed, 0x27ee: 0x27ef,
+	0x2983: 0x2984, 0x2985: 0x2986, 0x2987: 0x2988, 0x2989: 0x298a, 0x298b: 0x298c, 0x298d: 0x2990, 0x298f: 0x298e, 0x2991: 0x2992,
+	0x2993: 0x2994, 0x2995: 0x2996, 0x2997: 0x2998, 0x29d8: 0x29d9, 0x29da: 0x29db, 0x29fc: 0x29fd, 0x2e22: 0x2e23, 0x2e24: 0x2e25,
+	0x2e26: 0x2e27, 0x2e28: 0x2e29, 0x3008: 0x3009, 0x300a: 0x300b, 0x300c: 0x300d, 0x300e: 0x300f, 0x3010: 0x3011, 0x3014: 0x3015,
+	0x3016: 0x3017, 0x3018: 0x3019, 0x301a: 0x301b, 0xfe59: 0xfe5a, 0xfe5b: 0xfe5c, 0xfe5d: 0xfe5e, 0xff08: 0xff09, 0xff3b: 0xff3d,
+	0xff5b: 0xff5d, 0xff5f: 0xff60, 0xff62: 0xff63,
+}
+
+// bidiMirrors holds the pairs of Bidi_Mirroring_Glyph that are not brackets.
+var bidiMirrors = map[rune]rune{
+	'<': '>', 0x00ab: 0x00bb, 0x2039: 0x203a, 0x2208: 0x220b, 0x2209: 0x220c, 0x220a: 0x220d, 0x2215: 0x29f5, 0x223c: 0x223d,
+	0x2243: 0x22cd, 0x2252: 0x2253, 0x2254: 0x2255, 0x2264: 0x2265, 0x2266: 0x2267, 0x2268: 0x2269, 0x226a: 0x226b, 0x226e: 0x226f,
+	0x2270: 0x2271, 0x2272: 0x2273, 0x2274: 0x2275, 0x2276: 0x2277, 0x2278: 0x2279, 0x227a: 0x227b, 0x227c: 0x227d, 0x227e: 0x227f,
+	0x2280: 0x2281, 0x2282: 0x2283, 0x2284: 0x2285, 0x2286: 0x2287, 0x2288: 0x2289, 0x228a: 0x228b, 0x228f: 0x2290, 0x2291: 0x2292,
+	0x2298: 0x29b8, 0x22a2: 0x22a3, 0x22a6: 0x2ade, 0x22b0: 0x22b1, 0x22b2: 0x22b3, 0x22b4: 0x22b5, 0x22b6: 0x22b7, 0x22c9: 0x22ca,
+	0x22cb: 0x22cc, 0x22d0: 0x22d1, 0x22d6: 0x22d7, 0x22d8: 0x22d9, 0x22da: 0x22db, 0x22dc: 0x22dd, 0x22de: 0x22df, 0x22e0: 0x22e1,
+	0x22e2: 0x22e3, 0x22e4: 0x22e5, 0x22e6: 0x22e7, 0x22e8: 0x22e9, 0x22ea: 0x22eb, 0x22ec: 0x22ed, 0x22f0: 0x22f1, 0xfe64: 0xfe65,
+	0xff1c: 0xff1e,
+}
+
+var bidiMirrorOf = func() map[rune]rune {
+	m := make(map[rune]rune)
+	for _, pairs := range []map[rune]rune{bidiBrackets, bidiMirrors} {
+		for a, b := range pairs {
+			m[a], m[b] = b, a
+		}
+	}
+	return m
+}()
+
+// bidiMirror returns the mirrored glyph of r for right-to-left text.
+func bidiMirror(r rune) (rune, bool) {
+	m, ok := bidiMirrorOf[r]
+	return m, ok
+}
+
+// bidiCanonicalBracket maps the deprecated angle brackets to the CJK ones
+// they are canonically equivalent to.
+func bidiCanonicalBracket(r rune) rune {
+	switch r {
+	case 0x2329:
+		return 0x3008
+	case 0x232a:
+		return 0x3009
+	}
+	return r
+}
+
+// bidiMaxDepth is the deepest embedding level.
+const bidiMaxDepth = 125
+
+// BidiRun is a run of text with one embedding level, Start and End are byte
+// offsets. Runs of odd levels are right-to-left.
+type BidiRun struct {
+	Start, End int
+	Level      int
+}
+
+func (r BidiRun) Direction() Direction {
+	if r.Level%2 == 1 {
+		return DirectionRTL
+	}
+	return DirectionLTR
+}
+
+type bidiParagraph struct {
+	start, end int
+	level      uint8
+}
+
+// Bidi resolves the embedding levels of a text following the rules of
+// UAX #9, explicit embeddings, overrides and isolates included. Lines of the
+// text are then reordered with Runs. Character types are taken from the
+// general category and the right-to-left blocks, with the exceptions of
+// DerivedBidiClass.txt for numbers, separators and formatting characters.
+type Bidi struct {
+	text    string
+	offsets []int
+	classes []bidiClass
+	types   []bidiClass
+	levels  []uint8
+	paras   []bidiParagraph
+	// matching holds the index of the PDI closing an isolate initiator, or
+	// -1, matched tells whether a PDI closes an isolate initiator
+	matching []int
+	matched  []bool
+}
+
+// NewBidi resolves the levels of text. dir sets the direction of every
+// paragraph, DirectionInvalid takes it from the first strong character of
+// each paragraph.
+func NewBidi(text string, dir Direction) *Bidi {
+	b := &Bidi{text: text}
+	for offset, r := range text {
+		b.offsets = append(b.offsets, offset)
+		b.classes = append(b.classes, bidiClassOf(r))
+	}
+	n := len(b.classes)
+	b.offsets = append(b.offsets, len(text))
+	b.types = append([]bidiClass(nil), b.classes...)
+	b.levels = make([]uint8, n)
+	b.matchIsolates()
+
+	start := 0
+	for i := 0; i < n; i++ {
+		if b.classes[i] == bidiB || i == n-1 {
+			p := bidiParagraph{start: start, end: i + 1}
+			switch dir {
+			case DirectionRTL:
+				p.level = 1
+			case DirectionLTR:
+				p.level = 0
+			default:
+				p.level = b.firstStrong(start, i+1, 0)
+			}
+			b.paras = append(b.paras, p)
+			b.resolve(p)
+			start = i + 1
+		}
+	}
+	return b
+}
+
+// matchIsolates pairs isolate initiators with their PDI (BD9).
+func (b *Bidi) matchIsolates() {
+	b.matching = make([]int, len(b.classes))
+	b.matched = make([]bool, len(b.classes))
+	open := []int{}
+	for i, c := range b.classes {
+		b.matching[i] = -1
+		switch c {
+		case bidiLRI, bidiRLI, bidiFSI:
+			open = append(open, i)
+		case bidiPDI:
+			if len(open) > 0 {
+				b.matching[open[len(open)-1]] = i
+				b.matched[i] = true
+				open = open[:len(open)-1]
+			}
+		case bidiB:
+			open = open[:0]
+		}
+	}
+}
+
+// firstStrong returns the level of the first strong character in
+// [start, end) outside isolates (P2, P3), def when there is none.
+func (b *Bidi) firstStrong(start, end int, def uint8) uint8 {
+	for i := start; i < end; i++ {
+		switch b.classes[i] {
+		case bidiL:
+			return 0
+		case bidiR, bidiAL:
+			return 1
+		case bidiLRI, bidiRLI, bidiFSI:
+			if b.matching[i] < 0 {
+				return def
+			}
+			i = b.matching[i]
+		case bidiB:
+			return def
+		}
+	}
+	return def
+}
+
+func removedByX9(c bidiClass) bool {
+	switch c {
+	case bidiLRE, bidiRLE, bidiLRO, bidiRLO, bidiPDF, bidiBN:
+		return true
+	}
+	return false
+}
+
+func isolateInitiator(c bidiClass) bool {
+	return c == bidiLRI || c == bidiRLI || c == bidiFSI
+}
+
+func (b *Bidi) resolve(p bidiParagraph) {
+	b.explicitLevels(p)
+	for _, seq := range b.isolatingRunSequences(p) {
+		b.resolveSequence(p, seq)
+	}
+	// removed characters take the level of the character before them
+	for i := p.start; i < p.end; i++ {
+		if removedByX9(b.classes[i]) {
+			if i > p.start {
+				b.levels[i] = b.levels[i-1]
+			} else {
+				b.levels[i] = p.level
+			}
+		}
+	}
+}
+
+// explicitLevels applies X1 to X8.
+func (b *Bidi) explicitLevels(p bidiParagraph) {
+	type status struct {
+		level    uint8
+		override bidiClass
+		isolate  bool
+	}
+	stack := []status{{level: p.level, override: bidiON}}
+	overflowIsolates, overflowEmbeddings, validIsolates := 0, 0, 0
+
+	for i := p.start; i < p.end; i++ {
+		top := stack[len(stack)-1]
+		c := b.classes[i]
+		switch c {
+		case bidiRLE, bidiLRE, bidiRLO, bidiLRO:
+			b.levels[i] = top.level
+			level := (top.level + 2) &^ 1
+			if c == bidiRLE || c == bidiRLO {
+				level = (top.level + 1) | 1
+			}
+			if level <= bidiMaxDepth && overflowIsolates == 0 && overflowEmbeddings == 0 {
+				s := status{level: level, override: bidiON}
+				switch c {
+				case bidiLRO:
+					s.override = bidiL
+				case bidiRLO:
+					s.override = bidiR
+				}
+				stack = append(stack, s)
+			} else if overflowIsolates == 0 {
+				overflowEmbeddings++
+			}
+		case bidiRLI, bidiLRI, bidiFSI:
+			b.levels[i] = top.level
+			if top.override != bidiON {
+				b.types[i] = top.override
+			}
+			rtl := c == bidiRLI
+			if c == bidiFSI {
+				end := b.matching[i]
+				if end < 0 {
+					end = p.end
+				}
+				rtl = b.firstStrong(i+1, end, 0) == 1
+			}
+			level := (top.level + 2) &^ 1
+			if rtl {
+				level = (top.level + 1) | 1
+			}
+			if level <= bidiMaxDepth && overflowIsolates == 0 && overflowEmbeddings == 0 {
+				validIsolates++
+				stack = append(stack, status{level: level, override: bidiON, isolate: true})
+			} else {
+				overflowIsolates++
+			}
+		case bidiPDI:
+			if overflowIsolates > 0 {
+				overflowIsolates--
+			} else if validIsolates > 0 {
+				overflowEmbeddings = 0
+				for !stack[len(stack)-1].isolate {
+					stack = stack[:len(stack)-1]
+				}
+				stack = stack[:len(stack)-1]
+				validIsolates--
+			}
+			top = stack[len(stack)-1]
+			b.levels[i] = top.level
+			if top.override != bidiON {
+				b.types[i] = top.override
+			}
+		case bidiPDF:
+			b.levels[i] = top.level
+			switch {
+			case overflowIsolates > 0:
+			case overflowEmbeddings > 0:
+				overflowEmbeddings--
+			case !top.isolate && len(stack) >= 2:
+				stack = stack[:len(stack)-1]
+			}
+		case bidiB:
+			b.levels[i] = p.level
+		case bidiBN:
+			b.levels[i] = top.level
+		default:
+			b.levels[i] = top.level
+			if top.override != bidiON {
+				b.types[i] = top.override
+			}
+		}
+	}
+}
+
+// isolatingRunSequences splits the paragraph into level runs and chains the
+// runs ending with an isolate initiator with the run of its PDI (X10).
+func (b *Bidi) isolatingRunSequences(p bidiParagraph) [][]int {
+	runs := [][]int{}
+	runOf := make(map[int]int)
+	for i := p.start; i < p.end; i++ {
+		if removedByX9(b.classes[i]) {
+			continue
+		}
+		if n := len(runs); n == 0 || b.levels[runs[n-1][len(runs[n-1])-1]] != b.levels[i] {
+			runs = append(runs, []int{})
+		}
+		runs[len(runs)-1] = append(runs[len(runs)-1], i)
+		runOf[i] = len(runs) - 1
+	}
+
+	seqs := [][]int{}
+	for _, run := range runs {
+		first := run[0]
+		if b.classes[first] == bidiPDI && b.matched[first] {
+			continue
+		}
+		seq := append([]int(nil), run...)
+		for {
+			last := seq[len(seq)-1]
+			if !isolateInitiator(b.classes[last]) || b.matching[last] < 0 {
+				break
+			}
+			next, ok := runOf[b.matching[last]]
+			if !ok {
+				break
+			}
+			seq = append(seq, runs[next]...)
+		}
+		seqs = append(seqs, seq)
+	}
+	return seqs
+}
+
+func levelClass(level uint8) bidiClass {
+	if level%2 == 1 {
+		return bidiR
+	}
+	return bidiL
+}
+
+// resolveSequence applies W1 to W7, N0 to N2 and I1, I2 to an isolating run
+// sequence.
+func (b *Bidi) resolveSequence(p bidiParagraph, seq []int) {
+	level := b.levels[seq[0]]
+	first, last := seq[0], seq[len(seq)-1]
+
+	before := p.level
+	for i := first - 1; i >= p.start; i-- {
+		if !removedByX9(b.classes[i]) {
+			before = b.levels[i]
+			break
+		}
+	}
+	after := p.level
+	if !isolateInitiator(b.classes[last]) {
+		for i := last + 1; i < p.end; i++ {
+			if !removedByX9(b.classes[i]) {
+				after = b.levels[i]
+				break
+			}
+		}
+	}
+	if before < level {
+		before = level
+	}
+	if after < level {
+		after = level
+	}
+	sos, eos := levelClass(before), levelClass(after)
+
+	t := make([]bidiClass, len(seq))
+	for k, i := range seq {
+		t[k] = b.types[i]
+	}
+
+	// W1
+	for k := range t {
+		if t[k] != bidiNSM {
+			continue
+		}
+		switch {
+		case k == 0:
+			t[k] = sos
+		case isolateInitiator(t[k-1]) || t[k-1] == bidiPDI:
+			t[k] = bidiON
+		default:
+			t[k] = t[k-1]
+		}
+	}
+	// W2, W3
+	strong := sos
+	for k := range t {
+		switch t[k] {
+		case bidiL, bidiR, bidiAL:
+			strong = t[k]
+		case bidiEN:
+			if strong == bidiAL {
+				t[k] = bidiAN
+			}
+		}
+	}
+	for k := range t {
+		if t[k] == bidiAL {
+			t[k] = bidiR
+		}
+	}
+	// W4
+	for k := 1; k+1 < len(t); k++ {
+		if t[k] == bidiES && t[k-1] == bidiEN && t[k+1] == bidiEN {
+			t[k] = bidiEN
+		} else if t[k] == bidiCS && t[k-1] == t[k+1] && (t[k-1] == bidiEN || t[k-1] == bidiAN) {
+			t[k] = t[k-1]
+		}
+	}
+	// W5
+	for k := 0; k < len(t); k++ {
+		if t[k] != bidiET {
+			continue
+		}
+		end := k
+		for end < len(t) && t[end] == bidiET {
+			end++
+		}
+		if (k > 0 && t[k-1] == bidiEN) || (end < len(t) && t[end] == bidiEN) {
+			for j := k; j < end; j++ {
+				t[j] = bidiEN
+			}
+		}
+		k = end - 1
+	}
+	// W6
+	for k := range t {
+		switch t[k] {
+		case bidiES, bidiET, bidiCS:
+			t[k] = bidiON
+		}
+	}
+	// W7
+	strong = sos
+	for k := range t {
+		switch t[k] {
+		case bidiL, bidiR:
+			strong = t[k]
+		case bidiEN:
+			if strong == bidiL {
+				t[k] = bidiL
+			}
+		}
+	}
+
+	b.resolveBrackets(seq, t, sos, level)
+
+	// N1, N2
+	neutral := func(c bidiClass) bool {
+		switch c {
+		case bidiB, bidiS, bidiWS, bidiON, bidiLRI, bidiRLI, bidiFSI, bidiPDI:
+			return true
+		}
+		return false
+	}
+	direction := func(c bidiClass) bidiClass {
+		if c == bidiEN || c == bidiAN {
+			return bidiR
+		}
+		return c
+	}
+	for k := 0; k < len(t); k++ {
+		if !neutral(t[k]) {
+			continue
+		}
+		end := k
+		for end < len(t) && neutral(t[end]) {
+			end++
+		}
+		prev, next := sos, eos
+		if k > 0 {
+			prev = direction(t[k-1])
+		}
+		if end < len(t) {
+			next = direction(t[end])
+		}
+		c := levelClass(level)
+		if prev == next {
+			c = prev
+		}
+		for j := k; j < end; j++ {
+			t[j] = c
+		}
+		k = end - 1
+	}
+
+	// I1, I2
+	for k, i := range seq {
+		b.types[i] = t[k]
+		switch {
+		case level%2 == 0 && t[k] == bidiR:
+			b.levels[i] = level + 1
+		case level%2 == 0 && (t[k] == bidiAN || t[k] == bidiEN):
+			b.levels[i] = level + 2
+		case level%2 == 1 && (t[k] == bidiL || t[k] == bidiEN || t[k] == bidiAN):
+			b.levels[i] = level + 1
+		}
+	}
+}
+
+// resolveBrackets applies N0 to the bracket pairs of a sequence.
+func (b *Bidi) resolveBrackets(seq []int, t []bidiClass, sos bidiClass, level uint8) {
+	type pair struct{ open, close int }
+	pairs := []pair{}
+	type opening struct {
+		k     int
+		close rune
+	}
+	stack := []opening{}
+	for k, i := range seq {
+		if t[k] != bidiON {
+			continue
+		}
+		r, _ := utf8.DecodeRuneInString(b.text[b.offsets[i]:])
+		if close, ok := bidiBrackets[r]; ok {
+			if len(stack) == 63 {
+				break
+			}
+			stack = append(stack, opening{k, bidiCanonicalBracket(close)})
+			continue
+		}
+		r = bidiCanonicalBracket(r)
+		for s := len(stack) - 1; s >= 0; s-- {
+			if stack[s].close == r {
+				pairs = append(pairs, pair{stack[s].k, k})
+				stack = stack[:s]
+				break
+			}
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].open < pairs[j].open })
+
+	strongOf := func(c bidiClass) bidiClass {
+		switch c {
+		case bidiL:
+			return bidiL
+		case bidiR, bidiAL, bidiEN, bidiAN:
+			return bidiR
+		}
+		return bidiON
+	}
+	embedding := levelClass(level)
+	for _, pr := range pairs {
+		found, opposite := false, false
+		for k := pr.open + 1; k < pr.close; k++ {
+			switch strongOf(t[k]) {
+			case embedding:
+				found = true
+			case bidiON:
+			default:
+				opposite = true
+			}
+		}
+		c := bidiON
+		switch {
+		case found:
+			c = embedding
+		case opposite:
+			context := sos
+			for k := pr.open - 1; k >= 0; k-- {
+				if s := strongOf(t[k]); s != bidiON {
+					context = s
+					break
+				}
+			}
+			c = embedding
+			if context != embedding {
+				c = context
+			}
+		}
+		if c == bidiON {
+			continue
+		}
+		for _, k := range []int{pr.open, pr.close} {
+			t[k] = c
+			for j := k + 1; j < len(seq) && b.classes[seq[j]] == bidiNSM; j++ {
+				t[j] = c
+			}
+		}
+	}
+}
+
+func (b *Bidi) runeIndex(offset int) int {
+	return sort.SearchInts(b.offsets, offset)
+}
+
+// Direction returns the direction of the paragraph holding the byte offset.
+func (b *Bidi) Direction(offset int) Direction {
+	i := b.runeIndex(offset)
+	for _, p := range b.paras {
+		if i >= p.start && i < p.end {
+			return BidiRun{Level: int(p.level)}.Direction()
+		}
+	}
+	return DirectionLTR
+}
+
+// Runs returns the runs of the line text[start:end] in visual order, from
+// left to right. The line must not span paragraphs.
+func (b *Bidi) Runs(start, end int) []BidiRun {
+	first, last := b.runeIndex(start), b.runeIndex(end)
+	if first >= last {
+		return nil
+	}
+	paraLevel := uint8(0)
+	for _, p := range b.paras {
+		if first >= p.start && first < p.end {
+			paraLevel = p.level
+		}
+	}
+
+	// L1, separators and the whitespace before them or the end of the line
+	// go back to the paragraph level
+	levels := append([]uint8(nil), b.levels[first:last]...)
+	trailing := true
+	for i := last - 1; i >= first; i-- {
+		switch b.classes[i] {
+		case bidiS, bidiB:
+			levels[i-first] = paraLevel
+			trailing = true
+		case bidiWS, bidiLRI, bidiRLI, bidiFSI, bidiPDI, bidiBN, bidiLRE, bidiRLE, bidiLRO, bidiRLO, bidiPDF:
+			if trailing {
+				levels[i-first] = paraLevel
+			}
+		default:
+			trailing = false
+		}
+	}
+
+	runs := []BidiRun{}
+	maxLevel, minOdd := 0, bidiMaxDepth+2
+	for i, level := range levels {
+		l := int(level)
+		if n := len(runs); n > 0 && runs[n-1].Level == l {
+			runs[n-1].End = b.offsets[first+i+1]
+			continue
+		}
+		runs = append(runs, BidiRun{Start: b.offsets[first+i], End: b.offsets[first+i+1], Level: l})
+		if l > maxLevel {
+			maxLevel = l
+		}
+		if l%2 == 1 && l < minOdd {
+			minOdd = l
+		}
+	}
+
+	// L2
+	for level := maxLevel; level >= minOdd; level-- {
+		for i := 0; i < len(runs); i++ {
+			if runs[i].Level < level {
+				continue
+			}
+			j := i
+			for j < len(runs) && runs[j].Level >= level {
+				j++
+			}
+			for a, z := i, j-1; a < z; a, z = a+1, z-1 {
+				runs[a], runs[z] = runs[z], runs[a]
+			}
+			i = j
+		}
+	}
+	return runs
+}
@@ -0,0 +1,75 @@
+package fontcatalog
+
+import (
+	"testing"
+)
+
+// visual reorders text with the runs of Bidi, mirroring the characters of
+// right-to-left runs.
+func visual(text string, dir Direction) string {
+	b := NewBidi(text, dir)
+	ret := []rune{}
+	for _, run := range b.Runs(0, len(text)) {
+		rs := []rune(text[run.Start:run.End])
+		if run.Direction() == DirectionRTL {
+			for i, j := 0, len(rs)-1; i < j; i, j = i+1, j-1 {
+				rs[i], rs[j] = rs[j], rs[i]
+			}
+			for i := range rs {
+				if m, ok := bidiMirror(rs[i]); ok {
+					rs[i] = m
+				}
+			}
+		}
+		ret = append(ret, rs...)
+	}
+	return string(ret)
+}
+
+func TestBidi(t *testing.T) {
+	tests := []struct {
+		text string
+		dir  Direction
+		want string
+	}{
+		{"abc def", DirectionInvalid, "abc def"},
+		{"abc אבג def", DirectionInvalid, "abc גבא def"},
+		{"אבג abc", DirectionInvalid, "abc גבא"},
+		{"אבג abc", DirectionLTR, "גבא abc"},
+		{"abc אבג", DirectionRTL, "גבא abc"},
+		{"אבג 123 דהו", DirectionInvalid, "והד 123 גבא"},
+		{"ערך: 1,234.5%", DirectionInvalid, "1,234.5% :ךרע"},
+		{"مرحبا 123 world", DirectionLTR, "123 ابحرم world"},
+		{"אבג (def)", DirectionInvalid, "(def) גבא"},
+		{"abc (אבג) def", DirectionInvalid, "abc (גבא) def"},
+		{"(אבג)", DirectionInvalid, "(גבא)"},
+		{"a<b", DirectionRTL, "a<b"},
+		{"אבג<b", DirectionInvalid, "b>גבא"},
+		{"he said ⁧אבג⁩!", DirectionInvalid, "he said ⁧גבא⁩!"},
+		{"‮abc‬ def", DirectionInvalid, "‮‬cba def"},
+	}
+	for _, test := range tests {
+		if got := visual(test.text, test.dir); got != test.want {
+			t.Errorf("%q: got %q, want %q", test.text, got, test.want)
+		}
+	}
+
+	b := NewBidi("abc\nאבג abc", DirectionInvalid)
+	if b.Direction(0) != DirectionLTR || b.Direction(4) != DirectionRTL {
+		t.FailNow()
+	}
+	runs := b.Runs(4, len(b.text))
+	if len(runs) != 2 || runs[0].Level != 2 || runs[0].Start != 11 || runs[1].Level != 1 || runs[1].Start != 4 {
+		t.Fatalf("%+v", runs)
+	}
+
+	if m, ok := bidiMirror('('); !ok || m != ')' {
+		t.FailNow()
+	}
+	if m, ok := bidiMirror('≤'); !ok || m != '≥' {
+		t.FailNow()
+	}
+	if _, ok := bidiMirror('a'); ok {
+		t.FailNow()
+	}
+}
@@ -3,7 +3,9 @@ package fontcatalog
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"image"
+	"path"
 )
 
 type Charset struct {
@@ -68,6 +70,37 @@ type FontInfo struct {
 	Spacing      [2]int   `json:"spacing"`
 }
 
+type jsonBool bool
+
+func (b *jsonBool) UnmarshalJSON(data []byte) error {
+	switch string(data) {
+	case "true", "1":
+		*b = true
+	case "false", "0", "null":
+		*b = false
+	default:
+		return fmt.Errorf("cannot unmarshal %s into bool", data)
+	}
+	return nil
+}
+
+func (fi *FontInfo) UnmarshalJSON(data []byte) error {
+	type rawFontInfo FontInfo
+	aux := struct {
+		*rawFontInfo
+		Bold    jsonBool `json:"bold"`
+		Italic  jsonBool `json:"italic"`
+		Unicode jsonBool `json:"unicode"`
+	}{rawFontInfo: (*rawFontInfo)(fi)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	fi.Bold = bool(aux.Bold)
+	fi.Italic = bool(aux.Italic)
+	fi.Unicode = bool(aux.Unicode)
+	return nil
+}
+
 type FontCommon struct {
 	LineHeight   int         `json:"lineHeight"`
 	Base         int         `json:"base"`
@@ -131,6 +164,8 @@ type BitmapFont struct {
 	Kerning       KerningSort         `json:"kernings,omitempty"`
 	pagesMap      map[int]Page        `json:"-"`
 	pageSheets    map[int]image.Image `json:"-"`
+	planes        []glyphPlane
+	metrics       *FontMetrics
 }
 
 func (ur *BitmapFont) ToJson() (string, error) {
@@ -143,9 +178,54 @@ func (ur *BitmapFont) ToJson() (string, error) {
 	return string(b), e
 }
 
-func ReadBitmapFont(datas []byte) *BitmapFont {
+func (ur *BitmapFont) GetPageSheet(page int) image.Image {
+	return ur.pageSheets[page]
+}
+
+func (ur *BitmapFont) Write(out OutputWriter, dir string, filename string) error {
+	return ur.WriteFormat(out, dir, filename, BitmapFontJSON)
+}
+
+// WriteFormat writes the pages and the descriptor of the font. The binary
+// layout renames the pages of multi page fonts, see ToBinary.
+func (ur *BitmapFont) WriteFormat(out OutputWriter, dir string, filename string, format BitmapFontFormat) error {
+	if format == BitmapFontBinary {
+		bin := *ur
+		bin.Pages = binaryPageNames(ur.Pages)
+		ur = &bin
+	}
+	if err := ur.writePages(out, dir); err != nil {
+		return err
+	}
+	return ur.writeDescriptor(out, dir, filename, format)
+}
+
+func (ur *BitmapFont) writePages(out OutputWriter, dir string) error {
+	for p := range ur.Pages {
+		image, ok := ur.pageSheets[p]
+		if !ok {
+			continue
+		}
+		if err := writeImage(out, path.Join(dir, pageFile(ur.Pages[p])), image); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ur *BitmapFont) writeDescriptor(out OutputWriter, dir string, filename string, format BitmapFontFormat) error {
+	data, err := ur.Encode(format)
+	if err != nil {
+		return err
+	}
+	return out.WriteFile(path.Join(dir, filename+format.Ext()), data)
+}
+
+func ReadBitmapFont(datas []byte) (*BitmapFont, error) {
 	ts := &BitmapFont{}
 	data := bytes.NewBuffer(datas)
-	json.NewDecoder(data).Decode(&ts)
-	return ts
+	if err := json.NewDecoder(data).Decode(&ts); err != nil {
+		return nil, err
+	}
+	return ts, nil
 }
@@ -11,9 +11,9 @@ func TestReadBitmapFont(t *testing.T) {
 
 	data, _ := ioutil.ReadAll(f)
 
-	font := ReadBitmapFont(data)
+	font, err := ReadBitmapFont(data)
 
-	if font == nil {
+	if err != nil || font == nil {
 		t.FailNow()
 	}
 }
@@ -0,0 +1,655 @@
+package fontcatalog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+type BitmapFontFormat int
+
+const (
+	BitmapFontJSON BitmapFontFormat = iota
+	BitmapFontText
+	BitmapFontXML
+	BitmapFontBinary
+	BitmapFontMsdfAtlas
+)
+
+func (f BitmapFontFormat) Ext() string {
+	switch f {
+	case BitmapFontText:
+		return ".fnt"
+	case BitmapFontXML:
+		return ".xml"
+	case BitmapFontBinary:
+		return ".bin"
+	case BitmapFontMsdfAtlas:
+		return ".atlas.json"
+	default:
+		return ".json"
+	}
+}
+
+// Encode serializes the font in one of the AngelCode BMFont layouts. Text and
+// XML carry the glyph index and distance field as extra attributes, the binary
+// layout has no room for them. Kerning amounts are rounded to whole pixels
+// outside of JSON.
+func (ur *BitmapFont) Encode(format BitmapFontFormat) ([]byte, error) {
+	switch format {
+	case BitmapFontJSON:
+		data, err := ur.ToJson()
+		return []byte(data), err
+	case BitmapFontText:
+		data, err := ur.ToText()
+		return []byte(data), err
+	case BitmapFontXML:
+		data, err := ur.ToXML()
+		return []byte(data), err
+	case BitmapFontBinary:
+		return ur.ToBinary()
+	case BitmapFontMsdfAtlas:
+		data, err := ur.ToMsdfAtlas().ToJson()
+		return []byte(data), err
+	}
+	return nil, fmt.Errorf("unknown bitmap font format %d", format)
+}
+
+// DecodeBitmapFont reads a font in any of the BMFont formats written by
+// Encode, the msdf-atlas-gen layout is export only.
+func DecodeBitmapFont(data []byte) (*BitmapFont, error) {
+	trimmed := bytes.TrimLeft(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")), " \t\r\n")
+	switch {
+	case bytes.HasPrefix(data, []byte("BMF")):
+		return ReadBitmapFontBinary(data)
+	case bytes.HasPrefix(trimmed, []byte("<")):
+		return ReadBitmapFontXML(data)
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		return ReadBitmapFont(data)
+	default:
+		return ReadBitmapFontText(data)
+	}
+}
+
+// pageFile is the image of a page, pages are named without their .png
+// extension and multi page fonts name them X, X.1, X.2...
+func pageFile(page string) string {
+	return page + ".png"
+}
+
+func pageName(file string) string {
+	return strings.TrimSuffix(file, ".png")
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func kerningAmount(amount float64) int {
+	return int(math.Round(amount))
+}
+
+// finish restores what the BMFont layouts do not store explicitly.
+func (ur *BitmapFont) finish() {
+	ur.Info.Charset = make([]string, len(ur.Chars))
+	for i := range ur.Chars {
+		if ur.Info.Unicode {
+			ur.Chars[i].Char = string(rune(ur.Chars[i].ID))
+		}
+		ur.Info.Charset[i] = ur.Chars[i].Char
+	}
+	ur.pagesMap = make(map[int]Page)
+	for i, p := range ur.Pages {
+		ur.pagesMap[i] = Page{ID: i, File: pageFile(p)}
+	}
+}
+
+func (ur *BitmapFont) ToText() (string, error) {
+	var b strings.Builder
+	i, c := ur.Info, ur.Common
+	fmt.Fprintf(&b, "info face=\"%s\" size=%d bold=%d italic=%d charset=\"\" unicode=%d stretchH=%d smooth=%d aa=%d padding=%d,%d,%d,%d spacing=%d,%d outline=0\n",
+		i.Face, i.Size, boolInt(i.Bold), boolInt(i.Italic), boolInt(i.Unicode), i.StretchHeigt, i.Smooth, i.AA,
+		i.Padding[0], i.Padding[1], i.Padding[2], i.Padding[3], i.Spacing[0], i.Spacing[1])
+	fmt.Fprintf(&b, "common lineHeight=%d base=%d scaleW=%d scaleH=%d pages=%d packed=%d alphaChnl=%d redChnl=%d greenChnl=%d blueChnl=%d\n",
+		c.LineHeight, c.Base, c.ScaleW, c.ScaleH, c.Pages, c.Packed, c.AlphaChannel, c.RedChannel, c.GreenChannel, c.BlueChannel)
+	for id, p := range ur.Pages {
+		fmt.Fprintf(&b, "page id=%d file=\"%s\"\n", id, pageFile(p))
+	}
+	if ur.DistanceField.FieldType != "" {
+		fmt.Fprintf(&b, "distanceField fieldType=%s distanceRange=%s\n", ur.DistanceField.FieldType, strconv.FormatFloat(ur.DistanceField.DistanceRange, 'f', -1, 64))
+	}
+	fmt.Fprintf(&b, "chars count=%d\n", len(ur.Chars))
+	for _, ch := range ur.Chars {
+		fmt.Fprintf(&b, "char id=%d x=%d y=%d width=%d height=%d xoffset=%d yoffset=%d xadvance=%d page=%d chnl=%d index=%d\n",
+			ch.ID, ch.X, ch.Y, ch.Width, ch.Height, ch.XOffset, ch.YOffset, ch.XAdvance, ch.Page, ch.Channel, ch.Index)
+	}
+	if len(ur.Kerning) > 0 {
+		fmt.Fprintf(&b, "kernings count=%d\n", len(ur.Kerning))
+		for _, k := range ur.Kerning {
+			fmt.Fprintf(&b, "kerning first=%d second=%d amount=%d\n", k.First, k.Second, kerningAmount(k.Amount))
+		}
+	}
+	return b.String(), nil
+}
+
+// parseTextLine splits a BMFont text line into its tag and attributes,
+// quoted values may contain spaces.
+func parseTextLine(line string) (string, map[string]string) {
+	attrs := make(map[string]string)
+	line = strings.TrimSpace(line)
+	sp := strings.IndexAny(line, " \t")
+	if sp < 0 {
+		return line, attrs
+	}
+	tag, rest := line[:sp], line[sp:]
+	for {
+		rest = strings.TrimLeft(rest, " \t")
+		if rest == "" {
+			break
+		}
+		eq := strings.IndexByte(rest, '=')
+		if eq < 0 {
+			break
+		}
+		key := rest[:eq]
+		rest = rest[eq+1:]
+		var value string
+		if strings.HasPrefix(rest, "\"") {
+			end := strings.IndexByte(rest[1:], '"')
+			if end < 0 {
+				value, rest = rest[1:], ""
+			} else {
+				value, rest = rest[1:end+1], rest[end+2:]
+			}
+		} else {
+			end := strings.IndexAny(rest, " \t")
+			if end < 0 {
+				value, rest = rest, ""
+			} else {
+				value, rest = rest[:end], rest[end:]
+			}
+		}
+		attrs[key] = value
+	}
+	return tag, attrs
+}
+
+type attrReader struct {
+	attrs map[string]string
+	err   error
+}
+
+func (r *attrReader) int(key string) int {
+	v, ok := r.attrs[key]
+	if !ok || r.err != nil {
+		return 0
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		r.err = fmt.Errorf("%s: %w", key, err)
+	}
+	return n
+}
+
+func (r *attrReader) float(key string) float64 {
+	v, ok := r.attrs[key]
+	if !ok || r.err != nil {
+		return 0
+	}
+	n, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		r.err = fmt.Errorf("%s: %w", key, err)
+	}
+	return n
+}
+
+func (r *attrReader) ints(key string, dst []int) {
+	v, ok := r.attrs[key]
+	if !ok || r.err != nil {
+		return
+	}
+	parts := strings.Split(v, ",")
+	if len(parts) != len(dst) {
+		r.err = fmt.Errorf("%s: expected %d values, got %q", key, len(dst), v)
+		return
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(strings.TrimSpace(p))
+		if err != nil {
+			r.err = fmt.Errorf("%s: %w", key, err)
+			return
+		}
+		dst[i] = n
+	}
+}
+
+func (ur *BitmapFont) readAttrs(tag string, r *attrReader) {
+	switch tag {
+	case "info":
+		ur.Info.Face = r.attrs["face"]
+		ur.Info.Size = r.int("size")
+		ur.Info.Bold = r.int("bold") != 0
+		ur.Info.Italic = r.int("italic") != 0
+		ur.Info.Unicode = r.int("unicode") != 0
+		ur.Info.StretchHeigt = r.int("stretchH")
+		ur.Info.Smooth = r.int("smooth")
+		ur.Info.AA = r.int("aa")
+		r.ints("padding", ur.Info.Padding[:])
+		r.ints("spacing", ur.Info.Spacing[:])
+	case "common":
+		ur.Common = FontCommon{
+			LineHeight:   r.int("lineHeight"),
+			Base:         r.int("base"),
+			ScaleW:       r.int("scaleW"),
+			ScaleH:       r.int("scaleH"),
+			Pages:        r.int("pages"),
+			Packed:       r.int("packed"),
+			AlphaChannel: ChannelInfo(r.int("alphaChnl")),
+			RedChannel:   ChannelInfo(r.int("redChnl")),
+			GreenChannel: ChannelInfo(r.int("greenChnl")),
+			BlueChannel:  ChannelInfo(r.int("blueChnl")),
+		}
+	case "page":
+		id := r.int("id")
+		if r.err == nil && (id < 0 || id > len(ur.Pages)) {
+			r.err = fmt.Errorf("page id %d out of order", id)
+			return
+		}
+		if id == len(ur.Pages) {
+			ur.Pages = append(ur.Pages, "")
+		}
+		ur.Pages[id] = pageName(r.attrs["file"])
+	case "distanceField":
+		ur.DistanceField = DistanceField{FieldType: r.attrs["fieldType"], DistanceRange: r.float("distanceRange")}
+	case "char":
+		ch := Charset{
+			ID:       r.int("id"),
+			X:        r.int("x"),
+			Y:        r.int("y"),
+			Width:    r.int("width"),
+			Height:   r.int("height"),
+			XOffset:  r.int("xoffset"),
+			YOffset:  r.int("yoffset"),
+			XAdvance: r.int("xadvance"),
+			Page:     r.int("page"),
+			Channel:  Channel(r.int("chnl")),
+		}
+		ch.Index = ch.ID
+		if _, ok := r.attrs["index"]; ok {
+			ch.Index = r.int("index")
+		}
+		ur.Chars = append(ur.Chars, ch)
+	case "kerning":
+		ur.Kerning = append(ur.Kerning, Kerning{
+			First:  rune(r.int("first")),
+			Second: rune(r.int("second")),
+			Amount: float64(r.int("amount")),
+		})
+	}
+}
+
+func ReadBitmapFontText(datas []byte) (*BitmapFont, error) {
+	ur := &BitmapFont{}
+	scanner := bufio.NewScanner(bytes.NewReader(datas))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		tag, attrs := parseTextLine(scanner.Text())
+		r := &attrReader{attrs: attrs}
+		ur.readAttrs(tag, r)
+		if r.err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, r.err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if line == 0 {
+		return nil, errors.New("empty bitmap font")
+	}
+	ur.finish()
+	return ur, nil
+}
+
+func (ur *BitmapFont) ToXML() (string, error) {
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	enc := xml.NewEncoder(&b)
+	enc.Indent("", "  ")
+
+	elem := func(name string, attrs ...string) xml.StartElement {
+		se := xml.StartElement{Name: xml.Name{Local: name}}
+		for i := 0; i+1 < len(attrs); i += 2 {
+			se.Attr = append(se.Attr, xml.Attr{Name: xml.Name{Local: attrs[i]}, Value: attrs[i+1]})
+		}
+		return se
+	}
+	itoa := strconv.Itoa
+	// the first encoding error is kept and the tokens after it skipped
+	var err error
+	token := func(t xml.Token) {
+		if err == nil {
+			err = enc.EncodeToken(t)
+		}
+	}
+	empty := func(se xml.StartElement) {
+		token(se)
+		token(se.End())
+	}
+
+	i, c := ur.Info, ur.Common
+	root := elem("font")
+	token(root)
+	empty(elem("info", "face", i.Face, "size", itoa(i.Size), "bold", itoa(boolInt(i.Bold)), "italic", itoa(boolInt(i.Italic)),
+		"charset", "", "unicode", itoa(boolInt(i.Unicode)), "stretchH", itoa(i.StretchHeigt), "smooth", itoa(i.Smooth), "aa", itoa(i.AA),
+		"padding", fmt.Sprintf("%d,%d,%d,%d", i.Padding[0], i.Padding[1], i.Padding[2], i.Padding[3]),
+		"spacing", fmt.Sprintf("%d,%d", i.Spacing[0], i.Spacing[1]), "outline", "0"))
+	empty(elem("common", "lineHeight", itoa(c.LineHeight), "base", itoa(c.Base), "scaleW", itoa(c.ScaleW), "scaleH", itoa(c.ScaleH),
+		"pages", itoa(c.Pages), "packed", itoa(c.Packed), "alphaChnl", itoa(int(c.AlphaChannel)), "redChnl", itoa(int(c.RedChannel)),
+		"greenChnl", itoa(int(c.GreenChannel)), "blueChnl", itoa(int(c.BlueChannel))))
+
+	pages := elem("pages")
+	token(pages)
+	for id, p := range ur.Pages {
+		empty(elem("page", "id", itoa(id), "file", pageFile(p)))
+	}
+	token(pages.End())
+
+	if ur.DistanceField.FieldType != "" {
+		empty(elem("distanceField", "fieldType", ur.DistanceField.FieldType, "distanceRange", strconv.FormatFloat(ur.DistanceField.DistanceRange, 'f', -1, 64)))
+	}
+
+	chars := elem("chars", "count", itoa(len(ur.Chars)))
+	token(chars)
+	for _, ch := range ur.Chars {
+		empty(elem("char", "id", itoa(ch.ID), "x", itoa(ch.X), "y", itoa(ch.Y), "width", itoa(ch.Width), "height", itoa(ch.Height),
+			"xoffset", itoa(ch.XOffset), "yoffset", itoa(ch.YOffset), "xadvance", itoa(ch.XAdvance), "page", itoa(ch.Page),
+			"chnl", itoa(int(ch.Channel)), "index", itoa(ch.Index)))
+	}
+	token(chars.End())
+
+	if len(ur.Kerning) > 0 {
+		kernings := elem("kernings", "count", itoa(len(ur.Kerning)))
+		token(kernings)
+		for _, k := range ur.Kerning {
+			empty(elem("kerning", "first", itoa(int(k.First)), "second", itoa(int(k.Second)), "amount", itoa(kerningAmount(k.Amount))))
+		}
+		token(kernings.End())
+	}
+
+	token(root.End())
+	if err != nil {
+		return "", err
+	}
+	if err := enc.Flush(); err != nil {
+		return "", err
+	}
+	b.WriteString("\n")
+	return b.String(), nil
+}
+
+func ReadBitmapFontXML(datas []byte) (*BitmapFont, error) {
+	ur := &BitmapFont{}
+	dec := xml.NewDecoder(bytes.NewReader(datas))
+	root := false
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, err
+		}
+		se, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if se.Name.Local == "font" {
+			root = true
+			continue
+		}
+		attrs := make(map[string]string, len(se.Attr))
+		for _, a := range se.Attr {
+			attrs[a.Name.Local] = a.Value
+		}
+		r := &attrReader{attrs: attrs}
+		ur.readAttrs(se.Name.Local, r)
+		if r.err != nil {
+			return nil, fmt.Errorf("%s: %w", se.Name.Local, r.err)
+		}
+	}
+	if !root {
+		return nil, errors.New("missing font element")
+	}
+	ur.finish()
+	return ur, nil
+}
+
+const (
+	bmfBlockInfo    = 1
+	bmfBlockCommon  = 2
+	bmfBlockPages   = 3
+	bmfBlockChars   = 4
+	bmfBlockKerning = 5
+
+	bmfInfoSmooth   = 0x80
+	bmfInfoUnicode  = 0x40
+	bmfInfoItalic   = 0x20
+	bmfInfoBold     = 0x10
+	bmfCommonPacked = 0x01
+)
+
+// ToBinary encodes the font in the BMFont binary layout version 3. Page names
+// of different lengths are renamed by binaryPageNames, WriteFormat writes the
+// page images under the new names.
+func (ur *BitmapFont) ToBinary() ([]byte, error) {
+	var out bytes.Buffer
+	out.WriteString("BMF\x03")
+
+	block := func(typ byte, data []byte) {
+		out.WriteByte(typ)
+		binary.Write(&out, binary.LittleEndian, uint32(len(data)))
+		out.Write(data)
+	}
+	le := binary.LittleEndian
+
+	var b bytes.Buffer
+	i := ur.Info
+	var bits uint8
+	if i.Smooth != 0 {
+		bits |= bmfInfoSmooth
+	}
+	if i.Unicode {
+		bits |= bmfInfoUnicode
+	}
+	if i.Italic {
+		bits |= bmfInfoItalic
+	}
+	if i.Bold {
+		bits |= bmfInfoBold
+	}
+	binary.Write(&b, le, int16(i.Size))
+	b.WriteByte(bits)
+	b.WriteByte(0)
+	binary.Write(&b, le, uint16(i.StretchHeigt))
+	b.WriteByte(uint8(i.AA))
+	for _, p := range i.Padding {
+		b.WriteByte(uint8(p))
+	}
+	b.WriteByte(uint8(i.Spacing[0]))
+	b.WriteByte(uint8(i.Spacing[1]))
+	b.WriteByte(0)
+	b.WriteString(i.Face)
+	b.WriteByte(0)
+	block(bmfBlockInfo, b.Bytes())
+
+	b.Reset()
+	c := ur.Common
+	var packed uint8
+	if c.Packed != 0 {
+		packed = bmfCommonPacked
+	}
+	binary.Write(&b, le, []uint16{uint16(c.LineHeight), uint16(c.Base), uint16(c.ScaleW), uint16(c.ScaleH), uint16(c.Pages)})
+	b.Write([]byte{packed, uint8(c.AlphaChannel), uint8(c.RedChannel), uint8(c.GreenChannel), uint8(c.BlueChannel)})
+	block(bmfBlockCommon, b.Bytes())
+
+	b.Reset()
+	for _, p := range binaryPageNames(ur.Pages) {
+		b.WriteString(pageFile(p))
+		b.WriteByte(0)
+	}
+	block(bmfBlockPages, b.Bytes())
+
+	b.Reset()
+	for _, ch := range ur.Chars {
+		binary.Write(&b, le, uint32(ch.ID))
+		binary.Write(&b, le, []uint16{uint16(ch.X), uint16(ch.Y), uint16(ch.Width), uint16(ch.Height)})
+		binary.Write(&b, le, []int16{int16(ch.XOffset), int16(ch.YOffset), int16(ch.XAdvance)})
+		b.Write([]byte{uint8(ch.Page), uint8(ch.Channel)})
+	}
+	block(bmfBlockChars, b.Bytes())
+
+	if len(ur.Kerning) > 0 {
+		b.Reset()
+		for _, k := range ur.Kerning {
+			binary.Write(&b, le, []uint32{uint32(k.First), uint32(k.Second)})
+			binary.Write(&b, le, int16(kerningAmount(k.Amount)))
+		}
+		block(bmfBlockKerning, b.Bytes())
+	}
+
+	return out.Bytes(), nil
+}
+
+// binaryPageNames renames pages for the binary layout, whose page names must
+// all have the same length. Pages of different lengths become X.0, X.1...
+// with zero padded numbers, X being the name of the first page.
+func binaryPageNames(pages []string) []string {
+	equal := true
+	for _, p := range pages {
+		equal = equal && len(p) == len(pages[0])
+	}
+	if equal {
+		return pages
+	}
+	digits := len(strconv.Itoa(len(pages) - 1))
+	ret := make([]string, len(pages))
+	for i := range pages {
+		ret[i] = fmt.Sprintf("%s.%0*d", pages[0], digits, i)
+	}
+	return ret
+}
+
+func ReadBitmapFontBinary(datas []byte) (*BitmapFont, error) {
+	if len(datas) < 4 || string(datas[:3]) != "BMF" {
+		return nil, errors.New("not a binary bitmap font")
+	}
+	if datas[3] != 3 {
+		return nil, fmt.Errorf("unsupported binary bitmap font version %d", datas[3])
+	}
+	le := binary.LittleEndian
+	ur := &BitmapFont{}
+
+	rest := datas[4:]
+	for len(rest) > 0 {
+		if len(rest) < 5 {
+			return nil, errors.New("truncated block header")
+		}
+		typ, size := rest[0], int(le.Uint32(rest[1:5]))
+		if len(rest)-5 < size {
+			return nil, fmt.Errorf("block %d: truncated", typ)
+		}
+		b := rest[5 : 5+size]
+		rest = rest[5+size:]
+
+		switch typ {
+		case bmfBlockInfo:
+			if len(b) < 15 {
+				return nil, errors.New("info block too short")
+			}
+			bits := b[2]
+			ur.Info.Size = int(int16(le.Uint16(b[0:])))
+			ur.Info.Smooth = boolInt(bits&bmfInfoSmooth != 0)
+			ur.Info.Unicode = bits&bmfInfoUnicode != 0
+			ur.Info.Italic = bits&bmfInfoItalic != 0
+			ur.Info.Bold = bits&bmfInfoBold != 0
+			ur.Info.StretchHeigt = int(le.Uint16(b[4:]))
+			ur.Info.AA = int(b[6])
+			for i := range ur.Info.Padding {
+				ur.Info.Padding[i] = int(b[7+i])
+			}
+			ur.Info.Spacing = [2]int{int(b[11]), int(b[12])}
+			face := b[14:]
+			if n := bytes.IndexByte(face, 0); n >= 0 {
+				face = face[:n]
+			}
+			ur.Info.Face = string(face)
+		case bmfBlockCommon:
+			if len(b) < 15 {
+				return nil, errors.New("common block too short")
+			}
+			ur.Common = FontCommon{
+				LineHeight:   int(le.Uint16(b[0:])),
+				Base:         int(le.Uint16(b[2:])),
+				ScaleW:       int(le.Uint16(b[4:])),
+				ScaleH:       int(le.Uint16(b[6:])),
+				Pages:        int(le.Uint16(b[8:])),
+				Packed:       boolInt(b[10]&bmfCommonPacked != 0),
+				AlphaChannel: ChannelInfo(b[11]),
+				RedChannel:   ChannelInfo(b[12]),
+				GreenChannel: ChannelInfo(b[13]),
+				BlueChannel:  ChannelInfo(b[14]),
+			}
+		case bmfBlockPages:
+			for _, name := range bytes.Split(bytes.TrimSuffix(b, []byte{0}), []byte{0}) {
+				if len(name) > 0 {
+					ur.Pages = append(ur.Pages, pageName(string(name)))
+				}
+			}
+		case bmfBlockChars:
+			if len(b)%20 != 0 {
+				return nil, errors.New("chars block has a partial entry")
+			}
+			for ; len(b) > 0; b = b[20:] {
+				id := int(le.Uint32(b[0:]))
+				ur.Chars = append(ur.Chars, Charset{
+					ID:       id,
+					Index:    id,
+					X:        int(le.Uint16(b[4:])),
+					Y:        int(le.Uint16(b[6:])),
+					Width:    int(le.Uint16(b[8:])),
+					Height:   int(le.Uint16(b[10:])),
+					XOffset:  int(int16(le.Uint16(b[12:]))),
+					YOffset:  int(int16(le.Uint16(b[14:]))),
+					XAdvance: int(int16(le.Uint16(b[16:]))),
+					Page:     int(b[18]),
+					Channel:  Channel(b[19]),
+				})
+			}
+		case bmfBlockKerning:
+			if len(b)%10 != 0 {
+				return nil, errors.New("kerning block has a partial entry")
+			}
+			for ; len(b) > 0; b = b[10:] {
+				ur.Kerning = append(ur.Kerning, Kerning{
+					First:  rune(le.Uint32(b[0:])),
+					Second: rune(le.Uint32(b[4:])),
+					Amount: float64(int16(le.Uint16(b[8:]))),
+				})
+			}
+		}
+	}
+	ur.finish()
+	return ur, nil
+}
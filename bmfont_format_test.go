@@ -0,0 +1,108 @@
+package fontcatalog
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestBitmapFontFormatsRoundTrip(t *testing.T) {
+	data, err := ioutil.ReadFile("./fonts/FiraGO_Map.ttf")
+	if err != nil {
+		t.FailNow()
+	}
+
+	opts := DefaultBitmapFontOptions("Basic_Latin")
+	bmfont := NewBitmapFontGenerater(NewFontHolder(data), NewCharsetsASCII(), 32, 8, opts).Generate()
+	if bmfont == nil {
+		t.FailNow()
+	}
+	bmfont.Kerning = append(bmfont.Kerning, Kerning{First: 'A', Second: 'V', Amount: -2})
+	want, _ := bmfont.ToJson()
+
+	for _, format := range []BitmapFontFormat{BitmapFontJSON, BitmapFontText, BitmapFontXML, BitmapFontBinary} {
+		encoded, err := bmfont.Encode(format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := DecodeBitmapFont(encoded)
+		if err != nil {
+			t.Fatalf("format %d: %v", format, err)
+		}
+		if format == BitmapFontBinary {
+			decoded.DistanceField = bmfont.DistanceField
+			for i := range decoded.Chars {
+				decoded.Chars[i].Index = bmfont.Chars[i].Index
+			}
+		}
+		got, _ := decoded.ToJson()
+		if got != want {
+			t.Fatalf("format %d does not round trip:\n%s\n%s", format, got, want)
+		}
+	}
+
+}
+
+func TestBitmapFontFormatsMultiPage(t *testing.T) {
+	data, err := ioutil.ReadFile("./fonts/FiraGO_Map.ttf")
+	if err != nil {
+		t.FailNow()
+	}
+	opts := DefaultBitmapFontOptions("Basic_Latin")
+	opts.TextureSize = []int{128, 128}
+	bmfont := NewBitmapFontGenerater(NewFontHolder(data), NewCharsetsASCII(), 32, 8, opts).Generate()
+	if bmfont == nil || len(bmfont.Pages) < 2 {
+		t.FailNow()
+	}
+
+	// every descriptor names page images that were written
+	for _, format := range []BitmapFontFormat{BitmapFontJSON, BitmapFontText, BitmapFontXML, BitmapFontBinary} {
+		dir := t.TempDir()
+		if err := bmfont.WriteFormat(NewDirOutput(dir), "", "Basic_Latin", format); err != nil {
+			t.Fatalf("format %d: %v", format, err)
+		}
+		encoded, err := ioutil.ReadFile(dir + "/Basic_Latin" + format.Ext())
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := DecodeBitmapFont(encoded)
+		if err != nil {
+			t.Fatalf("format %d: %v", format, err)
+		}
+		if len(decoded.Pages) != len(bmfont.Pages) || len(decoded.Chars) != len(bmfont.Chars) {
+			t.Fatalf("format %d: %v", format, decoded.Pages)
+		}
+		for _, p := range decoded.Pages {
+			if _, err := os.Stat(dir + "/" + pageFile(p)); err != nil {
+				t.Fatalf("format %d: %v", format, err)
+			}
+		}
+		for i, c := range decoded.Chars {
+			if c.Page != bmfont.Chars[i].Page || c.Bounds() != bmfont.Chars[i].Bounds() {
+				t.Fatalf("format %d: %+v", format, c)
+			}
+		}
+	}
+}
+
+func TestReadBitmapFontText(t *testing.T) {
+	font, err := ReadBitmapFontText([]byte(`info face="Fira GO" size=32 bold=0 italic=1 charset="" unicode=1 stretchH=100 smooth=1 aa=1 padding=4,4,4,4 spacing=0,0
+common lineHeight=38 base=30 scaleW=256 scaleH=256 pages=1 packed=0 alphaChnl=4 redChnl=0 greenChnl=0 blueChnl=0
+page id=0 file="a b.png"
+chars count=1
+char id=65   x=1 y=2 width=20 height=24 xoffset=-1 yoffset=6 xadvance=19 page=0 chnl=15
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if font.Info.Face != "Fira GO" || !font.Info.Italic || font.Pages[0] != "a b" || font.Common.AlphaChannel != One {
+		t.FailNow()
+	}
+	if len(font.Chars) != 1 || font.Chars[0].Char != "A" || font.Chars[0].XOffset != -1 {
+		t.FailNow()
+	}
+
+	if _, err := ReadBitmapFontText([]byte("char id=x\n")); err == nil {
+		t.FailNow()
+	}
+}
@@ -0,0 +1,240 @@
+package fontcatalog
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// cacheVersion is part of every build key, bump it whenever the generator
+// output changes for identical inputs.
+const cacheVersion = 4
+
+type BuildInputs struct {
+	Font      string             `json:"font"`
+	FontHash  string             `json:"fontHash"`
+	Face      int                `json:"face,omitempty"`
+	Instance  string             `json:"instance,omitempty"`
+	Variation map[string]float64 `json:"variation,omitempty"`
+	Block     string             `json:"block"`
+	Style     string             `json:"style"`
+	Size      int                `json:"size"`
+	Distance  int                `json:"distance"`
+	FieldType string             `json:"fieldType"`
+	Blocks    []string           `json:"blocks,omitempty"`
+	Output    string             `json:"output"`
+	Options   BitmapFontOptions  `json:"options"`
+	Parts     []BuildInputs      `json:"parts,omitempty"`
+}
+
+// Key hashes everything that influences the generated assets. The font path
+// is left out so moving the fonts directory keeps the cache valid, the worker
+// count is left out because it does not change the output.
+func (i BuildInputs) Key() string {
+	k := i
+	k.Font = ""
+	k.Options.Workers = 0
+	k.Parts = make([]BuildInputs, len(i.Parts))
+	for p, part := range i.Parts {
+		part.Font = ""
+		k.Parts[p] = part
+	}
+	data, _ := json.Marshal(struct {
+		Version int         `json:"version"`
+		Inputs  BuildInputs `json:"inputs"`
+	}{cacheVersion, k})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+type BuildRecord struct {
+	Key        string        `json:"key"`
+	Inputs     BuildInputs   `json:"inputs"`
+	Outputs    []string      `json:"outputs"`
+	LineHeight int           `json:"lineHeight"`
+	Base       int           `json:"base"`
+	MaxWidth   float64       `json:"maxWidth"`
+	MaxHeight  float64       `json:"maxHeight"`
+	Parts      []BuildRecord `json:"parts,omitempty"`
+}
+
+type BuildManifest struct {
+	Version int           `json:"version"`
+	Records []BuildRecord `json:"records"`
+}
+
+func (m *BuildManifest) ToJson() (string, error) {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func hashFontData(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+const cacheManifestName = "manifest.json"
+
+// BuildCache keeps a copy of every generated asset under dir, indexed by the
+// key of the inputs that produced it.
+type BuildCache struct {
+	mu       sync.Mutex
+	dir      string
+	previous map[string]BuildRecord
+	used     map[string]BuildRecord
+	hits     int
+	misses   int
+}
+
+func NewBuildCache(dir string) (*BuildCache, error) {
+	c := &BuildCache{dir: dir, previous: make(map[string]BuildRecord), used: make(map[string]BuildRecord)}
+	data, err := ioutil.ReadFile(filepath.Join(dir, cacheManifestName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var m BuildManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	if m.Version != cacheVersion {
+		return c, nil
+	}
+	for _, rec := range m.Records {
+		c.previous[rec.Key] = rec
+	}
+	return c, nil
+}
+
+func (c *BuildCache) Hits() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits
+}
+
+func (c *BuildCache) Misses() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.misses
+}
+
+func (c *BuildCache) assetPath(key, name string) string {
+	return filepath.Join(c.dir, key, filepath.FromSlash(name))
+}
+
+// restore copies the cached assets of key into out. A missing or unreadable
+// asset is reported as a miss so the caller regenerates it.
+func (c *BuildCache) restore(key string, out OutputWriter) (*BuildRecord, bool, error) {
+	c.mu.Lock()
+	rec, ok := c.previous[key]
+	c.mu.Unlock()
+	if !ok {
+		return nil, false, nil
+	}
+
+	files := make([][]byte, len(rec.Outputs))
+	for i, name := range rec.Outputs {
+		data, err := ioutil.ReadFile(c.assetPath(key, name))
+		if err != nil {
+			return nil, false, nil
+		}
+		files[i] = data
+	}
+	for i, name := range rec.Outputs {
+		if err := out.WriteFile(name, files[i]); err != nil {
+			return nil, false, err
+		}
+	}
+
+	c.mu.Lock()
+	c.used[key] = rec
+	c.hits++
+	c.mu.Unlock()
+	return &rec, true, nil
+}
+
+func (c *BuildCache) store(rec *BuildRecord, files map[string][]byte) error {
+	if err := os.RemoveAll(filepath.Join(c.dir, rec.Key)); err != nil {
+		return err
+	}
+	assets := NewDirOutput(filepath.Join(c.dir, rec.Key))
+	for _, name := range rec.Outputs {
+		if err := assets.WriteFile(name, files[name]); err != nil {
+			return err
+		}
+	}
+	c.mu.Lock()
+	c.used[rec.Key] = *rec
+	c.misses++
+	c.mu.Unlock()
+	return nil
+}
+
+// Save writes the cache manifest and drops the assets of every entry that
+// was not used by the last build.
+func (c *BuildCache) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.previous {
+		if _, ok := c.used[key]; !ok {
+			if err := os.RemoveAll(filepath.Join(c.dir, key)); err != nil {
+				return err
+			}
+		}
+	}
+
+	m := &BuildManifest{Version: cacheVersion}
+	for _, rec := range c.used {
+		m.Records = append(m.Records, rec)
+	}
+	sort.Slice(m.Records, func(i, j int) bool { return m.Records[i].Key < m.Records[j].Key })
+	data, err := m.ToJson()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(c.dir, os.ModePerm); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(c.dir, cacheManifestName), []byte(data), os.ModePerm); err != nil {
+		return err
+	}
+
+	c.previous = c.used
+	c.used = make(map[string]BuildRecord)
+	return nil
+}
+
+type recordingOutput struct {
+	out   OutputWriter
+	keep  bool
+	names []string
+	files map[string][]byte
+}
+
+func newRecordingOutput(out OutputWriter, keep bool) *recordingOutput {
+	return &recordingOutput{out: out, keep: keep, files: make(map[string][]byte)}
+}
+
+func (o *recordingOutput) WriteFile(name string, data []byte) error {
+	if err := o.out.WriteFile(name, data); err != nil {
+		return err
+	}
+	o.names = append(o.names, name)
+	if o.keep {
+		o.files[name] = append([]byte(nil), data...)
+	}
+	return nil
+}
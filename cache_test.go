@@ -0,0 +1,58 @@
+package fontcatalog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestBuildCacheReuse(t *testing.T) {
+	desc := `{"name":"Test","size":32,"distance":8,"type":"sdf","fontsDir":"./fonts","fonts":[{"name":"FiraGO_Map","blocks":["Basic Latin","Latin-1 Supplement"]}]}`
+	dir := t.TempDir()
+
+	build := func() (*MemoryOutput, *BuildCache) {
+		fcd, err := ReadFontCatalogDescription(strings.NewReader(desc))
+		if err != nil {
+			t.Fatal(err)
+		}
+		cache, err := NewBuildCache(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		opts := DefaultBitmapFontOptions("")
+		gen := NewFontCatalogGenerater(fcd, &opts)
+		gen.SetCache(cache)
+		out := NewMemoryOutput()
+		if err := gen.GenerateTo(out); err != nil {
+			t.Fatal(err)
+		}
+		return out, cache
+	}
+
+	first, cache := build()
+	if cache.Hits() != 0 || cache.Misses() != 3 {
+		t.Fatalf("first build: hits %d misses %d", cache.Hits(), cache.Misses())
+	}
+
+	second, cache := build()
+	if cache.Hits() != 3 || cache.Misses() != 0 {
+		t.Fatalf("second build: hits %d misses %d", cache.Hits(), cache.Misses())
+	}
+
+	names := first.Names()
+	if strings.Join(names, ",") != strings.Join(second.Names(), ",") {
+		t.FailNow()
+	}
+	for _, name := range names {
+		a, _ := first.ReadFile(name)
+		b, _ := second.ReadFile(name)
+		if !bytes.Equal(a, b) {
+			t.Fatalf("%s differs between builds", name)
+		}
+	}
+
+	manifest, err := second.ReadFile("Test_Manifest.json")
+	if err != nil || !bytes.Contains(manifest, []byte(`"Test_Assets/FiraGO_Map/Basic_Latin.png"`)) {
+		t.FailNow()
+	}
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	fontcatalog "github.com/flywave/go-fontcatalog"
+)
+
+type intPairValue struct {
+	p *[]int
+}
+
+func (v intPairValue) String() string {
+	if v.p == nil || len(*v.p) != 2 {
+		return ""
+	}
+	return fmt.Sprintf("%d,%d", (*v.p)[0], (*v.p)[1])
+}
+
+func (v intPairValue) Set(s string) error {
+	parts := strings.Split(s, ",")
+	if len(parts) == 1 {
+		parts = append(parts, parts[0])
+	}
+	if len(parts) != 2 {
+		return fmt.Errorf("expected two comma separated integers, got %q", s)
+	}
+	pair := make([]int, 2)
+	for i, part := range parts {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return err
+		}
+		pair[i] = n
+	}
+	*v.p = pair
+	return nil
+}
+
+type enumValue struct {
+	names []string
+	set   func(int)
+	get   func() int
+}
+
+func (v enumValue) String() string {
+	if v.get == nil {
+		return ""
+	}
+	i := v.get()
+	if i < 0 || i >= len(v.names) {
+		return strconv.Itoa(i)
+	}
+	return v.names[i]
+}
+
+func (v enumValue) Set(s string) error {
+	for i, name := range v.names {
+		if strings.EqualFold(name, s) {
+			v.set(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("expected one of %s, got %q", strings.Join(v.names, "|"), s)
+}
+
+var algorithmNames = []string{"maxrects", "skyline", "guillotine", "all"}
+
+var heuristicNames = []string{"bssf", "baf", "bl", "blsf", "cp"}
+
+var edgeColoringNames = []string{"simple", "inktrap", "distance"}
+
+var pageSizeNames = []string{"crop", "pot", "square", "pot-square", "fixed"}
+
+var formatNames = []string{"json", "txt", "xml", "bin", "msdf-atlas"}
+
+var identifierNames = []string{"unicode", "glyph"}
+
+var identifierTypes = []fontcatalog.GlyphIdentifierType{fontcatalog.UNICODE_CODEPOINT, fontcatalog.GLYPH_INDEX}
+
+func registerOptionFlags(fs *flag.FlagSet, opts *fontcatalog.BitmapFontOptions) {
+	fs.StringVar(&opts.Filename, "filename", opts.Filename, "base name of the generated atlas pages and json")
+	fs.Var(intPairValue{&opts.FontSpacing}, "spacing", "glyph spacing `x,y` recorded in the font info")
+	fs.Var(intPairValue{&opts.TextureSize}, "texture-size", "atlas page size `w,h`")
+	fs.Var(intPairValue{&opts.TexturePadding}, "texture-padding", "padding `x,y` between packed glyphs")
+	fs.StringVar(&opts.FieldType, "type", opts.FieldType, "field type: hardmask|softmask|sdf|psdf|msdf|mtsdf")
+	fs.BoolVar(&opts.AllowRotation, "allow-rotation", opts.AllowRotation, "allow glyphs to be rotated when packing")
+	fs.Var(enumValue{
+		names: heuristicNames,
+		set:   func(i int) { opts.PackerMethod = fontcatalog.FreeRectChoiceHeuristic(i) },
+		get:   func() int { return int(opts.PackerMethod) },
+	}, "packer", "free rect heuristic: "+strings.Join(heuristicNames, "|"))
+	fs.Var(enumValue{
+		names: algorithmNames,
+		set:   func(i int) { opts.Packer = fontcatalog.PackerAlgorithm(i) },
+		get:   func() int { return int(opts.Packer) },
+	}, "packer-algorithm", "packing algorithm, all keeps the one doing best on the first glyphs: "+strings.Join(algorithmNames, "|"))
+	fs.IntVar(&opts.Limit, "limit", opts.Limit, "maximum number of glyphs per page, 0 packs pages until they are full")
+	fs.Var(enumValue{
+		names: pageSizeNames,
+		set:   func(i int) { opts.PageSize = fontcatalog.PageSizeMode(i) },
+		get:   func() int { return int(opts.PageSize) },
+	}, "page-size", "atlas page size: "+strings.Join(pageSizeNames, "|"))
+	fs.Var(enumValue{
+		names: edgeColoringNames,
+		set:   func(i int) { opts.EdgeColoring = fontcatalog.EdgeColoring(i) },
+		get:   func() int { return int(opts.EdgeColoring) },
+	}, "edge-coloring", "msdf edge coloring: "+strings.Join(edgeColoringNames, "|"))
+	fs.Float64Var(&opts.AngleThreshold, "angle-threshold", opts.AngleThreshold, "msdf edge coloring angle threshold")
+	fs.Uint64Var(&opts.Seed, "seed", opts.Seed, "msdf edge coloring seed")
+	fs.IntVar(&opts.Workers, "workers", opts.Workers, "number of parallel glyph rasterization workers")
+	fs.Var(enumValue{
+		names: identifierNames,
+		set:   func(i int) { opts.Identifier = identifierTypes[i] },
+		get: func() int {
+			if opts.Identifier == fontcatalog.GLYPH_INDEX {
+				return 1
+			}
+			return 0
+		},
+	}, "identifier", "glyph identifier: "+strings.Join(identifierNames, "|"))
+}
+
+type stringsValue []string
+
+func (v *stringsValue) String() string {
+	return strings.Join(*v, ",")
+}
+
+func (v *stringsValue) Set(s string) error {
+	*v = append(*v, s)
+	return nil
+}
+
+type variationValue map[string]float64
+
+func (v variationValue) String() string {
+	parts := []string{}
+	for tag, value := range v {
+		parts = append(parts, fmt.Sprintf("%s=%g", tag, value))
+	}
+	return strings.Join(parts, ",")
+}
+
+func (v variationValue) Set(s string) error {
+	parts := strings.SplitN(s, "=", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("expected axis=value, got %q", s)
+	}
+	f, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if err != nil {
+		return err
+	}
+	v[strings.TrimSpace(parts[0])] = f
+	return nil
+}
+
+// registerFontFlags registers the flags selecting the face and variation
+// instance of a font file, the returned function loads a font with them.
+func registerFontFlags(fs *flag.FlagSet) func(path string) (*fontcatalog.FontHolder, error) {
+	face := fs.Int("face", 0, "face index inside a .ttc/.otc font collection")
+	instance := fs.String("instance", "", "named instance of a variable font, e.g. Bold")
+	variation := variationValue{}
+	fs.Var(variation, "var", "variable font axis `value`, e.g. wght=700 (repeatable)")
+	return func(path string) (*fontcatalog.FontHolder, error) {
+		holder, err := fontcatalog.NewFontHolderFromFile(path, *face)
+		if err != nil {
+			return nil, err
+		}
+		if *instance != "" {
+			if err := holder.SetNamedInstanceByName(*instance); err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+		}
+		if len(variation) > 0 {
+			values := holder.Variation()
+			if values == nil {
+				values = map[string]float64{}
+			}
+			for tag, v := range variation {
+				values[tag] = v
+			}
+			if err := holder.SetVariation(values); err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+		}
+		return holder, nil
+	}
+}
+
+func parseRange(s string) (rune, rune, error) {
+	parts := strings.SplitN(s, "-", 2)
+	if len(parts) == 1 {
+		parts = append(parts, parts[0])
+	}
+	var bounds [2]rune
+	for i, part := range parts {
+		n, err := strconv.ParseInt(strings.TrimSpace(part), 0, 32)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid range %q: %v", s, err)
+		}
+		bounds[i] = rune(n)
+	}
+	if bounds[0] > bounds[1] {
+		return 0, 0, fmt.Errorf("invalid range %q", s)
+	}
+	return bounds[0], bounds[1], nil
+}
@@ -0,0 +1,404 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	fontcatalog "github.com/flywave/go-fontcatalog"
+)
+
+const usage = `usage: fontcatalog <command> [flags] [args]
+
+commands:
+  build    build a font catalog from a FontCatalogDescription json
+  atlas    build a single bitmap font from a font and a charset
+  inspect  print the metrics and unicode blocks of a font
+  pbf      build the Mapbox GL glyph ranges of a font
+  serve    serve glyph ranges of a FontCatalogDescription over http
+  preview  render a contact sheet or a page of a generated bitmap font
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "build":
+		err = runBuild(os.Args[2:])
+	case "atlas":
+		err = runAtlas(os.Args[2:])
+	case "inspect":
+		err = runInspect(os.Args[2:])
+	case "pbf":
+		err = runPbf(os.Args[2:])
+	case "serve":
+		err = runServe(os.Args[2:])
+	case "preview":
+		err = runPreview(os.Args[2:])
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(os.Stdout, usage)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "fontcatalog: unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "fontcatalog %s: %v\n", os.Args[1], err)
+		os.Exit(1)
+	}
+}
+
+func runBuild(args []string) error {
+	fs := flag.NewFlagSet("build", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: fontcatalog build [flags] <description.json> <output dir|.zip|.tar>")
+		fs.PrintDefaults()
+	}
+	opts := fontcatalog.DefaultBitmapFontOptions("")
+	registerOptionFlags(fs, &opts)
+	fontsDir := fs.String("fonts-dir", "", "override the fontsDir of the description")
+	cacheDir := fs.String("cache", "", "`dir` keeping generated assets between builds, unchanged blocks are reused")
+	atlas := fs.String("atlas", "", "override the atlas mode of the description: block|font|catalog")
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	desc, err := fontcatalog.ReadFontCatalogDescription(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fs.Arg(0), err)
+	}
+	if *fontsDir != "" {
+		desc.FontsDir = *fontsDir
+	}
+	if *atlas != "" {
+		desc.Atlas = *atlas
+	}
+
+	out, closeOutput, err := openOutput(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	gen := fontcatalog.NewFontCatalogGenerater(desc, &opts)
+	var cache *fontcatalog.BuildCache
+	if *cacheDir != "" {
+		cache, err = fontcatalog.NewBuildCache(*cacheDir)
+		if err != nil {
+			closeOutput()
+			return err
+		}
+		gen.SetCache(cache)
+	}
+	if err := gen.GenerateTo(out); err != nil {
+		closeOutput()
+		return err
+	}
+	if cache != nil {
+		fmt.Fprintf(os.Stderr, "fontcatalog build: %d blocks reused, %d generated\n", cache.Hits(), cache.Misses())
+	}
+	return closeOutput()
+}
+
+func runAtlas(args []string) error {
+	fs := flag.NewFlagSet("atlas", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: fontcatalog atlas [flags] <font> <output dir|.zip|.tar>")
+		fs.PrintDefaults()
+	}
+	opts := fontcatalog.DefaultBitmapFontOptions("atlas")
+	registerOptionFlags(fs, &opts)
+	size := fs.Int("size", 32, "font size in pixels")
+	distance := fs.Float64("distance", 8, "distance field range in pixels")
+	charset := fs.String("charset", "", "characters to include")
+	charsetFile := fs.String("charset-file", "", "utf-8 file whose characters are included")
+	format := fontcatalog.BitmapFontJSON
+	fs.Var(enumValue{
+		names: formatNames,
+		set:   func(i int) { format = fontcatalog.BitmapFontFormat(i) },
+		get:   func() int { return int(format) },
+	}, "format", "bitmap font descriptor format: "+strings.Join(formatNames, "|"))
+	loadFont := registerFontFlags(fs)
+	var ranges, blocks stringsValue
+	fs.Var(&ranges, "range", "code point `range` to include, e.g. 0x20-0x7e (repeatable)")
+	fs.Var(&blocks, "block", "unicode `block` name to include, e.g. \"Basic Latin\" (repeatable)")
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	holder, err := loadFont(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	runes := []rune(*charset)
+	if *charsetFile != "" {
+		data, err := ioutil.ReadFile(*charsetFile)
+		if err != nil {
+			return err
+		}
+		runes = append(runes, []rune(string(data))...)
+	}
+	for _, r := range ranges {
+		min, max, err := parseRange(r)
+		if err != nil {
+			return err
+		}
+		for c := min; c <= max; c++ {
+			runes = append(runes, c)
+		}
+	}
+	for _, name := range blocks {
+		found := false
+		for _, ur := range fontcatalog.ReadUnicodeRanges() {
+			if strings.EqualFold(ur.Category, name) {
+				for c := ur.Range[0]; c <= ur.Range[1]; c++ {
+					runes = append(runes, rune(c))
+				}
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("unknown unicode block %q", name)
+		}
+	}
+
+	var charsets *fontcatalog.Charsets
+	if len(runes) == 0 {
+		charsets = fontcatalog.NewCharsetsASCII()
+	} else {
+		charsets = fontcatalog.NewCharsets()
+		charsets.AddRunes(runes)
+	}
+	if opts.Identifier == fontcatalog.GLYPH_INDEX {
+		charsets = holder.GlyphClosure(charsets)
+		if charsets == nil {
+			return fmt.Errorf("%s: cannot compute glyph closure", fs.Arg(0))
+		}
+	}
+
+	gen := fontcatalog.NewBitmapFontGenerater(holder, charsets, *size, *distance, opts)
+	bmfont := gen.Generate()
+	if err := gen.Err(); err != nil {
+		return fmt.Errorf("%s: %w", fs.Arg(0), err)
+	}
+	if bmfont == nil {
+		return fmt.Errorf("%s: no glyphs generated", fs.Arg(0))
+	}
+
+	out, closeOutput, err := openOutput(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	if err := bmfont.WriteFormat(out, "", opts.Filename, format); err != nil {
+		closeOutput()
+		return err
+	}
+	return closeOutput()
+}
+
+func runInspect(args []string) error {
+	fs := flag.NewFlagSet("inspect", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: fontcatalog inspect [flags] <font>")
+		fs.PrintDefaults()
+	}
+	loadFont := registerFontFlags(fs)
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	holder, err := loadFont(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	data, err := fontcatalog.InspectFont(holder).ToJson()
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(os.Stdout, data)
+	return err
+}
+
+func runPbf(args []string) error {
+	fs := flag.NewFlagSet("pbf", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: fontcatalog pbf [flags] <font> <output dir|.zip|.tar>")
+		fs.PrintDefaults()
+	}
+	name := fs.String("name", "", "fontstack `name`, defaults to the font file name")
+	loadFont := registerFontFlags(fs)
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	holder, err := loadFont(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if *name == "" {
+		*name = strings.TrimSuffix(filepath.Base(fs.Arg(0)), filepath.Ext(fs.Arg(0)))
+	}
+
+	out, closeOutput, err := openOutput(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	if err := fontcatalog.WriteMapboxGlyphs(out, holder, *name); err != nil {
+		closeOutput()
+		return err
+	}
+	return closeOutput()
+}
+
+func runServe(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: fontcatalog serve [flags] <description.json>")
+		fs.PrintDefaults()
+	}
+	opts := fontcatalog.DefaultBitmapFontOptions("")
+	registerOptionFlags(fs, &opts)
+	addr := fs.String("addr", ":8080", "`address` to listen on")
+	fontsDir := fs.String("fonts-dir", "", "override the fontsDir of the description")
+	cacheDir := fs.String("cache", "", "`dir` keeping generated glyph ranges between restarts")
+	memory := fs.Int("memory", fontcatalog.DefaultGlyphCacheSize>>20, "`megabytes` of glyph ranges kept in memory")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	desc, err := fontcatalog.ReadFontCatalogDescription(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fs.Arg(0), err)
+	}
+	if *fontsDir != "" {
+		desc.FontsDir = *fontsDir
+	}
+	server := fontcatalog.NewGlyphServer(desc, opts)
+	if *cacheDir != "" {
+		server.SetCacheDir(*cacheDir)
+	}
+	server.SetCacheSize(*memory << 20)
+	fmt.Fprintf(os.Stderr, "fontcatalog serve: listening on %s\n", *addr)
+	return http.ListenAndServe(*addr, server)
+}
+
+func runPreview(args []string) error {
+	fs := flag.NewFlagSet("preview", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: fontcatalog preview [flags] <bitmap font> <output.png>")
+		fs.PrintDefaults()
+	}
+	page := fs.Int("page", -1, "render `page` as it is in the atlas instead of a contact sheet")
+	scale := fs.Float64("scale", 1, "glyph scale of the contact sheet")
+	columns := fs.Int("columns", 16, "glyphs per row of the contact sheet")
+	sample := fs.String("sample", "The quick brown fox jumps over the lazy dog", "`text` drawn below the contact sheet, empty for none")
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	data, err := ioutil.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	bmfont, err := fontcatalog.DecodeBitmapFont(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fs.Arg(0), err)
+	}
+	if err := bmfont.LoadPages(os.DirFS(filepath.Dir(fs.Arg(0))), "."); err != nil {
+		return err
+	}
+	if !bmfont.Info.Unicode {
+		*sample = ""
+	}
+
+	var img image.Image
+	if *page >= 0 {
+		img, err = bmfont.RenderPage(*page)
+	} else {
+		img, err = bmfont.ContactSheet(fontcatalog.ContactSheetOptions{Scale: *scale, Columns: *columns, Sample: *sample})
+	}
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func openOutput(name string) (fontcatalog.OutputWriter, func() error, error) {
+	switch {
+	case strings.HasSuffix(name, ".zip"), strings.HasSuffix(name, ".tar"):
+		f, err := os.Create(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		var out interface {
+			fontcatalog.OutputWriter
+			Close() error
+		}
+		if strings.HasSuffix(name, ".zip") {
+			out = fontcatalog.NewZipOutput(f)
+		} else {
+			out = fontcatalog.NewTarOutput(f)
+		}
+		return out, func() error {
+			if err := out.Close(); err != nil {
+				f.Close()
+				return err
+			}
+			return f.Close()
+		}, nil
+	default:
+		return fontcatalog.NewDirOutput(name), func() error { return nil }, nil
+	}
+}
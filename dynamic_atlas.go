@@ -0,0 +1,324 @@
+package fontcatalog
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+	"sync"
+
+	"github.com/flywave/imaging"
+)
+
+var ErrAtlasFull = errors.New("dynamic atlas is full")
+
+// DirtyRect is an area of a page that changed since the last TakeDirty.
+type DirtyRect struct {
+	Page int
+	Rect image.Rectangle
+}
+
+type dynamicEntry struct {
+	id    rune
+	glyph Charset
+	node  RectNode
+	frame uint64
+}
+
+type dynamicPage struct {
+	image  *image.RGBA
+	packer *MaxRectsBinPacker
+}
+
+// DynamicAtlas rasterizes glyphs on demand into a fixed number of pages.
+// Glyphs are added by Request, when all pages are full the glyphs used least
+// recently are evicted to make room. Changed page areas are collected until
+// TakeDirty so only those have to be uploaded to the GPU.
+type DynamicAtlas struct {
+	Opt           BitmapFontOptions
+	mu            sync.Mutex
+	holder        *FontHolder
+	font          *FontGeometry
+	attr          *GeneratorAttributes
+	fontSize      int
+	distanceRange float64
+	maxPages      int
+	pages         []*dynamicPage
+	entries       map[rune]*list.Element
+	empty         map[rune]Charset
+	lru           *list.List
+	dirty         []DirtyRect
+	frame         uint64
+}
+
+// NewDynamicAtlas creates an empty atlas of at most maxPages pages of
+// Opt.TextureSize, a maxPages below one allows a single page.
+func NewDynamicAtlas(holder *FontHolder, fontSize int, distanceRange float64, maxPages int, opt BitmapFontOptions) *DynamicAtlas {
+	if maxPages < 1 {
+		maxPages = 1
+	}
+	ret := &DynamicAtlas{
+		Opt:           opt,
+		holder:        holder,
+		font:          NewFontGeometryWithGlyphs(NewGlyphGeometryList()),
+		attr:          NewGeneratorAttributes(),
+		fontSize:      fontSize,
+		distanceRange: distanceRange,
+		maxPages:      maxPages,
+		entries:       make(map[rune]*list.Element),
+		empty:         make(map[rune]Charset),
+		lru:           list.New(),
+	}
+	ret.font.LoadMetrics(holder, float64(fontSize))
+	return ret
+}
+
+// Request makes sure the glyphs of ids are in the atlas and returns them in
+// the same order. ids are code points, or glyph indices when Opt.Identifier
+// is GLYPH_INDEX. Glyphs without an image, whitespace or glyphs missing from
+// the font, are returned with page -1. Glyphs of earlier requests are evicted
+// when they are needed for room, the ones of this request never are. When the
+// pages cannot hold all of them the glyphs that did not fit have page -1 and
+// ErrAtlasFull is returned.
+func (a *DynamicAtlas) Request(ids []rune) ([]Charset, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.frame++
+	ret := make([]Charset, len(ids))
+	var err error
+	for i, id := range ids {
+		if e, ok := a.entries[id]; ok {
+			entry := e.Value.(*dynamicEntry)
+			entry.frame = a.frame
+			a.lru.MoveToBack(e)
+			ret[i] = entry.glyph
+			continue
+		}
+		if c, ok := a.empty[id]; ok {
+			ret[i] = c
+			continue
+		}
+
+		c, ok, rerr := a.add(id)
+		if rerr != nil {
+			return nil, rerr
+		}
+		if !ok && err == nil {
+			err = ErrAtlasFull
+		}
+		ret[i] = c
+	}
+	return ret, err
+}
+
+// Glyph returns a glyph already in the atlas without marking it as used.
+func (a *DynamicAtlas) Glyph(id rune) (Charset, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if e, ok := a.entries[id]; ok {
+		return e.Value.(*dynamicEntry).glyph, true
+	}
+	c, ok := a.empty[id]
+	return c, ok
+}
+
+func (a *DynamicAtlas) Len() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.lru.Len()
+}
+
+func (a *DynamicAtlas) Pages() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.pages)
+}
+
+// Page returns a copy of the image of a page.
+func (a *DynamicAtlas) Page(page int) *image.RGBA {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	src := a.pages[page].image
+	img := image.NewRGBA(src.Rect)
+	copy(img.Pix, src.Pix)
+	return img
+}
+
+// WithPage calls fn with the image of a page, Request waits until fn returns.
+// It saves the copy of Page when uploading dirty areas, fn must not keep the
+// image nor call the atlas.
+func (a *DynamicAtlas) WithPage(page int, fn func(*image.RGBA)) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	fn(a.pages[page].image)
+}
+
+// TakeDirty returns the areas changed since the last call and clears them.
+func (a *DynamicAtlas) TakeDirty() []DirtyRect {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	dirty := a.dirty
+	a.dirty = nil
+	return dirty
+}
+
+func (a *DynamicAtlas) rasterize(id rune) (*CharsetImage, Charset, error) {
+	scale := a.font.GetGeometryScale()
+	var glyph *GlyphGeometry
+	var char string
+	if a.Opt.Identifier == GLYPH_INDEX {
+		glyph = NewGlyphGeometryWithGlyphIndex(a.holder, scale, GlyphIndex(id))
+	} else {
+		glyph = NewGlyphGeometryWithCodePoint(a.holder, scale, id)
+		char = string(id)
+	}
+	c := Charset{ID: int(id), Char: char, Page: -1}
+	if glyph.m == nil {
+		return nil, c, nil
+	}
+	c.Index = glyph.GetIndex()
+	c.XAdvance = int(glyph.GetAdvance())
+	img, err := generateGlyphImage(glyph, char, a.Opt.FieldType, a.distanceRange, a.Opt.EdgeColoring, a.Opt.AngleThreshold, a.Opt.Seed, a.attr)
+	if err != nil {
+		return nil, c, fmt.Errorf("glyph %U: %w", id, err)
+	}
+	return img, c, nil
+}
+
+func (a *DynamicAtlas) add(id rune) (Charset, bool, error) {
+	img, c, err := a.rasterize(id)
+	if err != nil {
+		return c, false, err
+	}
+	if img == nil || img.image == nil {
+		a.empty[id] = c
+		return c, true, nil
+	}
+
+	rect := *img.glyph.Rect()
+	page, node, ok := a.place(rect)
+	if !ok {
+		c.Page = -1
+		return c, false, nil
+	}
+
+	if node.Rotated {
+		img.image = imaging.Rotate90(img.image)
+	}
+
+	fnt := img.font
+	fnt.X = node.X
+	fnt.Y = node.Y
+	fnt.XOffset = int(math.Round(img.plane.Bounds[0]))
+	fnt.YOffset = int(math.Round(a.baseline() - img.plane.Bounds[3]))
+	fnt.Page = page
+
+	p := a.pages[page]
+	area := node.ToRectangle()
+	draw.Draw(p.image, area, newPageImage(a.Opt, area.Dx(), area.Dy()), image.Point{}, draw.Src)
+	draw.Draw(p.image, img.image.Bounds().Add(area.Min), img.image, img.image.Bounds().Min, draw.Src)
+	a.dirty = append(a.dirty, DirtyRect{Page: page, Rect: area})
+
+	entry := &dynamicEntry{id: id, glyph: fnt, node: node, frame: a.frame}
+	a.entries[id] = a.lru.PushBack(entry)
+	return fnt, true, nil
+}
+
+// place finds room for rect on an existing page, on a new page while there
+// are less than maxPages, or on the page of the least recently used glyphs.
+// A rect larger than a page is refused before any page is added or glyph
+// evicted.
+func (a *DynamicAtlas) place(rect RectNode) (int, RectNode, bool) {
+	if !a.fitsPage(rect) {
+		return 0, RectNode{}, false
+	}
+	for i, p := range a.pages {
+		if node, ok := a.insert(p, rect); ok {
+			return i, node, true
+		}
+	}
+
+	if len(a.pages) < a.maxPages {
+		width, height := a.Opt.TextureSize[0], a.Opt.TextureSize[1]
+		p := &dynamicPage{
+			image:  newPageImage(a.Opt, width, height),
+			packer: NewMaxRectsBinPacker(width, height, a.Opt.TexturePadding[0], a.Opt.TexturePadding[1], a.Opt.AllowRotation),
+		}
+		a.pages = append(a.pages, p)
+		if node, ok := a.insert(p, rect); ok {
+			return len(a.pages) - 1, node, true
+		}
+		return 0, RectNode{}, false
+	}
+
+	// glyphs are evicted from one page at a time, the page of the least
+	// recently used glyph first, so the room they leave adds up
+	tried := make(map[int]bool)
+	for {
+		page := a.victimPage(tried)
+		if page < 0 {
+			return 0, RectNode{}, false
+		}
+		tried[page] = true
+		for e := a.lru.Front(); e != nil; {
+			entry := e.Value.(*dynamicEntry)
+			if entry.frame == a.frame {
+				break
+			}
+			next := e.Next()
+			if entry.glyph.Page == page {
+				a.evict(e)
+				if node, ok := a.insert(a.pages[page], rect); ok {
+					return page, node, true
+				}
+			}
+			e = next
+		}
+	}
+}
+
+// victimPage returns the page of the least recently used glyph that is not
+// in tried and was not requested this frame, -1 when there is none.
+func (a *DynamicAtlas) victimPage(tried map[int]bool) int {
+	for e := a.lru.Front(); e != nil; e = e.Next() {
+		entry := e.Value.(*dynamicEntry)
+		if entry.frame == a.frame {
+			break
+		}
+		if !tried[entry.glyph.Page] {
+			return entry.glyph.Page
+		}
+	}
+	return -1
+}
+
+func (a *DynamicAtlas) fitsPage(rect RectNode) bool {
+	width, height := a.Opt.TextureSize[0]-a.Opt.TexturePadding[0], a.Opt.TextureSize[1]-a.Opt.TexturePadding[1]
+	if rect.W <= width && rect.H <= height {
+		return true
+	}
+	return a.Opt.AllowRotation && rect.H <= width && rect.W <= height
+}
+
+func (a *DynamicAtlas) insert(p *dynamicPage, rect RectNode) (RectNode, bool) {
+	res := p.packer.Pack([]RectNode{rect}, a.Opt.PackerMethod)
+	if len(res.NotPlacedRects) > 0 {
+		return RectNode{}, false
+	}
+	return res.PlacedRects[len(res.PlacedRects)-1], true
+}
+
+func (a *DynamicAtlas) evict(e *list.Element) {
+	entry := a.lru.Remove(e).(*dynamicEntry)
+	delete(a.entries, entry.id)
+	a.pages[entry.glyph.Page].packer.Release(entry.node)
+}
+
+func (a *DynamicAtlas) baseline() float64 {
+	fontmetric := a.font.GetFontMetrics()
+	return fontmetric.AscenderY*(float64(a.fontSize)/fontmetric.EmSize) + (0.5 * a.distanceRange)
+}
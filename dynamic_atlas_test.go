@@ -0,0 +1,140 @@
+package fontcatalog
+
+import (
+	"image"
+	"io/ioutil"
+	"testing"
+)
+
+func TestDynamicAtlas(t *testing.T) {
+	data, err := ioutil.ReadFile("./fonts/FiraGO_Map.ttf")
+	if err != nil {
+		t.FailNow()
+	}
+
+	opts := DefaultBitmapFontOptions("")
+	opts.TextureSize = []int{128, 128}
+	atlas := NewDynamicAtlas(NewFontHolder(data), 32, 8, 1, opts)
+
+	glyphs, err := atlas.Request([]rune("AB A"))
+	if err != nil || len(glyphs) != 4 {
+		t.Fatal(err)
+	}
+	if glyphs[0] != glyphs[3] || glyphs[0].Page != 0 || glyphs[0].Width == 0 {
+		t.FailNow()
+	}
+	if glyphs[2].Page != -1 || glyphs[2].XAdvance == 0 {
+		t.FailNow()
+	}
+	if dirty := atlas.TakeDirty(); len(dirty) != 2 {
+		t.Fatalf("dirty rects %v", dirty)
+	}
+
+	page := atlas.Page(0)
+	lit := false
+	for y := glyphs[0].Y; y < glyphs[0].Y+glyphs[0].Height; y++ {
+		for x := glyphs[0].X; x < glyphs[0].X+glyphs[0].Width; x++ {
+			if page.RGBAAt(x, y).R > 128 {
+				lit = true
+			}
+		}
+	}
+	if !lit {
+		t.FailNow()
+	}
+	pix := page.Pix[0]
+	page.Pix[0]++
+	atlas.WithPage(0, func(img *image.RGBA) {
+		if img == page || img.Pix[0] != pix {
+			t.FailNow()
+		}
+	})
+
+	if _, err := atlas.Request([]rune("AB")); err != nil || len(atlas.TakeDirty()) != 0 {
+		t.FailNow()
+	}
+
+	for _, r := range "CDEFGHIJKLMNOPQRSTUVWXYZ" {
+		glyphs, err := atlas.Request([]rune{r})
+		if err != nil || glyphs[0].Page != 0 {
+			t.Fatalf("%c: %v", r, err)
+		}
+	}
+	if atlas.Pages() != 1 || atlas.Len() >= 26 {
+		t.Fatalf("pages %d glyphs %d", atlas.Pages(), atlas.Len())
+	}
+	if _, ok := atlas.Glyph('A'); ok {
+		t.FailNow()
+	}
+	if _, ok := atlas.Glyph('Z'); !ok {
+		t.FailNow()
+	}
+
+	if _, err := atlas.Request([]rune("abcdefghijklmnopqrstuvwxyz")); err != ErrAtlasFull {
+		t.FailNow()
+	}
+}
+
+func TestDynamicAtlasEvictsFromOnePage(t *testing.T) {
+	data, err := ioutil.ReadFile("./fonts/FiraGO_Map.ttf")
+	if err != nil {
+		t.FailNow()
+	}
+
+	opts := DefaultBitmapFontOptions("")
+	opts.TextureSize = []int{64, 64}
+	atlas := NewDynamicAtlas(NewFontHolder(data), 32, 8, 2, opts)
+
+	narrow := []rune("il1!|.,:;'jftr()[]{}")
+	glyphs, err := atlas.Request(narrow)
+	if err != ErrAtlasFull || atlas.Pages() != 2 {
+		t.FailNow()
+	}
+
+	// use the glyphs of both pages in turn so the least recently used order
+	// alternates between them
+	var byPage [2][]rune
+	for i, g := range glyphs {
+		if g.Page >= 0 {
+			byPage[g.Page] = append(byPage[g.Page], narrow[i])
+		}
+	}
+	for i := 0; i < len(byPage[0]) || i < len(byPage[1]); i++ {
+		for _, ids := range byPage {
+			if i < len(ids) {
+				atlas.Request([]rune{ids[i]})
+			}
+		}
+	}
+
+	glyphs, err = atlas.Request([]rune("W"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for page, ids := range byPage {
+		if page == glyphs[0].Page {
+			continue
+		}
+		for _, id := range ids {
+			if _, ok := atlas.Glyph(id); !ok {
+				t.Fatalf("%c evicted from page %d", id, page)
+			}
+		}
+	}
+}
+
+func TestDynamicAtlasGlyphLargerThanPage(t *testing.T) {
+	data, err := ioutil.ReadFile("./fonts/FiraGO_Map.ttf")
+	if err != nil {
+		t.FailNow()
+	}
+
+	opts := DefaultBitmapFontOptions("")
+	opts.TextureSize = []int{16, 16}
+	atlas := NewDynamicAtlas(NewFontHolder(data), 32, 8, 2, opts)
+
+	glyphs, err := atlas.Request([]rune("W"))
+	if err != ErrAtlasFull || glyphs[0].Page != -1 || atlas.Pages() != 0 {
+		t.FailNow()
+	}
+}
@@ -0,0 +1,241 @@
+package fontcatalog
+
+// #include <stdlib.h>
+// #include "fontcatalog_lib.h"
+// #cgo CFLAGS: -I ./lib
+// #cgo linux CXXFLAGS: -I ./lib -std=c++14
+// #cgo darwin CXXFLAGS: -I ./lib  -std=gnu++14
+import "C"
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+	"unsafe"
+)
+
+// FontNames are the entries of the name table, english windows names are
+// preferred.
+type FontNames struct {
+	Copyright            string `json:"copyright,omitempty"`
+	Family               string `json:"family,omitempty"`
+	Subfamily            string `json:"subfamily,omitempty"`
+	UniqueID             string `json:"uniqueId,omitempty"`
+	FullName             string `json:"fullName,omitempty"`
+	Version              string `json:"version,omitempty"`
+	PostScriptName       string `json:"postScriptName,omitempty"`
+	Trademark            string `json:"trademark,omitempty"`
+	Manufacturer         string `json:"manufacturer,omitempty"`
+	Designer             string `json:"designer,omitempty"`
+	License              string `json:"license,omitempty"`
+	TypographicFamily    string `json:"typographicFamily,omitempty"`
+	TypographicSubfamily string `json:"typographicSubfamily,omitempty"`
+}
+
+// LayoutScript is a script of the GSUB or GPOS table with the languages it
+// has specific features for.
+type LayoutScript struct {
+	Script    string   `json:"script"`
+	Languages []string `json:"languages,omitempty"`
+}
+
+// FontDetails describes a font, metrics are in font units. The OS/2 fields are
+// zero when the font has no OS/2 table, XHeight and CapHeight are measured
+// from the outlines of x and H when the table does not carry them.
+type FontDetails struct {
+	Names FontNames `json:"names"`
+	// FamilyName and StyleName prefer the typographic names
+	FamilyName string `json:"familyName"`
+	StyleName  string `json:"styleName"`
+
+	NumGlyphs  int `json:"numGlyphs"`
+	UnitsPerEm int `json:"unitsPerEm"`
+
+	Ascender  int `json:"ascender"`
+	Descender int `json:"descender"`
+	LineGap   int `json:"lineGap"`
+
+	OS2Version    int       `json:"os2Version"`
+	TypoAscender  int       `json:"typoAscender"`
+	TypoDescender int       `json:"typoDescender"`
+	TypoLineGap   int       `json:"typoLineGap"`
+	WinAscent     int       `json:"winAscent"`
+	WinDescent    int       `json:"winDescent"`
+	XHeight       int       `json:"xHeight"`
+	CapHeight     int       `json:"capHeight"`
+	AvgCharWidth  int       `json:"avgCharWidth"`
+	WeightClass   int       `json:"weightClass"`
+	WidthClass    int       `json:"widthClass"`
+	FsType        int       `json:"fsType"`
+	FsSelection   int       `json:"fsSelection"`
+	Panose        [10]int   `json:"panose"`
+	Vendor        string    `json:"vendor,omitempty"`
+	UnicodeRanges [4]uint32 `json:"unicodeRanges"`
+	CodePages     [2]uint32 `json:"codePages"`
+
+	StrikeoutPosition  int     `json:"strikeoutPosition"`
+	StrikeoutSize      int     `json:"strikeoutSize"`
+	UnderlinePosition  int     `json:"underlinePosition"`
+	UnderlineThickness int     `json:"underlineThickness"`
+	ItalicAngle        float64 `json:"italicAngle"`
+	FixedPitch         bool    `json:"fixedPitch"`
+	Bold               bool    `json:"bold"`
+	Italic             bool    `json:"italic"`
+
+	// Scripts are the unicode scripts with characters in the cmap,
+	// LayoutScripts the scripts and languages of the OpenType tables.
+	Scripts       []string       `json:"scripts,omitempty"`
+	LayoutScripts []LayoutScript `json:"layoutScripts,omitempty"`
+}
+
+func (ur *FontDetails) ToJson() (string, error) {
+	b, err := json.Marshal(ur)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func (h *FontHolder) Info() *FontDetails {
+	d := C.fc_font_holder_get_font_details(h.m)
+	info := h.getFontInfo()
+
+	ret := &FontDetails{
+		Names:              h.fontNames(),
+		NumGlyphs:          int(d.numGlyphs),
+		UnitsPerEm:         int(d.unitsPerEm),
+		Ascender:           int(d.ascender),
+		Descender:          int(d.descender),
+		LineGap:            int(d.lineGap),
+		OS2Version:         int(d.os2Version),
+		TypoAscender:       int(d.typoAscender),
+		TypoDescender:      int(d.typoDescender),
+		TypoLineGap:        int(d.typoLineGap),
+		WinAscent:          int(d.winAscent),
+		WinDescent:         int(d.winDescent),
+		XHeight:            int(d.xHeight),
+		CapHeight:          int(d.capHeight),
+		AvgCharWidth:       int(d.avgCharWidth),
+		WeightClass:        int(d.weightClass),
+		WidthClass:         int(d.widthClass),
+		FsType:             int(d.fsType),
+		FsSelection:        int(d.fsSelection),
+		Vendor:             strings.TrimRight(C.GoString(&d.vendor[0]), " \x00"),
+		StrikeoutPosition:  int(d.strikeoutPosition),
+		StrikeoutSize:      int(d.strikeoutSize),
+		UnderlinePosition:  int(d.underlinePosition),
+		UnderlineThickness: int(d.underlineThickness),
+		ItalicAngle:        float64(d.italicAngle),
+		FixedPitch:         bool(d.fixedPitch),
+		Bold:               info.Bold,
+		Italic:             info.Italic,
+		Scripts:            charsetScripts(info.CharacterSet),
+		LayoutScripts:      h.layoutScripts(),
+	}
+	for i := range ret.Panose {
+		ret.Panose[i] = int(d.panose[i])
+	}
+	for i := range ret.UnicodeRanges {
+		ret.UnicodeRanges[i] = uint32(d.unicodeRange[i])
+	}
+	for i := range ret.CodePages {
+		ret.CodePages[i] = uint32(d.codePageRange[i])
+	}
+
+	ret.FamilyName = ret.Names.TypographicFamily
+	if ret.FamilyName == "" {
+		ret.FamilyName = ret.Names.Family
+	}
+	ret.StyleName = ret.Names.TypographicSubfamily
+	if ret.StyleName == "" {
+		ret.StyleName = ret.Names.Subfamily
+	}
+	return ret
+}
+
+func (h *FontHolder) fontNames() FontNames {
+	records := h.names()
+	names := map[int]string{}
+	for id := 0; id <= 17; id++ {
+		names[id] = pickName(records, id)
+	}
+	return FontNames{
+		Copyright:            names[0],
+		Family:               names[1],
+		Subfamily:            names[2],
+		UniqueID:             names[3],
+		FullName:             names[4],
+		Version:              names[5],
+		PostScriptName:       names[6],
+		Trademark:            names[7],
+		Manufacturer:         names[8],
+		Designer:             names[9],
+		License:              names[13],
+		TypographicFamily:    names[16],
+		TypographicSubfamily: names[17],
+	}
+}
+
+func layoutTag(tag C.uint32_t) string {
+	return strings.TrimRight(axisTag(tag), " ")
+}
+
+func (h *FontHolder) layoutScripts() []LayoutScript {
+	var si C.size_t
+	data := C.fc_font_holder_get_layout_tags(h.m, &si)
+	if data == nil {
+		return nil
+	}
+	defer C.free(unsafe.Pointer(data))
+
+	var tags []C.struct__fc_layout_tag_t
+	bufHeader := (*reflect.SliceHeader)((unsafe.Pointer(&tags)))
+	bufHeader.Cap = int(si)
+	bufHeader.Len = int(si)
+	bufHeader.Data = uintptr(unsafe.Pointer(data))
+
+	// tags come sorted by script, the default language first
+	ret := []LayoutScript{}
+	for _, t := range tags {
+		script := layoutTag(t.script)
+		if len(ret) == 0 || ret[len(ret)-1].Script != script {
+			ret = append(ret, LayoutScript{Script: script})
+		}
+		if t.language != 0 {
+			ret[len(ret)-1].Languages = append(ret[len(ret)-1].Languages, layoutTag(t.language))
+		}
+	}
+	return ret
+}
+
+// charsetScripts returns the sorted names of the unicode scripts with at
+// least one character in cs, Common and Inherited are left out.
+func charsetScripts(cs []rune) []string {
+	found := map[string]bool{}
+	last := ""
+	for _, r := range cs {
+		if r == 0 {
+			continue
+		}
+		// the character set is sorted, neighbours mostly share a script
+		if last != "" && unicode.Is(unicode.Scripts[last], r) {
+			continue
+		}
+		for name, table := range unicode.Scripts {
+			if unicode.Is(table, r) {
+				found[name] = true
+				last = name
+				break
+			}
+		}
+	}
+	delete(found, "Common")
+	delete(found, "Inherited")
+	ret := make([]string, 0, len(found))
+	for name := range found {
+		ret = append(ret, name)
+	}
+	sort.Strings(ret)
+	return ret
+}
@@ -0,0 +1,68 @@
+package fontcatalog
+
+import (
+	"io/ioutil"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestFontHolderInfo(t *testing.T) {
+	data, err := ioutil.ReadFile("./fonts/FiraGO_Map.ttf")
+	if err != nil {
+		t.FailNow()
+	}
+	info := NewFontHolder(data).Info()
+	if info.FamilyName != "Fira GO" || info.StyleName != "Map" || info.Names.Family != "Fira GO Map" || info.Names.PostScriptName != "FiraGO-Map" {
+		t.Fatalf("%+v", info.Names)
+	}
+	if info.UnitsPerEm != 1000 || info.NumGlyphs == 0 || info.WeightClass != 400 || info.WidthClass != 5 || info.Vendor != "CTDB" {
+		t.Fatalf("%+v", info)
+	}
+	if info.XHeight <= 0 || info.CapHeight <= info.XHeight || info.TypoAscender <= info.CapHeight || info.UnderlineThickness <= 0 {
+		t.Fatalf("%+v", info)
+	}
+	if !strings.Contains(strings.Join(info.Scripts, ","), "Latin") || strings.Contains(strings.Join(info.Scripts, ","), "Common") {
+		t.Fatal(info.Scripts)
+	}
+	found := false
+	for _, ls := range info.LayoutScripts {
+		if ls.Script == "latn" && strings.Contains(strings.Join(ls.Languages, ","), "TRK") {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatal(info.LayoutScripts)
+	}
+
+	data, err = ioutil.ReadFile("./fonts/FiraGO_MapBold.ttf")
+	if err != nil {
+		t.FailNow()
+	}
+	if info := NewFontHolder(data).Info(); info.WeightClass != 700 || !info.Bold {
+		t.Fatalf("%+v", info)
+	}
+
+	// no x or H and no OS/2 version 2 fields
+	data, err = ioutil.ReadFile("./fonts/LohitIndic.ttf")
+	if err != nil {
+		t.FailNow()
+	}
+	if info := NewFontHolder(data).Info(); info.XHeight != 0 || info.CapHeight != 0 || info.LineGap == 0 {
+		t.Fatalf("%+v", info)
+	}
+
+	fcd, err := ReadFontCatalogDescription(strings.NewReader(`{"name":"Test","size":32,"distance":8,"type":"sdf","fontsDir":"./fonts","fonts":[{"name":"FiraGO_Map","blocks":["Basic Latin"]}]}`))
+	if err != nil {
+		t.FailNow()
+	}
+	opts := DefaultBitmapFontOptions("")
+	catalog, err := NewFontCatalogGenerater(fcd, &opts).Describe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := catalog.Fonts[0].Metrics
+	if m.XHeight != int(math.Round(float64(info.XHeight)*32/1000)) || m.CapHeight != int(math.Round(float64(info.CapHeight)*32/1000)) {
+		t.Fatalf("%+v", m)
+	}
+}
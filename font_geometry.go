@@ -84,7 +84,10 @@ func (h *FontGeometry) GetFontMetrics() FontMetrics {
 }
 
 func (h *FontGeometry) GetPreferredIdentifierType() GlyphIdentifierType {
-	return GlyphIdentifierType(C.fc_font_geometry_get_preferred_identifier_type(h.m))
+	if C.fc_font_geometry_get_preferred_identifier_type(h.m) == 0 {
+		return GLYPH_INDEX
+	}
+	return UNICODE_CODEPOINT
 }
 
 func (h *FontGeometry) GetGlyphs() *GlyphRange {
@@ -168,6 +171,7 @@ func (h *KerningMap) free() {
 func (h *KerningMap) GetKernings() []Kerning {
 	var si C.size_t
 	ck := C.fc_kerning_map_get_kernings(h.m, &si)
+	defer C.free(unsafe.Pointer(ck))
 
 	var dSlice []C.struct__fc_kerning_t
 	dHeader := (*reflect.SliceHeader)((unsafe.Pointer(&dSlice)))
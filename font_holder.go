@@ -7,8 +7,14 @@ package fontcatalog
 // #cgo darwin CXXFLAGS: -I ./lib  -std=gnu++14
 import "C"
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
 	"reflect"
 	"runtime"
+	"unicode/utf16"
 	"unsafe"
 )
 
@@ -26,20 +32,125 @@ type fontInfo struct {
 }
 
 type FontHolder struct {
-	m *C.struct__fc_font_holder_t
+	m    *C.struct__fc_font_holder_t
+	data []byte
+	path string
+	face int
+	// coords are the variation coordinates set on the font, kept so
+	// clones load the same instance
+	coords []float64
+}
+
+var ErrUnsupportedFontFormat = errors.New("unsupported font format")
+
+// checkFontFormat rejects the formats FreeType is built without, head is the
+// start of the font file. FreeType itself reads TrueType and OpenType fonts,
+// collections and WOFF, WOFF2 would need brotli.
+func checkFontFormat(head []byte) error {
+	if bytes.HasPrefix(head, []byte("wOF2")) {
+		return fmt.Errorf("%w: woff2", ErrUnsupportedFontFormat)
+	}
+	return nil
 }
 
 func NewFontHolder(data []byte) *FontHolder {
 	handle := C.fc_font_holder_load_font_memory((*C.uchar)(unsafe.Pointer(&data[0])), C.long(len(data)))
-	ret := &FontHolder{m: handle}
+	ret := &FontHolder{m: handle, data: data}
 	runtime.SetFinalizer(ret, (*FontHolder).free)
 	return ret
 }
 
+// NewFontHolderFace loads face of the font collection in data, single fonts
+// only have face 0.
+func NewFontHolderFace(data []byte, face int) (*FontHolder, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%w: empty font data", ErrUnsupportedFontFormat)
+	}
+	if err := checkFontFormat(data); err != nil {
+		return nil, err
+	}
+	handle := C.fc_font_holder_load_font_memory_face((*C.uchar)(unsafe.Pointer(&data[0])), C.long(len(data)), C.int(face))
+	if handle == nil {
+		return nil, fmt.Errorf("cannot load face %d of font data", face)
+	}
+	ret := &FontHolder{m: handle, data: data, face: face}
+	runtime.SetFinalizer(ret, (*FontHolder).free)
+	return ret, nil
+}
+
+// NewFontHolderFromFile loads face of the font file at path, FreeType reads
+// the file itself so large collections are not copied into memory.
+func NewFontHolderFromFile(path string, face int) (*FontHolder, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	head := make([]byte, 4)
+	_, err = io.ReadFull(f, head)
+	f.Close()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := checkFontFormat(head); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	cpath := C.CString(path)
+	defer C.free(unsafe.Pointer(cpath))
+	handle := C.fc_font_holder_load_font_file(cpath, C.int(face))
+	if handle == nil {
+		return nil, fmt.Errorf("%s: cannot load face %d", path, face)
+	}
+	ret := &FontHolder{m: handle, path: path, face: face}
+	runtime.SetFinalizer(ret, (*FontHolder).free)
+	return ret, nil
+}
+
 func (h *FontHolder) free() {
 	C.fc_font_holder_free(h.m)
 }
 
+// clone loads the font of the holder again, with the same variation, for
+// use by another goroutine.
+func (h *FontHolder) clone() (*FontHolder, error) {
+	var ret *FontHolder
+	var err error
+	if h.path != "" {
+		ret, err = NewFontHolderFromFile(h.path, h.face)
+	} else {
+		ret, err = NewFontHolderFace(h.data, h.face)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if h.coords != nil {
+		if err := ret.setVariationCoords(h.coords); err != nil {
+			return nil, err
+		}
+		ret.coords = h.coords
+	}
+	return ret, nil
+}
+
+// Face is the index of the loaded face in its font collection.
+func (h *FontHolder) Face() int {
+	return h.face
+}
+
+// NumFaces is the number of faces of the font collection the holder was
+// loaded from, 1 for single fonts.
+func (h *FontHolder) NumFaces() int {
+	return int(C.fc_font_holder_num_faces(h.m))
+}
+
+func (h *FontHolder) GlyphClosure(codepoints *Charsets) *Charsets {
+	glyphs := NewCharsets()
+	if !bool(C.fc_font_holder_glyph_closure(h.m, codepoints.m, glyphs.m)) {
+		return nil
+	}
+	return glyphs
+}
+
 func (h *FontHolder) getFontInfo() *fontInfo {
 	info := &fontInfo{}
 
@@ -51,6 +162,7 @@ func (h *FontHolder) getFontInfo() *fontInfo {
 	info.BaseLine = int(metrics.baseLine)
 	info.UnitsPerEm = int(metrics.unitsPerEm)
 	info.LineHeight = int(metrics.lineHeight)
+	info.LineGap = int(metrics.lineGap)
 	info.FontHeight = int(metrics.lineHeight)
 	info.Bold = (int(metrics.flags) & 1) != 0
 	info.Italic = (int(metrics.flags) & 2) != 0
@@ -67,3 +179,72 @@ func (h *FontHolder) getFontInfo() *fontInfo {
 
 	return info
 }
+
+type sfntName struct {
+	PlatformID int
+	EncodingID int
+	LanguageID int
+	NameID     int
+	Value      string
+}
+
+// names reads the records of the name table, values of the unicode and
+// windows platforms are decoded from UTF-16, the others are taken as is.
+func (h *FontHolder) names() []sfntName {
+	var si C.size_t
+	data := C.fc_font_holder_get_names(h.m, &si)
+	if data == nil {
+		return nil
+	}
+	defer C.free(unsafe.Pointer(data))
+
+	var records []C.struct__fc_sfnt_name_t
+	bufHeader := (*reflect.SliceHeader)((unsafe.Pointer(&records)))
+	bufHeader.Cap = int(si)
+	bufHeader.Len = int(si)
+	bufHeader.Data = uintptr(unsafe.Pointer(data))
+
+	names := make([]sfntName, len(records))
+	for i, r := range records {
+		raw := C.GoBytes(unsafe.Pointer(r.string), C.int(r.length))
+		name := sfntName{PlatformID: int(r.platformId), EncodingID: int(r.encodingId), LanguageID: int(r.languageId), NameID: int(r.nameId)}
+		if name.PlatformID == 0 || name.PlatformID == 3 {
+			u := make([]uint16, len(raw)/2)
+			for j := range u {
+				u[j] = uint16(raw[2*j])<<8 | uint16(raw[2*j+1])
+			}
+			name.Value = string(utf16.Decode(u))
+		} else {
+			name.Value = string(raw)
+		}
+		names[i] = name
+	}
+	return names
+}
+
+// name returns the name table entry id, preferring english windows names.
+func (h *FontHolder) name(id int) string {
+	return pickName(h.names(), id)
+}
+
+func pickName(names []sfntName, id int) string {
+	best, score := "", 0
+	for _, n := range names {
+		if n.NameID != id {
+			continue
+		}
+		s := 1
+		switch {
+		case n.PlatformID == 3 && n.LanguageID == 0x409:
+			s = 4
+		case n.PlatformID == 3 || n.PlatformID == 0:
+			s = 3
+		case n.PlatformID == 1 && n.LanguageID == 0:
+			s = 2
+		}
+		if s > score {
+			best, score = n.Value, s
+		}
+	}
+	return best
+}
@@ -0,0 +1,215 @@
+package fontcatalog
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type sfntTable struct {
+	tag      string
+	checksum uint32
+	data     []byte
+}
+
+func readSfntTables(t *testing.T, data []byte) (uint32, []sfntTable) {
+	numTables := int(binary.BigEndian.Uint16(data[4:]))
+	tables := make([]sfntTable, numTables)
+	for i := range tables {
+		rec := data[12+16*i:]
+		offset, length := binary.BigEndian.Uint32(rec[8:]), binary.BigEndian.Uint32(rec[12:])
+		if int(offset+length) > len(data) {
+			t.Fatalf("table %d out of bounds", i)
+		}
+		tables[i] = sfntTable{string(rec[:4]), binary.BigEndian.Uint32(rec[4:]), data[offset : offset+length]}
+	}
+	return binary.BigEndian.Uint32(data), tables
+}
+
+func pad4(b []byte) []byte {
+	for len(b)%4 != 0 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+// buildCollection packs fonts into a TrueType collection, face i is fonts[i].
+func buildCollection(t *testing.T, fonts ...[]byte) []byte {
+	be := binary.BigEndian
+	header := 12 + 4*len(fonts)
+	dirs := make([][]byte, len(fonts))
+	body := []byte{}
+	offset := header
+	for _, f := range fonts {
+		_, tables := readSfntTables(t, f)
+		offset += 12 + 16*len(tables)
+	}
+	for i, f := range fonts {
+		version, tables := readSfntTables(t, f)
+		dir := make([]byte, 12, 12+16*len(tables))
+		be.PutUint32(dir, version)
+		be.PutUint16(dir[4:], uint16(len(tables)))
+		for _, table := range tables {
+			rec := make([]byte, 16)
+			copy(rec, table.tag)
+			be.PutUint32(rec[4:], table.checksum)
+			be.PutUint32(rec[8:], uint32(offset+len(body)))
+			be.PutUint32(rec[12:], uint32(len(table.data)))
+			dir = append(dir, rec...)
+			body = pad4(append(body, table.data...))
+		}
+		dirs[i] = dir
+	}
+
+	out := make([]byte, header)
+	copy(out, "ttcf")
+	be.PutUint32(out[4:], 0x00010000)
+	be.PutUint32(out[8:], uint32(len(fonts)))
+	pos := header
+	for i, dir := range dirs {
+		be.PutUint32(out[12+4*i:], uint32(pos))
+		pos += len(dir)
+	}
+	for _, dir := range dirs {
+		out = append(out, dir...)
+	}
+	return append(out, body...)
+}
+
+// buildWoff wraps font into a WOFF 1.0 file with zlib compressed tables.
+func buildWoff(t *testing.T, font []byte) []byte {
+	be := binary.BigEndian
+	flavor, tables := readSfntTables(t, font)
+	sfntSize := 12 + 16*len(tables)
+	dir := []byte{}
+	body := []byte{}
+	offset := 44 + 20*len(tables)
+	for _, table := range tables {
+		var buf bytes.Buffer
+		zw := zlib.NewWriter(&buf)
+		zw.Write(table.data)
+		zw.Close()
+		data := buf.Bytes()
+		if len(data) >= len(table.data) {
+			data = table.data
+		}
+		rec := make([]byte, 20)
+		copy(rec, table.tag)
+		be.PutUint32(rec[4:], uint32(offset+len(body)))
+		be.PutUint32(rec[8:], uint32(len(data)))
+		be.PutUint32(rec[12:], uint32(len(table.data)))
+		be.PutUint32(rec[16:], table.checksum)
+		dir = append(dir, rec...)
+		body = pad4(append(body, data...))
+		sfntSize += (len(table.data) + 3) &^ 3
+	}
+
+	out := make([]byte, 44)
+	copy(out, "wOFF")
+	be.PutUint32(out[4:], flavor)
+	be.PutUint32(out[8:], uint32(offset+len(body)))
+	be.PutUint16(out[12:], uint16(len(tables)))
+	be.PutUint32(out[16:], uint32(sfntSize))
+	be.PutUint16(out[20:], 1)
+	out = append(out, dir...)
+	return append(out, body...)
+}
+
+func TestFontHolderFaces(t *testing.T) {
+	regular, err := ioutil.ReadFile("./fonts/FiraGO_Map.ttf")
+	if err != nil {
+		t.FailNow()
+	}
+	bold, err := ioutil.ReadFile("./fonts/FiraGO_MapBold.ttf")
+	if err != nil {
+		t.FailNow()
+	}
+	dir := t.TempDir()
+	ttc := buildCollection(t, regular, bold)
+	if err := ioutil.WriteFile(filepath.Join(dir, "FiraGO.ttc"), ttc, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "FiraGO_MapBold.woff"), buildWoff(t, bold), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "FiraGO_MapBold.woff2"), []byte("wOF2\x00\x01\x00\x00"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if NewFontHolder(regular).getFontInfo().Bold || !NewFontHolder(bold).getFontInfo().Bold {
+		t.FailNow()
+	}
+
+	holder, err := NewFontHolderFace(ttc, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if holder.NumFaces() != 2 || holder.Face() != 1 || !holder.getFontInfo().Bold {
+		t.FailNow()
+	}
+	if _, err := NewFontHolderFace(ttc, 2); err == nil {
+		t.FailNow()
+	}
+
+	holder, err = NewFontHolderFromFile(filepath.Join(dir, "FiraGO.ttc"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if holder.NumFaces() != 2 || holder.getFontInfo().Bold {
+		t.FailNow()
+	}
+	if clone, err := holder.clone(); err != nil || clone.getFontInfo().Bold {
+		t.FailNow()
+	}
+	if _, err := (&FontHolder{path: filepath.Join(dir, "missing.ttf")}).clone(); err == nil {
+		t.FailNow()
+	}
+
+	holder, err = NewFontHolderFromFile(filepath.Join(dir, "FiraGO_MapBold.woff"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !holder.getFontInfo().Bold || len(holder.getFontInfo().CharacterSet) != len(NewFontHolder(bold).getFontInfo().CharacterSet) {
+		t.FailNow()
+	}
+
+	if _, err := NewFontHolderFromFile(filepath.Join(dir, "FiraGO_MapBold.woff2"), 0); !errors.Is(err, ErrUnsupportedFontFormat) {
+		t.Fatal(err)
+	}
+
+	fcd, err := ReadFontCatalogDescription(strings.NewReader(`{"name":"Test","size":32,"distance":8,"type":"sdf","fontsDir":"` + filepath.ToSlash(dir) + `",
+		"fonts":[{"name":"FiraGO","bold":"FiraGO_MapBold","blocks":["Basic Latin"],"files":{"FiraGO":{"file":"FiraGO.ttc"}}},
+		{"name":"FiraGOBold","blocks":["Basic Latin"],"files":{"FiraGOBold":{"file":"FiraGO.ttc","face":1}}}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := DefaultBitmapFontOptions("")
+	gen := NewFontCatalogGenerater(fcd, &opts)
+	for _, ufont := range fcd.Fonts {
+		_, styles, err := gen.loadFontStyles(ufont)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, fs := range styles {
+			switch fs.name {
+			case "FiraGO":
+				if fs.file.Face != 0 || filepath.Base(fs.fontPath) != "FiraGO.ttc" {
+					t.FailNow()
+				}
+			case "FiraGOBold":
+				if fs.file.Face != 1 || filepath.Base(fs.fontPath) != "FiraGO.ttc" {
+					t.FailNow()
+				}
+			case "FiraGO_MapBold":
+				if filepath.Base(fs.fontPath) != "FiraGO_MapBold.woff" {
+					t.FailNow()
+				}
+			}
+		}
+	}
+}
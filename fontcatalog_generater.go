@@ -10,8 +10,6 @@ import (
 	"strings"
 
 	_ "embed"
-
-	"github.com/flywave/imaging"
 )
 
 //go:embed NotoSans-Regular.ttf
@@ -21,167 +19,392 @@ type FontCatalogGenerater struct {
 	opts        *BitmapFontOptions
 	fontDesc    *FontCatalogDescription
 	fontCatalog *FontCatalog
+	cache       *BuildCache
+	manifest    *BuildManifest
 }
 
 func NewFontCatalogGenerater(desc *FontCatalogDescription, opts *BitmapFontOptions) *FontCatalogGenerater {
 	if desc.Type != "" && desc.Type != opts.FieldType {
 		opts.FieldType = desc.Type
 	}
-	ret := &FontCatalogGenerater{fontDesc: desc, opts: opts, fontCatalog: &FontCatalog{Name: desc.Name, Type: desc.Type, Size: float64(desc.Size), DistanceRange: float64(desc.Distance)}}
+	ret := &FontCatalogGenerater{fontDesc: desc, opts: opts, fontCatalog: newFontCatalog(desc)}
 	return ret
 }
 
+func newFontCatalog(desc *FontCatalogDescription) *FontCatalog {
+	return &FontCatalog{Name: desc.Name, Type: desc.Type, Size: float64(desc.Size), DistanceRange: float64(desc.Distance), Atlas: desc.Atlas}
+}
+
+func (g *FontCatalogGenerater) SetCache(cache *BuildCache) {
+	g.cache = cache
+}
+
+func styleName(bold, italic bool) string {
+	if bold {
+		if italic {
+			return "boldItalic"
+		}
+		return "bold"
+	}
+	if italic {
+		return "italic"
+	}
+	return "regular"
+}
+
+// fontStyle is one style of a catalog font, the unit the atlases are built
+// from.
+type fontStyle struct {
+	font         *Font
+	name         string
+	fontData     []byte
+	fontPath     string
+	file         FontFile
+	fontHash     string
+	characterSet []rune
+	bold         bool
+	italic       bool
+}
+
+func assetSuffix(bold, italic bool) string {
+	if bold {
+		if italic {
+			return "_BoldItalicAssets/"
+		}
+		return "_BoldAssets/"
+	}
+	if italic {
+		return "_ItalicAssets/"
+	}
+	return "_Assets/"
+}
+
 func (g *FontCatalogGenerater) Generate(outputPath string) error {
+	return g.GenerateTo(NewDirOutput(outputPath))
+}
+
+func (g *FontCatalogGenerater) GenerateTo(out OutputWriter) error {
+	atlas := g.fontDesc.Atlas
+	if atlas == "" {
+		atlas = ATLAS_BLOCK
+	}
+	if atlas != ATLAS_BLOCK && atlas != ATLAS_FONT && atlas != ATLAS_CATALOG {
+		return fmt.Errorf("font catalog %s: unknown atlas mode %q", g.fontCatalog.Name, atlas)
+	}
+
+	g.manifest = &BuildManifest{Version: cacheVersion}
+	fonts := []*Font{}
+	catalogStyles := []*fontStyle{}
 	for _, ufont := range g.fontDesc.Fonts {
-		fontPath := path.Join(g.fontDesc.FontsDir, fmt.Sprintf("%s.ttf", ufont.Name))
-		fontData, err := ioutil.ReadFile(fontPath)
+		font, styles, err := g.loadFontStyles(ufont)
 		if err != nil {
 			return err
 		}
-		fontHolder := NewFontHolder(fontData)
-		fontInfo := fontHolder.getFontInfo()
-		font := &Font{
-			Name: "Extra",
-			Metrics: FontMetric{
-				Size:          g.fontDesc.Size,
-				DistanceRange: float64(g.fontDesc.Distance),
-				Base:          0.0,
-				LineHeight:    0.0,
-				LineGap:       int(math.Round(float64(fontInfo.LineGap/fontInfo.UnitsPerEm) * float64(g.fontDesc.Size))),
-				CapHeight: int(math.Round(
-					float64(fontInfo.Ascent/fontInfo.UnitsPerEm) * float64(g.fontDesc.Size))),
-				XHeight: 0,
-			},
-			Charset: "",
-		}
-
-		g.createFontAssets(fontData, font, g.fontCatalog, fontInfo.CharacterSet, fontPath, false, false, outputPath)
-
-		if ufont.Bold != nil {
-			boldFontPath := path.Join(g.fontDesc.FontsDir, fmt.Sprintf("%s.ttf", *ufont.Bold))
-			boldFontData, err := ioutil.ReadFile(boldFontPath)
-			if err != nil {
-				return err
-			}
-			boldFontHolder := NewFontHolder(boldFontData)
-			boldFontInfo := boldFontHolder.getFontInfo()
-			font.Bold = ufont.Bold
-			g.createFontAssets(fontData, font, g.fontCatalog, boldFontInfo.CharacterSet, boldFontPath, true, false, outputPath)
-		}
 
-		if ufont.Italic != nil {
-			italicFontPath := path.Join(g.fontDesc.FontsDir, fmt.Sprintf("%s.ttf", *ufont.Italic))
-			italicFontData, err := ioutil.ReadFile(italicFontPath)
-			if err != nil {
-				return err
+		for _, fs := range styles {
+			switch atlas {
+			case ATLAS_CATALOG:
+				catalogStyles = append(catalogStyles, fs)
+			case ATLAS_FONT:
+				dir := path.Join(g.fontCatalog.Name+assetSuffix(fs.bold, fs.italic), font.Name)
+				if err := g.createAtlasAssets([]*fontStyle{fs}, dir, font.Name, []string{font.Name}, out); err != nil {
+					return fmt.Errorf("font %s style %s: %w", font.Name, styleName(fs.bold, fs.italic), err)
+				}
+			default:
+				if err := g.createFontAssets(fs, out); err != nil {
+					return err
+				}
 			}
-			italicFontHolder := NewFontHolder(italicFontData)
-			italicFontInfo := italicFontHolder.getFontInfo()
-			font.Italic = ufont.Italic
-			g.createFontAssets(fontData, font, g.fontCatalog, italicFontInfo.CharacterSet, italicFontPath, false, true, outputPath)
 		}
 
-		if ufont.BoldItalic != nil {
-			boldItalicFontPath := path.Join(g.fontDesc.FontsDir, fmt.Sprintf("%s.ttf", *ufont.BoldItalic))
-			boldItalicFontData, err := ioutil.ReadFile(boldItalicFontPath)
-			if err != nil {
-				return err
-			}
-			boldItalicFontHolder := NewFontHolder(boldItalicFontData)
-			boldItalicFontInfo := boldItalicFontHolder.getFontInfo()
-			font.BoldItalic = ufont.BoldItalic
-			g.createFontAssets(fontData, font, g.fontCatalog, boldItalicFontInfo.CharacterSet, boldItalicFontPath, true, true, outputPath)
+		fonts = append(fonts, font)
+	}
+	if len(catalogStyles) > 0 {
+		names := make([]string, len(catalogStyles))
+		for i, fs := range catalogStyles {
+			names[i] = fs.name
 		}
-
+		dir := g.fontCatalog.Name + assetSuffix(false, false)
+		if err := g.createAtlasAssets(catalogStyles, dir, g.fontCatalog.Name, names, out); err != nil {
+			return fmt.Errorf("font catalog %s: %w", g.fontCatalog.Name, err)
+		}
+	}
+	for _, font := range fonts {
 		g.fontCatalog.Fonts = append(g.fontCatalog.Fonts, *font)
 	}
-	g.createReplacementAssets(g.fontCatalog, outputPath)
+	if err := g.createReplacementAssets(g.fontCatalog, out); err != nil {
+		return fmt.Errorf("font Extra style regular block Specials: %w", err)
+	}
 
-	fcpath := path.Join(outputPath, fmt.Sprintf("%s_FontCatalog.json", g.fontCatalog.Name))
+	fcpath := fmt.Sprintf("%s_FontCatalog.json", g.fontCatalog.Name)
 
 	data, err := g.fontCatalog.ToJson()
 	if err != nil {
-		return err
+		return fmt.Errorf("font catalog %s: %w", g.fontCatalog.Name, err)
 	}
-	err = os.WriteFile(fcpath, []byte(data), os.ModePerm)
+	err = out.WriteFile(fcpath, []byte(data))
 	if err != nil {
-		return err
+		return fmt.Errorf("font catalog %s: %w", g.fontCatalog.Name, err)
+	}
+
+	mpath := fmt.Sprintf("%s_Manifest.json", g.fontCatalog.Name)
+
+	data, err = g.manifest.ToJson()
+	if err != nil {
+		return fmt.Errorf("font catalog %s: %w", g.fontCatalog.Name, err)
+	}
+	if err := out.WriteFile(mpath, []byte(data)); err != nil {
+		return fmt.Errorf("font catalog %s: %w", g.fontCatalog.Name, err)
+	}
+
+	if g.cache != nil {
+		if err := g.cache.Save(); err != nil {
+			return fmt.Errorf("font catalog %s: cache: %w", g.fontCatalog.Name, err)
+		}
 	}
 	return nil
 }
 
-func (g *FontCatalogGenerater) createBlockAssets(fontData []byte, font *Font, fontObject *FontCatalog, characterSet []rune, fontPath string, unicodeBlock *UnicodeRanges, bold bool, italic bool, outputPath string) {
-	var assetSuffix string
-	if bold {
-		if italic {
-			assetSuffix = "_BoldItalicAssets/"
-		} else {
-			assetSuffix = "_BoldAssets/"
+// Describe returns the catalog of the description without generating any
+// asset. MaxWidth and MaxHeight depend on the generated glyphs and are left
+// zero, the supported blocks list no assets.
+func (g *FontCatalogGenerater) Describe() (*FontCatalog, error) {
+	d := &FontCatalogGenerater{fontDesc: g.fontDesc, opts: g.opts, fontCatalog: newFontCatalog(g.fontDesc)}
+	for _, ufont := range d.fontDesc.Fonts {
+		font, styles, err := d.loadFontStyles(ufont)
+		if err != nil {
+			return nil, err
 		}
-	} else {
-		if italic {
-			assetSuffix = "_ItalicAssets/"
-		} else {
-			assetSuffix = "_Assets/"
+		for _, fs := range styles {
+			for _, block := range fontBlocks(font) {
+				font.Charset += blockCharset(fs.characterSet, block)
+				d.addSupportedBlock(block, font.Name, fs.name, fs.bold || fs.italic, "")
+			}
+			holder, err := fs.newHolder()
+			if err != nil {
+				return nil, err
+			}
+			font.Metrics.LineHeight, font.Metrics.Base = d.lineMetrics(holder)
 		}
+		d.fontCatalog.Fonts = append(d.fontCatalog.Fonts, *font)
 	}
-	assetsDir := path.Join(outputPath, fmt.Sprintf("%s%s", fontObject.Name, assetSuffix))
-	sdfOptions := *g.opts
 
-	sdfOptions.Filename = strings.ReplaceAll(unicodeBlock.Category, " ", "_")
+	font := d.replacementFont()
+	font.Metrics.LineHeight, font.Metrics.Base = d.lineMetrics(NewFontHolder([]byte(notosans_regular)))
+	d.addSupportedBlock(specialsBlock, ReplacementFontName, ReplacementFontName, false, "")
+	d.fontCatalog.Fonts = append(d.fontCatalog.Fonts, *font)
+	return d.fontCatalog, nil
+}
 
-	supportedCharset := ""
+// fontMetric scales the metrics of holder to the catalog size, line height
+// and base are filled in once the bitmap fonts are generated.
+func (g *FontCatalogGenerater) fontMetric(holder *FontHolder) FontMetric {
+	info := holder.Info()
+	scale := 0.0
+	if info.UnitsPerEm > 0 {
+		scale = float64(g.fontDesc.Size) / float64(info.UnitsPerEm)
+	}
+	return FontMetric{
+		Size:          g.fontDesc.Size,
+		DistanceRange: float64(g.fontDesc.Distance),
+		LineGap:       int(math.Round(float64(info.LineGap) * scale)),
+		CapHeight:     int(math.Round(float64(info.CapHeight) * scale)),
+		XHeight:       int(math.Round(float64(info.XHeight) * scale)),
+	}
+}
 
-	for _, codePoint := range characterSet {
-		if int(codePoint) >= unicodeBlock.Range[0] && int(codePoint) <= unicodeBlock.Range[1] {
-			supportedCharset += string(codePoint)
-		}
+// lineMetrics computes the line height and base the bitmap fonts generated
+// from holder get.
+func (g *FontCatalogGenerater) lineMetrics(holder *FontHolder) (int, int) {
+	geometry := NewFontGeometryWithGlyphs(NewGlyphGeometryList())
+	if !geometry.LoadMetrics(holder, float64(g.fontDesc.Size)) {
+		return 0, 0
 	}
-	font.Charset += supportedCharset
-	Charset := supportedCharset
+	fontmetric := geometry.GetFontMetrics()
+	baseline := fontmetric.AscenderY*(float64(g.fontDesc.Size)/fontmetric.EmSize) + (0.5 * float64(g.fontDesc.Distance))
+	return int(math.Round(fontmetric.LineHeight)), int(math.Round(baseline))
+}
 
-	if Charset == "" {
-		return
-	} else {
-		runs := []rune(Charset)
-		charsets := NewCharsets()
-		charsets.AddRunes(runs)
+// fontExtensions are tried in order for font styles without a file in the
+// description. WOFF2 is not read, see checkFontFormat.
+var fontExtensions = []string{".ttf", ".otf", ".ttc", ".otc", ".woff"}
 
-		holder := NewFontHolder(fontData)
+// fontFile returns the path and the file entry of the font style name of
+// ufont, either as named by the description or the first existing
+// <fontsDir>/<name> with one of the font extensions.
+func (g *FontCatalogGenerater) fontFile(ufont UnicodeBlockDescription, name string) (string, FontFile) {
+	if f, ok := ufont.Files[name]; ok {
+		return path.Join(g.fontDesc.FontsDir, f.File), f
+	}
+	for _, ext := range fontExtensions {
+		p := path.Join(g.fontDesc.FontsDir, name+ext)
+		if _, err := os.Stat(p); err == nil {
+			return p, FontFile{File: name + ext}
+		}
+	}
+	return path.Join(g.fontDesc.FontsDir, name+fontExtensions[0]), FontFile{File: name + fontExtensions[0]}
+}
 
-		gen := NewBitmapFontGenerater(holder, charsets, g.fontDesc.Size, float64(g.fontDesc.Distance), sdfOptions)
+// newHolder loads the face and variation instance of the font of fs.
+func (fs *fontStyle) newHolder() (*FontHolder, error) {
+	holder, err := NewFontHolderFace(fs.fontData, fs.file.Face)
+	if err != nil {
+		return nil, err
+	}
+	if fs.file.Instance != "" {
+		if err := holder.SetNamedInstanceByName(fs.file.Instance); err != nil {
+			return nil, err
+		}
+	}
+	if len(fs.file.Variation) > 0 {
+		values := fs.file.Variation
+		if fs.file.Instance != "" {
+			// axes left out keep the values of the instance
+			values = holder.Variation()
+			for tag, v := range fs.file.Variation {
+				values[tag] = v
+			}
+		}
+		if err := holder.SetVariation(values); err != nil {
+			return nil, err
+		}
+	}
+	return holder, nil
+}
 
-		bmfont := gen.Generate()
+// loadFontStyles reads the font files of every style of ufont.
+func (g *FontCatalogGenerater) loadFontStyles(ufont UnicodeBlockDescription) (*Font, []*fontStyle, error) {
+	font := &Font{
+		Name:    ufont.Name,
+		Charset: "",
+		Blocks:  ufont.Blocks,
+	}
 
-		if bmfont == nil {
-			return
+	styles := []struct {
+		name   *string
+		bold   bool
+		italic bool
+	}{
+		{&ufont.Name, false, false},
+		{ufont.Bold, true, false},
+		{ufont.Italic, false, true},
+		{ufont.BoldItalic, true, true},
+	}
+
+	ret := []*fontStyle{}
+	for _, style := range styles {
+		if style.name == nil {
+			continue
+		}
+		fontPath, file := g.fontFile(ufont, *style.name)
+		fontData, err := ioutil.ReadFile(fontPath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("font %s style %s: %w", ufont.Name, styleName(style.bold, style.italic), err)
+		}
+		fs := &fontStyle{
+			font:     font,
+			name:     *style.name,
+			fontData: fontData,
+			fontPath: fontPath,
+			file:     file,
+			fontHash: hashFontData(fontData),
+			bold:     style.bold,
+			italic:   style.italic,
 		}
+		fontHolder, err := fs.newHolder()
+		if err != nil {
+			return nil, nil, fmt.Errorf("font %s style %s: %s: %w", ufont.Name, styleName(style.bold, style.italic), fontPath, err)
+		}
+		fontInfo := fontHolder.getFontInfo()
 
-		assetsFontDir := path.Join(assetsDir, font.Name)
+		switch {
+		case style.bold && style.italic:
+			font.BoldItalic = style.name
+		case style.bold:
+			font.Bold = style.name
+		case style.italic:
+			font.Italic = style.name
+		default:
+			font.Metrics = g.fontMetric(fontHolder)
+		}
 
-		os.MkdirAll(assetsFontDir, os.ModePerm)
+		fs.characterSet = fontInfo.CharacterSet
+		ret = append(ret, fs)
+	}
+	return font, ret, nil
+}
 
-		for p, image := range bmfont.pageSheets {
-			imagePath := path.Join(assetsDir, font.Name, fmt.Sprintf("%s.png", bmfont.Pages[p]))
-			imaging.Save(image, imagePath)
+// buildAssets restores the assets for inputs from the cache or runs generate
+// and records what it wrote. generate returns a font for every part of inputs,
+// or a single one when there are no parts. A nil record means nothing was
+// generated.
+func (g *FontCatalogGenerater) buildAssets(inputs BuildInputs, out OutputWriter, generate func(out OutputWriter) ([]*BitmapFont, error)) (*BuildRecord, error) {
+	key := inputs.Key()
+	if g.cache != nil {
+		rec, ok, err := g.cache.restore(key, out)
+		if err != nil {
+			return nil, err
 		}
+		if ok {
+			rec.Inputs.Font = inputs.Font
+			for i := range rec.Parts {
+				rec.Parts[i].Inputs.Font = inputs.Parts[i].Font
+				rec.Inputs.Parts[i].Font = inputs.Parts[i].Font
+			}
+			g.manifest.Records = append(g.manifest.Records, *rec)
+			return rec, nil
+		}
+	}
 
-		font.Metrics.LineHeight = bmfont.Common.LineHeight
-		font.Metrics.Base = bmfont.Common.Base
+	rout := newRecordingOutput(out, g.cache != nil)
+	bmfonts, err := generate(rout)
+	if err != nil || len(rout.names) == 0 {
+		return nil, err
+	}
 
-		for _, char := range bmfont.Chars {
-			fontObject.MaxWidth = math.Max(fontCatalog.MaxWidth, float64(char.Width))
-			fontObject.MaxHeight = math.Max(fontCatalog.MaxHeight, float64(char.Height))
+	rec := &BuildRecord{
+		Key:     key,
+		Inputs:  inputs,
+		Outputs: rout.names,
+	}
+	if len(inputs.Parts) == 0 {
+		fillBuildRecord(rec, bmfonts[0])
+	} else {
+		rec.Parts = make([]BuildRecord, len(inputs.Parts))
+		for i, bmfont := range bmfonts {
+			part := &rec.Parts[i]
+			part.Inputs = inputs.Parts[i]
+			if bmfont == nil {
+				continue
+			}
+			part.Outputs = []string{inputs.Parts[i].Output}
+			fillBuildRecord(part, bmfont)
+			rec.MaxWidth = math.Max(rec.MaxWidth, part.MaxWidth)
+			rec.MaxHeight = math.Max(rec.MaxHeight, part.MaxHeight)
 		}
+	}
 
-		data, _ := bmfont.ToJson()
+	if g.cache != nil {
+		if err := g.cache.store(rec, rout.files); err != nil {
+			return nil, err
+		}
+	}
+	g.manifest.Records = append(g.manifest.Records, *rec)
+	return rec, nil
+}
 
-		jsonPath := path.Join(assetsDir, font.Name, fmt.Sprintf("%s.json", sdfOptions.Filename))
-		os.WriteFile(jsonPath, []byte(data), os.ModePerm)
+func fillBuildRecord(rec *BuildRecord, bmfont *BitmapFont) {
+	rec.LineHeight = bmfont.Common.LineHeight
+	rec.Base = bmfont.Common.Base
+	for _, char := range bmfont.Chars {
+		rec.MaxWidth = math.Max(rec.MaxWidth, float64(char.Width))
+		rec.MaxHeight = math.Max(rec.MaxHeight, float64(char.Height))
 	}
 }
 
-func (g *FontCatalogGenerater) createFontAssets(fontData []byte, font *Font, fontObject *FontCatalog, characterSet []rune, fontPath string, bold bool, italic bool, outputPath string) {
+// fontBlocks returns the unicode blocks requested for font, all known blocks
+// when the font does not list any.
+func fontBlocks(font *Font) []*UnicodeRanges {
 	var fontUnicodeBlockNames []string
 	if len(font.Blocks) > 0 {
 		fontUnicodeBlockNames = font.Blocks
@@ -189,6 +412,7 @@ func (g *FontCatalogGenerater) createFontAssets(fontData []byte, font *Font, fon
 		fontUnicodeBlockNames = unicodeBlockNames
 	}
 
+	blocks := []*UnicodeRanges{}
 	for _, blockName := range fontUnicodeBlockNames {
 		var selectedBlock *UnicodeRanges
 		for i := range unicodeBlocks {
@@ -196,105 +420,309 @@ func (g *FontCatalogGenerater) createFontAssets(fontData []byte, font *Font, fon
 				selectedBlock = &unicodeBlocks[i]
 			}
 		}
-		if selectedBlock == nil {
-			continue
+		if selectedBlock != nil {
+			blocks = append(blocks, selectedBlock)
 		}
-		g.createBlockAssets(fontData, font, fontObject, characterSet, fontPath, selectedBlock, bold, italic, outputPath)
-
-		var blockEntry *UnicodeBlock
+	}
+	return blocks
+}
 
-		for _, sb := range fontObject.SupportedBlocks {
-			if sb.Name == blockName {
-				blockEntry = &sb
-			}
+func blockCharset(characterSet []rune, unicodeBlock *UnicodeRanges) string {
+	supportedCharset := ""
+	for _, codePoint := range characterSet {
+		if int(codePoint) >= unicodeBlock.Range[0] && int(codePoint) <= unicodeBlock.Range[1] {
+			supportedCharset += string(codePoint)
 		}
-		if blockEntry == nil {
-			fontObject.SupportedBlocks = append(fontObject.SupportedBlocks, UnicodeBlock{
-				Name:  blockName,
-				Min:   selectedBlock.Range[0],
-				Max:   selectedBlock.Range[1],
-				Fonts: []string{font.Name},
-			})
-		} else if !bold && !italic {
-			blockEntry.Fonts = append(blockEntry.Fonts, font.Name)
+	}
+	return supportedCharset
+}
+
+// newGenerater prepares the generation of charset from the font of fs.
+func (g *FontCatalogGenerater) newGenerater(fs *fontStyle, charset string, opts BitmapFontOptions) (*BitmapFontGenerater, error) {
+	charsets := NewCharsets()
+	charsets.AddRunes([]rune(charset))
+
+	holder, err := fs.newHolder()
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Identifier == GLYPH_INDEX {
+		charsets = holder.GlyphClosure(charsets)
+		if charsets == nil {
+			return nil, errors.New("cannot compute glyph closure")
 		}
 	}
+
+	return NewBitmapFontGenerater(holder, charsets, g.fontDesc.Size, float64(g.fontDesc.Distance), opts), nil
 }
 
-func (g *FontCatalogGenerater) createReplacementAssets(fontObject *FontCatalog, outputPath string) error {
-	h := NewFontHolder([]byte(notosans_regular))
-	fontInfo := h.getFontInfo()
+// createBlockAssets writes the bitmap font of one unicode block and returns
+// its descriptor, nothing is written when the font has no glyph in the block.
+func (g *FontCatalogGenerater) createBlockAssets(fs *fontStyle, unicodeBlock *UnicodeRanges, out OutputWriter) (string, error) {
+	font := fs.font
+	assetsDir := g.fontCatalog.Name + assetSuffix(fs.bold, fs.italic)
 	sdfOptions := *g.opts
-	font := &Font{
-		Name: "Extra",
-		Metrics: FontMetric{
-			Size:          g.fontDesc.Size,
-			DistanceRange: float64(g.fontDesc.Distance),
-			Base:          0.0,
-			LineHeight:    0.0,
-			LineGap:       int(math.Round(float64(fontInfo.LineGap/fontInfo.UnitsPerEm) * float64(g.fontDesc.Size))),
-			CapHeight: int(math.Round(
-				float64(fontInfo.Ascent/fontInfo.UnitsPerEm) * float64(g.fontDesc.Size))),
-			XHeight: 0,
-		},
-		Charset: "",
-	}
-	assetsDir := path.Join(outputPath, fmt.Sprintf("%s%s", fontObject.Name, "_Assets/"))
 
-	sdfOptions.Filename = "Specials"
+	sdfOptions.Filename = strings.ReplaceAll(unicodeBlock.Category, " ", "_")
 
-	supportedCharset := "�"
+	supportedCharset := blockCharset(fs.characterSet, unicodeBlock)
 	font.Charset += supportedCharset
-	charsets := NewCharsets()
-	charsets.AddRunes([]rune(supportedCharset))
-
-	gen := NewBitmapFontGenerater(h, charsets, g.fontDesc.Size, float64(g.fontDesc.Distance), sdfOptions)
+	Charset := supportedCharset
 
-	bmfont := gen.Generate()
+	if Charset == "" {
+		return "", nil
+	}
 
-	if bmfont == nil {
-		return errors.New("error")
+	assetsFontDir := path.Join(assetsDir, font.Name)
+
+	inputs := BuildInputs{
+		Font:      fs.fontPath,
+		FontHash:  fs.fontHash,
+		Face:      fs.file.Face,
+		Instance:  fs.file.Instance,
+		Variation: fs.file.Variation,
+		Block:     unicodeBlock.Category,
+		Style:     styleName(fs.bold, fs.italic),
+		Size:      g.fontDesc.Size,
+		Distance:  g.fontDesc.Distance,
+		FieldType: sdfOptions.FieldType,
+		Output:    assetsFontDir,
+		Options:   sdfOptions,
 	}
 
-	assetsFontDir := path.Join(assetsDir, "Extra")
+	rec, err := g.buildAssets(inputs, out, func(out OutputWriter) ([]*BitmapFont, error) {
+		gen, err := g.newGenerater(fs, Charset, sdfOptions)
+		if err != nil {
+			return nil, err
+		}
 
-	os.MkdirAll(assetsFontDir, os.ModePerm)
+		bmfont := gen.Generate()
+		if err := gen.Err(); err != nil {
+			return nil, err
+		}
+
+		if bmfont == nil {
+			return nil, nil
+		}
 
-	for p, image := range bmfont.pageSheets {
-		imagePath := path.Join(assetsDir, "Extra", fmt.Sprintf("%s.png", bmfont.Pages[p]))
-		imaging.Save(image, imagePath)
+		if err := bmfont.Write(out, assetsFontDir, sdfOptions.Filename); err != nil {
+			return nil, err
+		}
+		return []*BitmapFont{bmfont}, nil
+	})
+	if err != nil || rec == nil {
+		return "", err
 	}
 
-	font.Metrics.LineHeight = bmfont.Common.LineHeight
-	font.Metrics.Base = bmfont.Common.Base
+	font.Metrics.LineHeight = rec.LineHeight
+	font.Metrics.Base = rec.Base
 
-	for _, char := range bmfont.Chars {
-		fontObject.MaxWidth = math.Max(fontCatalog.MaxWidth, float64(char.Width))
-		fontObject.MaxHeight = math.Max(fontCatalog.MaxHeight, float64(char.Height))
+	g.fontCatalog.MaxWidth = math.Max(g.fontCatalog.MaxWidth, rec.MaxWidth)
+	g.fontCatalog.MaxHeight = math.Max(g.fontCatalog.MaxHeight, rec.MaxHeight)
+
+	return path.Join(assetsFontDir, sdfOptions.Filename+BitmapFontJSON.Ext()), nil
+}
+
+func (g *FontCatalogGenerater) createFontAssets(fs *fontStyle, out OutputWriter) error {
+	for _, block := range fontBlocks(fs.font) {
+		asset, err := g.createBlockAssets(fs, block, out)
+		if err != nil {
+			return fmt.Errorf("font %s style %s block %s: %w", fs.font.Name, styleName(fs.bold, fs.italic), block.Category, err)
+		}
+		g.addSupportedBlock(block, fs.font.Name, fs.name, fs.bold || fs.italic, asset)
 	}
+	return nil
+}
 
-	data, _ := bmfont.ToJson()
+// createAtlasAssets packs all blocks of styles into one set of pages named
+// pageName in dir, the bitmap font of styles[i] is written as filenames[i].
+func (g *FontCatalogGenerater) createAtlasAssets(styles []*fontStyle, dir string, pageName string, filenames []string, out OutputWriter) error {
+	sdfOptions := *g.opts
+	sdfOptions.Filename = pageName
+
+	inputs := BuildInputs{
+		Size:      g.fontDesc.Size,
+		Distance:  g.fontDesc.Distance,
+		FieldType: sdfOptions.FieldType,
+		Output:    dir,
+		Options:   sdfOptions,
+		Parts:     make([]BuildInputs, len(styles)),
+	}
+	charsets := make([]string, len(styles))
+	for i, fs := range styles {
+		part := BuildInputs{
+			Font:      fs.fontPath,
+			FontHash:  fs.fontHash,
+			Face:      fs.file.Face,
+			Instance:  fs.file.Instance,
+			Variation: fs.file.Variation,
+			Style:     styleName(fs.bold, fs.italic),
+			Output:    path.Join(dir, filenames[i]+BitmapFontJSON.Ext()),
+		}
+		for _, block := range fontBlocks(fs.font) {
+			supportedCharset := blockCharset(fs.characterSet, block)
+			fs.font.Charset += supportedCharset
+			charsets[i] += supportedCharset
+			part.Blocks = append(part.Blocks, block.Category)
+		}
+		inputs.Parts[i] = part
+	}
 
-	jsonPath := path.Join(assetsDir, "/Extra/Specials.json")
-	os.WriteFile(jsonPath, []byte(data), os.ModePerm)
+	rec, err := g.buildAssets(inputs, out, func(out OutputWriter) ([]*BitmapFont, error) {
+		gens := make([]*BitmapFontGenerater, len(styles))
+		for i, fs := range styles {
+			gen, err := g.newGenerater(fs, charsets[i], sdfOptions)
+			if err != nil {
+				return nil, fmt.Errorf("font %s style %s: %w", fs.font.Name, styleName(fs.bold, fs.italic), err)
+			}
+			gens[i] = gen
+		}
+
+		shared := NewSharedBitmapFontGenerater(gens, sdfOptions)
+		bmfonts := shared.Generate()
+		if err := shared.Err(); err != nil {
+			return nil, err
+		}
+
+		pages := false
+		for i, bmfont := range bmfonts {
+			if bmfont == nil {
+				continue
+			}
+			if !pages {
+				if err := bmfont.writePages(out, dir); err != nil {
+					return nil, err
+				}
+				pages = true
+			}
+			if err := bmfont.writeDescriptor(out, dir, filenames[i], BitmapFontJSON); err != nil {
+				return nil, err
+			}
+		}
+		return bmfonts, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for i, fs := range styles {
+		var part *BuildRecord
+		if rec != nil && len(rec.Parts[i].Outputs) > 0 {
+			part = &rec.Parts[i]
+			fs.font.Metrics.LineHeight = part.LineHeight
+			fs.font.Metrics.Base = part.Base
+			g.fontCatalog.MaxWidth = math.Max(g.fontCatalog.MaxWidth, part.MaxWidth)
+			g.fontCatalog.MaxHeight = math.Max(g.fontCatalog.MaxHeight, part.MaxHeight)
+		}
+		for _, block := range fontBlocks(fs.font) {
+			asset := ""
+			if part != nil && blockCharset(fs.characterSet, block) != "" {
+				asset = part.Outputs[0]
+			}
+			g.addSupportedBlock(block, fs.font.Name, fs.name, fs.bold || fs.italic, asset)
+		}
+	}
+	return nil
+}
 
+// addSupportedBlock records that fontName serves block, asset is the bitmap
+// font descriptor holding the glyphs of the style named styleName.
+func (g *FontCatalogGenerater) addSupportedBlock(block *UnicodeRanges, fontName string, styleName string, styled bool, asset string) {
 	var blockEntry *UnicodeBlock
 
-	for _, sb := range fontObject.SupportedBlocks {
-		if sb.Name == "Specials" {
-			blockEntry = &sb
+	for i := range g.fontCatalog.SupportedBlocks {
+		if g.fontCatalog.SupportedBlocks[i].Name == block.Category {
+			blockEntry = &g.fontCatalog.SupportedBlocks[i]
 		}
 	}
 	if blockEntry == nil {
-		fontObject.SupportedBlocks = append(fontObject.SupportedBlocks, UnicodeBlock{
-			Name:  "Specials",
-			Min:   65520,
-			Max:   65535,
-			Fonts: []string{"Extra"},
+		g.fontCatalog.SupportedBlocks = append(g.fontCatalog.SupportedBlocks, UnicodeBlock{
+			Name:  block.Category,
+			Min:   block.Range[0],
+			Max:   block.Range[1],
+			Fonts: []string{fontName},
 		})
-	} else {
-		blockEntry.Fonts = append(blockEntry.Fonts, "Extra")
+		blockEntry = &g.fontCatalog.SupportedBlocks[len(g.fontCatalog.SupportedBlocks)-1]
+	} else if !styled {
+		blockEntry.Fonts = append(blockEntry.Fonts, fontName)
+	}
+	if asset != "" {
+		if blockEntry.Assets == nil {
+			blockEntry.Assets = make(map[string]string)
+		}
+		blockEntry.Assets[styleName] = asset
+	}
+}
+
+var specialsBlock = &UnicodeRanges{Category: "Specials", Range: [2]int{65520, 65535}}
+
+// replacementFont is the font serving the replacement character.
+func (g *FontCatalogGenerater) replacementFont() *Font {
+	return &Font{
+		Name:    ReplacementFontName,
+		Metrics: g.fontMetric(NewFontHolder([]byte(notosans_regular))),
+		Charset: "",
 	}
+}
+
+func (g *FontCatalogGenerater) createReplacementAssets(fontObject *FontCatalog, out OutputWriter) error {
+	sdfOptions := *g.opts
+	font := g.replacementFont()
+	assetsDir := fmt.Sprintf("%s%s", fontObject.Name, "_Assets/")
+
+	sdfOptions.Filename = "Specials"
+
+	supportedCharset := "�"
+	font.Charset += supportedCharset
+	assetsFontDir := path.Join(assetsDir, ReplacementFontName)
+
+	inputs := BuildInputs{
+		Font:      "NotoSans-Regular.ttf",
+		FontHash:  hashFontData([]byte(notosans_regular)),
+		Block:     "Specials",
+		Style:     styleName(false, false),
+		Size:      g.fontDesc.Size,
+		Distance:  g.fontDesc.Distance,
+		FieldType: sdfOptions.FieldType,
+		Output:    assetsFontDir,
+		Options:   sdfOptions,
+	}
+
+	rec, err := g.buildAssets(inputs, out, func(out OutputWriter) ([]*BitmapFont, error) {
+		gen, err := g.newGenerater(&fontStyle{font: font, fontData: []byte(notosans_regular)}, supportedCharset, sdfOptions)
+		if err != nil {
+			return nil, err
+		}
+
+		bmfont := gen.Generate()
+		if err := gen.Err(); err != nil {
+			return nil, err
+		}
+
+		if bmfont == nil {
+			return nil, nil
+		}
+
+		if err := bmfont.Write(out, assetsFontDir, sdfOptions.Filename); err != nil {
+			return nil, err
+		}
+		return []*BitmapFont{bmfont}, nil
+	})
+	if err != nil {
+		return err
+	}
+	if rec == nil {
+		return errors.New("no glyphs generated")
+	}
+
+	font.Metrics.LineHeight = rec.LineHeight
+	font.Metrics.Base = rec.Base
+
+	fontObject.MaxWidth = math.Max(fontObject.MaxWidth, rec.MaxWidth)
+	fontObject.MaxHeight = math.Max(fontObject.MaxHeight, rec.MaxHeight)
+
+	g.addSupportedBlock(specialsBlock, ReplacementFontName, ReplacementFontName, false, path.Join(assetsFontDir, sdfOptions.Filename+BitmapFontJSON.Ext()))
 
 	g.fontCatalog.Fonts = append(g.fontCatalog.Fonts, *font)
 
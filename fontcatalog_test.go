@@ -1,15 +1,19 @@
 package fontcatalog
 
 import (
+	"bytes"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
 func TestFontCatalogGenerater(t *testing.T) {
 	data, _ := os.Open("./DefaultFonts.json")
-	fcd := ReadFontCatalogDescription(data)
+	fcd, err := ReadFontCatalogDescription(data)
 
-	if fcd == nil {
+	if err != nil || fcd == nil {
 		t.FailNow()
 	}
 
@@ -17,9 +21,124 @@ func TestFontCatalogGenerater(t *testing.T) {
 
 	gen := NewFontCatalogGenerater(fcd, &opts)
 
-	err := gen.Generate("./data")
+	err = gen.Generate("./data")
 
 	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFontCatalogGeneraterMissingFont(t *testing.T) {
+	fcd, err := ReadFontCatalogDescription(strings.NewReader(`{"name":"Missing","size":32,"distance":8,"type":"sdf","fontsDir":"./fonts","fonts":[{"name":"NotAFont","blocks":["Basic Latin"]}]}`))
+	if err != nil {
+		t.FailNow()
+	}
+
+	opts := DefaultBitmapFontOptions("")
+	err = NewFontCatalogGenerater(fcd, &opts).Generate(t.TempDir())
+
+	if err == nil || !strings.Contains(err.Error(), "NotAFont") {
+		t.FailNow()
+	}
+
+	// woff2 files are not picked for a style
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "NotAFont.woff2"), []byte("wOF2\x00\x01\x00\x00"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fcd.FontsDir = dir
+	err = NewFontCatalogGenerater(fcd, &opts).Generate(t.TempDir())
+	if err == nil || !strings.Contains(err.Error(), "NotAFont.ttf") {
+		t.Fatal(err)
+	}
+}
+
+func TestFontCatalogGeneraterAtlas(t *testing.T) {
+	generate := func(atlas string) (*MemoryOutput, *FontCatalog) {
+		fcd, err := ReadFontCatalogDescription(strings.NewReader(`{"name":"Test","size":32,"distance":8,"type":"sdf","atlas":"` + atlas + `","fontsDir":"./fonts","fonts":[{"name":"FiraGO_Map","bold":"FiraGO_MapBold","blocks":["Basic Latin","Latin-1 Supplement"]}]}`))
+		if err != nil {
+			t.FailNow()
+		}
+		opts := DefaultBitmapFontOptions("")
+		out := NewMemoryOutput()
+		if err := NewFontCatalogGenerater(fcd, &opts).GenerateTo(out); err != nil {
+			t.Fatal(err)
+		}
+		data, err := out.ReadFile("Test_FontCatalog.json")
+		if err != nil {
+			t.FailNow()
+		}
+		catalog, err := ReadFontCatalog(bytes.NewReader(data))
+		if err != nil {
+			t.FailNow()
+		}
+		return out, catalog
+	}
+
+	readFont := func(out *MemoryOutput, name string) *BitmapFont {
+		data, err := out.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bmfont, err := ReadBitmapFont(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return bmfont
+	}
+
+	out, catalog := generate(ATLAS_FONT)
+	for _, name := range out.Names() {
+		if strings.HasSuffix(name, "Basic_Latin.png") {
+			t.Fatalf("%s written in font atlas mode", name)
+		}
+	}
+	regular := readFont(out, "Test_Assets/FiraGO_Map/FiraGO_Map.json")
+	if regular.Pages[0] != "FiraGO_Map" {
+		t.FailNow()
+	}
+	blocks := map[string]bool{}
+	for _, c := range regular.Chars {
+		switch {
+		case c.ID < 0x80:
+			blocks["Basic Latin"] = true
+		case c.ID < 0x100:
+			blocks["Latin-1 Supplement"] = true
+		}
+	}
+	if len(blocks) != 2 {
+		t.FailNow()
+	}
+	readFont(out, "Test_BoldAssets/FiraGO_Map/FiraGO_Map.json")
+	for _, sb := range catalog.SupportedBlocks {
+		if sb.Name == "Basic Latin" && sb.Assets["FiraGO_MapBold"] != "Test_BoldAssets/FiraGO_Map/FiraGO_Map.json" {
+			t.FailNow()
+		}
+	}
+
+	out, catalog = generate(ATLAS_CATALOG)
+	if _, err := out.ReadFile("Test_Assets/Test.png"); err != nil {
+		t.FailNow()
+	}
+	regular = readFont(out, "Test_Assets/FiraGO_Map.json")
+	bold := readFont(out, "Test_Assets/FiraGO_MapBold.json")
+	if len(regular.Pages) != len(bold.Pages) || regular.Pages[0] != "Test" || bold.Pages[0] != "Test" {
+		t.FailNow()
+	}
+	if catalog.Atlas != ATLAS_CATALOG || catalog.Fonts[0].Metrics.LineHeight == 0 {
+		t.FailNow()
+	}
+	for _, sb := range catalog.SupportedBlocks {
+		if sb.Assets["FiraGO_Map"] != "Test_Assets/FiraGO_Map.json" || sb.Assets["FiraGO_MapBold"] != "Test_Assets/FiraGO_MapBold.json" {
+			if sb.Name != "Specials" {
+				t.Fatalf("block %s assets %v", sb.Name, sb.Assets)
+			}
+		}
+	}
+
+	fcd, _ := ReadFontCatalogDescription(strings.NewReader(`{"name":"Test","atlas":"page","fonts":[]}`))
+	opts := DefaultBitmapFontOptions("")
+	if err := NewFontCatalogGenerater(fcd, &opts).GenerateTo(NewMemoryOutput()); err == nil {
 		t.FailNow()
 	}
 }
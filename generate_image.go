@@ -5,23 +5,63 @@ import (
 )
 
 type CharsetImage struct {
-	font  Charset
-	image image.Image
-	glyph *GlyphGeometry
+	font   Charset
+	image  image.Image
+	glyph  *GlyphGeometry
+	plane  glyphPlane
+	source int
 }
 
-func generateImage(fgeom *FontGeometry, char rune, fieldType string, distanceRange float64, ec EdgeColoring, angleThreshold float64, seed uint64, attr *GeneratorAttributes) *CharsetImage {
+func generateImage(fgeom *FontGeometry, char rune, fieldType string, distanceRange float64, ec EdgeColoring, angleThreshold float64, seed uint64, attr *GeneratorAttributes) (*CharsetImage, error) {
 	glyph := fgeom.GetGlyphFromUnicode(char)
 
+	if glyph == nil {
+		return nil, nil
+	}
+
+	return generateGlyphImage(glyph, string(char), fieldType, distanceRange, ec, angleThreshold, seed, attr)
+}
+
+func generateIndexImage(fgeom *FontGeometry, index GlyphIndex, fieldType string, distanceRange float64, ec EdgeColoring, angleThreshold float64, seed uint64, attr *GeneratorAttributes) (*CharsetImage, error) {
+	glyph := fgeom.GetGlyphFromIndex(index)
+
+	if glyph == nil {
+		return nil, nil
+	}
+
+	return generateGlyphImage(glyph, "", fieldType, distanceRange, ec, angleThreshold, seed, attr)
+}
+
+// generateGlyphImage rasterizes glyph, whitespace gets a char without image.
+func generateGlyphImage(glyph *GlyphGeometry, char string, fieldType string, distanceRange float64, ec EdgeColoring, angleThreshold float64, seed uint64, attr *GeneratorAttributes) (*CharsetImage, error) {
+	// like BMFont, chars are identified by code point unless the atlas is
+	// built from glyph indices
+	identifier := GLYPH_INDEX
+	if char != "" {
+		identifier = UNICODE_CODEPOINT
+	}
+	id := glyph.GetIdentifier(identifier)
+
+	// whitespace keeps its advance but has nothing to draw
 	if glyph.IsWhiteSpace() {
-		return nil
+		return &CharsetImage{
+			glyph: glyph,
+			plane: glyphPlane{Advance: glyph.GetAdvance()},
+			font: Charset{
+				ID:       id,
+				Index:    glyph.GetIndex(),
+				Char:     char,
+				XAdvance: int(glyph.GetAdvance()),
+				Channel:  15,
+			},
+		}, nil
 	}
 
 	glyph.WrapBox(1, distanceRange, 0)
 
-	box := glyph.GetBoxRect()
+	box := glyph.GetGlyphBox()
 
-	xOffset, yOffset, width, height := box[0], box[1], box[2], box[3]
+	width, height := box.Rect[2], box.Rect[3]
 
 	XAdvance := int(glyph.GetAdvance())
 
@@ -48,21 +88,21 @@ func generateImage(fgeom *FontGeometry, char rune, fieldType string, distanceRan
 	err := glyphGenerater(fieldType, bitmap, glyph, attr)
 
 	if err != nil {
-		return nil
+		return nil, err
 	}
 
 	return &CharsetImage{
 		glyph: glyph,
 		image: bitmap.GetImage(),
+		plane: glyphPlane{Bounds: box.Bounds, Advance: box.Advance},
 		font: Charset{
-			ID:       glyph.GetIndex(),
-			Char:     string(char),
+			ID:       id,
+			Index:    glyph.GetIndex(),
+			Char:     char,
 			Width:    width,
 			Height:   height,
-			XOffset:  xOffset,
-			YOffset:  yOffset,
 			XAdvance: XAdvance,
 			Channel:  15,
 		},
-	}
+	}, nil
 }
@@ -4,7 +4,11 @@ import (
 	"fmt"
 	"image"
 	"image/color"
+	"image/draw"
 	"math"
+	"runtime"
+	"sort"
+	"sync"
 
 	"github.com/flywave/imaging"
 	"github.com/fogleman/gg"
@@ -19,53 +23,168 @@ type BitmapFontGenerater struct {
 	attr          *GeneratorAttributes
 	fontSize      int
 	distanceRange float64
+	workers       []glyphWorker
+	err           error
 }
 
 func NewBitmapFontGenerater(holder *FontHolder, charsets *Charsets, fontSize int, distanceRange float64, opt BitmapFontOptions) *BitmapFontGenerater {
+	if opt.Identifier == IDENTIFIER_UNSET {
+		opt.Identifier = UNICODE_CODEPOINT
+	}
 	ret := &BitmapFontGenerater{Opt: opt, Charsets: charsets, holder: holder, glyphs: NewGlyphGeometryList(), attr: NewGeneratorAttributes(), fontSize: fontSize, distanceRange: distanceRange}
 	ret.font = NewFontGeometryWithGlyphs(ret.glyphs)
-	ret.font.LoadFromCharset(ret.holder, float64(fontSize), ret.Charsets)
+	if opt.Identifier == GLYPH_INDEX {
+		ret.font.LoadFromGlyphset(ret.holder, float64(fontSize), ret.Charsets)
+	} else {
+		ret.font.LoadFromCharset(ret.holder, float64(fontSize), ret.Charsets)
+	}
 	return ret
 }
 
+// packBatchSize is the number of glyphs rasterized before they are handed to
+// the packer, it bounds the memory held by glyph images waiting for a page.
+const packBatchSize = 256
+
+// Generate rasterizes and packs the glyphs, it returns nil when there are
+// none or generation failed, see Err.
 func (g *BitmapFontGenerater) Generate() *BitmapFont {
-	font := &BitmapFont{pagesMap: make(map[int]Page), pageSheets: make(map[int]image.Image)}
-	start := 0
-	done := true
-	p := 0
-
-	chars := g.Charsets.GetRunes()
-
-	for done {
-		limit := g.Opt.Limit
-		if start+g.Opt.Limit > g.Charsets.Size() {
-			limit = g.Charsets.Size() - start
-			done = false
-		}
-		images := g.mapCharsets(start, start+limit, chars)
-		image, chrs := g.packeCharsets(images, p)
-		if image != nil && chrs != nil {
-			font.Chars = append(font.Chars, chrs...)
-			font.pageSheets[p] = image
-			var page string
-			if p > 1 {
-				page = fmt.Sprintf("%s.%d", g.Opt.Filename, p)
-			} else {
-				page = g.Opt.Filename
+	s := NewSharedBitmapFontGenerater([]*BitmapFontGenerater{g}, g.Opt)
+	font := s.Generate()[0]
+	g.err = s.err
+	return font
+}
+
+// Err returns the error of the last Generate.
+func (g *BitmapFontGenerater) Err() error {
+	return g.err
+}
+
+// SharedBitmapFontGenerater packs the glyphs of several generaters into one
+// set of pages. Every generater still gets a font of its own, all of them
+// refer to the same pages named after Opt.Filename.
+type SharedBitmapFontGenerater struct {
+	Opt  BitmapFontOptions
+	gens []*BitmapFontGenerater
+	err  error
+}
+
+func NewSharedBitmapFontGenerater(gens []*BitmapFontGenerater, opt BitmapFontOptions) *SharedBitmapFontGenerater {
+	return &SharedBitmapFontGenerater{Opt: opt, gens: gens}
+}
+
+// Generate returns a font for every generater in the same order, nil for the
+// ones that have no glyphs. All are nil when generation failed, see Err.
+func (s *SharedBitmapFontGenerater) Generate() []*BitmapFont {
+	s.err = nil
+	defer func() {
+		for _, g := range s.gens {
+			g.releaseWorkers()
+		}
+	}()
+	fonts := make([]*BitmapFont, len(s.gens))
+	for i := range fonts {
+		fonts[i] = &BitmapFont{pagesMap: make(map[int]Page)}
+	}
+	sheets := make(map[int]image.Image)
+	pages := []string{}
+
+	addPage := func(p *atlasPage) {
+		if len(p.nodes) == 0 {
+			return
+		}
+		page := len(pages)
+		sheets[page] = s.drawPage(fonts, p, page)
+		if page > 0 {
+			pages = append(pages, fmt.Sprintf("%s.%d", s.Opt.Filename, page))
+		} else {
+			pages = append(pages, s.Opt.Filename)
+		}
+	}
+
+	// glyphs are packed by batches as they are rasterized, PackerTryAll picks
+	// the packer doing best on the first batch
+	var f *pageFiller
+	for i, g := range s.gens {
+		chars := g.Charsets.GetRunes()
+		for start := 0; start < len(chars); start += packBatchSize {
+			end := start + packBatchSize
+			if end > len(chars) {
+				end = len(chars)
 			}
-			font.Pages = append(font.Pages, page)
+			images, err := s.mapCharsets(fonts, i, start, end, chars)
+			if err != nil {
+				s.err = err
+				return make([]*BitmapFont, len(s.gens))
+			}
+			if f == nil {
+				if len(images) == 0 {
+					continue
+				}
+				candidate := packerCandidate{s.Opt.Packer, s.Opt.PackerMethod}
+				if s.Opt.Packer == PackerTryAll {
+					candidate = bestPackerCandidate(s.Opt, images)
+				}
+				f = newPageFiller(s.Opt, candidate, addPage)
+			}
+			f.add(images)
 		}
-		start += limit
-		p++
+	}
+	if f != nil {
+		f.flush()
 	}
 
-	if len(font.pageSheets) == 0 {
-		return nil
+	if len(sheets) == 0 {
+		return make([]*BitmapFont, len(s.gens))
+	}
+
+	resizePages(s.Opt, sheets)
+
+	for i, g := range s.gens {
+		if len(fonts[i].Chars) == 0 {
+			fonts[i] = nil
+			continue
+		}
+		fonts[i].Pages = pages
+		fonts[i].pageSheets = sheets
+		g.finish(fonts[i])
+	}
+	return fonts
+}
+
+// Err returns the error of the last Generate.
+func (s *SharedBitmapFontGenerater) Err() error {
+	return s.err
+}
+
+// mapCharsets rasterizes chars[start:end] of the generater at source and
+// returns the images to pack. Glyphs with nothing to draw, like spaces, go
+// straight to the font as empty chars on the first page.
+func (s *SharedBitmapFontGenerater) mapCharsets(fonts []*BitmapFont, source, start, end int, chars []rune) ([]*CharsetImage, error) {
+	images, err := s.gens[source].mapCharsets(start, end, chars)
+	if err != nil {
+		return nil, err
+	}
+	ret := images[:0]
+	for _, img := range images {
+		img.source = source
+		if img.image != nil {
+			ret = append(ret, img)
+			continue
+		}
+		fnt := img.font
+		fnt.YOffset = int(math.Round(s.gens[source].baseline()))
+		fonts[source].Chars = append(fonts[source].Chars, fnt)
+		fonts[source].planes = append(fonts[source].planes, img.plane)
 	}
+	return ret, nil
+}
 
+// finish fills in everything but the glyphs and pages, which are shared with
+// the other fonts of the atlas.
+func (g *BitmapFontGenerater) finish(font *BitmapFont) {
 	fontmetric := g.font.GetFontMetrics()
-	km := g.font.GetKerning()
-	font.Kerning = km.GetKernings()
+	font.metrics = &fontmetric
+	font.Kerning = g.kernings(font.Chars)
 
 	charsets := make([]string, len(font.Chars))
 	for i, c := range font.Chars {
@@ -80,7 +199,7 @@ func (g *BitmapFontGenerater) Generate() *BitmapFont {
 		Bold:         false,
 		Italic:       false,
 		Charset:      charsets,
-		Unicode:      true,
+		Unicode:      g.Opt.Identifier != GLYPH_INDEX,
 		StretchHeigt: 100,
 		Smooth:       1,
 		AA:           1,
@@ -88,17 +207,24 @@ func (g *BitmapFontGenerater) Generate() *BitmapFont {
 		Spacing:      [2]int{g.Opt.FontSpacing[0], g.Opt.FontSpacing[1]},
 	}
 
+	// glyph images are drawn opaque into every channel, only mtsdf stores a
+	// field in alpha
+	alphaChannel := One
+	if g.Opt.FieldType == MOD_MTSDF {
+		alphaChannel = Glyph
+	}
+
 	rect := font.pageSheets[0].Bounds()
-	baseline := fontmetric.AscenderY*(float64(g.fontSize)/fontmetric.EmSize) + (0.5 * g.distanceRange)
+	baseline := g.baseline()
 
 	font.Common = FontCommon{
 		LineHeight:   int(math.Round(fontmetric.LineHeight)),
 		Base:         int(math.Round(baseline)),
 		ScaleW:       rect.Dx(),
 		ScaleH:       rect.Dy(),
-		Pages:        p,
+		Pages:        len(font.Pages),
 		Packed:       0,
-		AlphaChannel: 0,
+		AlphaChannel: alphaChannel,
 		RedChannel:   0,
 		GreenChannel: 0,
 		BlueChannel:  0,
@@ -108,63 +234,348 @@ func (g *BitmapFontGenerater) Generate() *BitmapFont {
 		FieldType:     g.Opt.FieldType,
 		DistanceRange: g.distanceRange,
 	}
+}
 
-	return font
+// kernings returns the kerning pairs between chars. The font geometry keys
+// pairs by glyph index, they are mapped to char ids, a glyph shared by several
+// code points gets a pair for each of them.
+func (g *BitmapFontGenerater) kernings(chars []Charset) KerningSort {
+	km := g.font.GetKerning()
+	pairs := km.GetKernings()
+	runtime.KeepAlive(km)
+	if len(pairs) == 0 {
+		return nil
+	}
+
+	ids := make(map[int][]rune)
+	for _, c := range chars {
+		ids[c.Index] = append(ids[c.Index], rune(c.ID))
+	}
+	ret := KerningSort{}
+	for _, k := range pairs {
+		for _, first := range ids[int(k.First)] {
+			for _, second := range ids[int(k.Second)] {
+				ret = append(ret, Kerning{First: first, Second: second, Amount: k.Amount})
+			}
+		}
+	}
+	if len(ret) == 0 {
+		return nil
+	}
+	sort.Sort(ret)
+	return ret
 }
 
-func (g *BitmapFontGenerater) mapCharsets(start, end int, chars []rune) []*CharsetImage {
+func (g *BitmapFontGenerater) mapCharsets(start, end int, chars []rune) ([]*CharsetImage, error) {
+	if g.Opt.Workers > 1 && end-start > 1 {
+		return g.mapCharsetsParallel(start, end, chars)
+	}
 	ret := []*CharsetImage{}
 	for i := start; i < end; i++ {
 		if chars[i] != 0 {
-			cimg := generateImage(g.font, chars[i], g.Opt.FieldType, g.distanceRange, g.Opt.EdgeColoring, g.Opt.AngleThreshold, g.Opt.Seed, g.attr)
+			var cimg *CharsetImage
+			var err error
+			if g.Opt.Identifier == GLYPH_INDEX {
+				cimg, err = generateIndexImage(g.font, GlyphIndex(chars[i]), g.Opt.FieldType, g.distanceRange, g.Opt.EdgeColoring, g.Opt.AngleThreshold, g.Opt.Seed, g.attr)
+			} else {
+				cimg, err = generateImage(g.font, chars[i], g.Opt.FieldType, g.distanceRange, g.Opt.EdgeColoring, g.Opt.AngleThreshold, g.Opt.Seed, g.attr)
+			}
+			if err != nil {
+				return nil, g.glyphError(chars[i], err)
+			}
 			if cimg != nil {
 				ret = append(ret, cimg)
 			}
 		}
 	}
-	return ret
+	return ret, nil
 }
 
-func (g *BitmapFontGenerater) packeCharsets(images []*CharsetImage, page int) (image.Image, []Charset) {
-	if len(images) == 0 {
-		return nil, nil
+func (g *BitmapFontGenerater) mapCharsetsParallel(start, end int, chars []rune) ([]*CharsetImage, error) {
+	workers := g.Opt.Workers
+	if workers > end-start {
+		workers = end - start
+	}
+
+	if err := g.startWorkers(workers); err != nil {
+		return nil, err
+	}
+
+	results := make([]*CharsetImage, end-start)
+	errs := make([]error, end-start)
+	scale := g.font.GetGeometryScale()
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w glyphWorker) {
+			defer wg.Done()
+			for i := range jobs {
+				var glyph *GlyphGeometry
+				var char string
+				if g.Opt.Identifier == GLYPH_INDEX {
+					glyph = NewGlyphGeometryWithGlyphIndex(w.holder, scale, GlyphIndex(chars[i]))
+				} else {
+					glyph = NewGlyphGeometryWithCodePoint(w.holder, scale, chars[i])
+					char = string(chars[i])
+				}
+				if glyph.m == nil {
+					continue
+				}
+				results[i-start], errs[i-start] = generateGlyphImage(glyph, char, g.Opt.FieldType, g.distanceRange, g.Opt.EdgeColoring, g.Opt.AngleThreshold, g.Opt.Seed, w.attr)
+			}
+		}(g.workers[w])
 	}
-	packer := NewMaxRectsBinPacker(g.Opt.TextureSize[0], g.Opt.TextureSize[1], g.Opt.TexturePadding[0], g.Opt.TexturePadding[1], g.Opt.AllowRotation)
-	rects := make([]RectNode, len(images))
 
+	for i := start; i < end; i++ {
+		if chars[i] != 0 {
+			jobs <- i
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	ret := []*CharsetImage{}
+	for i, cimg := range results {
+		if errs[i] != nil {
+			return nil, g.glyphError(chars[start+i], errs[i])
+		}
+		if cimg != nil {
+			ret = append(ret, cimg)
+		}
+	}
+	return ret, nil
+}
+
+// glyphWorker is what a worker needs to rasterize glyphs alongside the
+// others, FreeType faces cannot be shared between threads.
+type glyphWorker struct {
+	holder *FontHolder
+	attr   *GeneratorAttributes
+}
+
+// startWorkers makes sure there are at least n workers, they are kept for
+// every batch until releaseWorkers.
+func (g *BitmapFontGenerater) startWorkers(n int) error {
+	for len(g.workers) < n {
+		holder, err := g.holder.clone()
+		if err != nil {
+			return err
+		}
+		g.workers = append(g.workers, glyphWorker{holder: holder, attr: NewGeneratorAttributes()})
+	}
+	return nil
+}
+
+// releaseWorkers frees the holders and attributes of the workers right away
+// instead of leaving them to the finalizers.
+func (g *BitmapFontGenerater) releaseWorkers() {
+	for _, w := range g.workers {
+		runtime.SetFinalizer(w.holder, nil)
+		w.holder.free()
+		runtime.SetFinalizer(w.attr, nil)
+		w.attr.free()
+	}
+	g.workers = nil
+}
+
+// glyphError tells the glyph, font and block rasterization failed for.
+func (g *BitmapFontGenerater) glyphError(id rune, err error) error {
+	if g.Opt.Identifier == GLYPH_INDEX {
+		return fmt.Errorf("glyph index %d of font %s block %s: %w", id, g.font.GetName(), g.Opt.Filename, err)
+	}
+	return fmt.Errorf("glyph %U of font %s block %s: %w", id, g.font.GetName(), g.Opt.Filename, err)
+}
+
+type atlasPage struct {
+	packer BinPacker
+	images []*CharsetImage
+	nodes  []RectNode
+}
+
+// pack places as many images as fit on the page, at most limit in total when
+// limit is positive, and returns the ones left over.
+func (p *atlasPage) pack(images []*CharsetImage, limit int) []*CharsetImage {
+	n := len(images)
+	if limit > 0 && len(p.images)+n > limit {
+		n = limit - len(p.images)
+	}
+	rects := make([]RectNode, n)
 	for i := range rects {
 		rects[i] = *images[i].glyph.Rect()
+		rects[i].Index = i
 	}
-	res := packer.Pack(rects, g.Opt.PackerMethod)
 
-	image := image.NewRGBA(image.Rect(0, 0, res.Width, res.Height))
+	placed := len(p.nodes)
+	res := p.packer.Pack(rects)
+	for _, node := range res.PlacedRects[placed:] {
+		p.images = append(p.images, images[node.Index])
+		p.nodes = append(p.nodes, node)
+	}
 
-	dbg := gg.NewContextForImage(image)
+	left := make([]*CharsetImage, 0, len(res.NotPlacedRects)+len(images)-n)
+	for _, node := range res.NotPlacedRects {
+		left = append(left, images[node.Index])
+	}
+	return append(left, images[n:]...)
+}
 
-	if g.Opt.FieldType == MOD_MTSDF || g.Opt.FieldType == MOD_MSDF {
-		dbg.SetColor(color.Black)
-		dbg.Clear()
+func (p *atlasPage) size() (int, int) {
+	width, height := 0, 0
+	for _, node := range p.nodes {
+		width = Max(width, node.Right())
+		height = Max(height, node.Bottom())
 	}
+	return width, height
+}
 
-	maps := make(map[int]*CharsetImage)
-	chars := []Charset{}
+// pageFiller packs glyphs into the current page until it is full, hands the
+// page to done and continues on a new one.
+type pageFiller struct {
+	opt       BitmapFontOptions
+	candidate packerCandidate
+	page      *atlasPage
+	done      func(*atlasPage)
+}
 
-	for i := range images {
-		maps[images[i].glyph.GetIndex()] = images[i]
+func newPageFiller(opt BitmapFontOptions, candidate packerCandidate, done func(*atlasPage)) *pageFiller {
+	f := &pageFiller{opt: opt, candidate: candidate, done: done}
+	f.page = f.newPage(opt.TextureSize[0], opt.TextureSize[1])
+	return f
+}
+
+func (f *pageFiller) newPage(width, height int) *atlasPage {
+	opt := f.opt
+	return &atlasPage{packer: NewBinPacker(f.candidate.algorithm, f.candidate.heuristic, width, height, opt.TexturePadding[0], opt.TexturePadding[1], opt.AllowRotation)}
+}
+
+func (f *pageFiller) add(pending []*CharsetImage) {
+	opt := f.opt
+	for len(pending) > 0 {
+		pending = f.page.pack(pending, opt.Limit)
+		if len(pending) == 0 {
+			break
+		}
+		if len(f.page.images) == 0 {
+			// the glyph does not fit an empty page, give it a page of its own size
+			r := pending[0].glyph.Rect()
+			f.page = f.newPage(Max(opt.TextureSize[0], r.W+opt.TexturePadding[0]), Max(opt.TextureSize[1], r.H+opt.TexturePadding[1]))
+			continue
+		}
+		f.done(f.page)
+		f.page = f.newPage(opt.TextureSize[0], opt.TextureSize[1])
+	}
+}
+
+func (f *pageFiller) flush() {
+	if len(f.page.nodes) > 0 {
+		f.done(f.page)
 	}
+	f.page = f.newPage(f.opt.TextureSize[0], f.opt.TextureSize[1])
+}
+
+// bestPackerCandidate packs images with every known packer and returns the
+// one that needs the fewest pages, then the smallest total page area. It is
+// given a sample of the glyphs, packing every glyph once per packer would
+// hold them all in memory.
+func bestPackerCandidate(opt BitmapFontOptions, images []*CharsetImage) packerCandidate {
+	best := packerCandidates[0]
+	bestPages, bestArea := math.MaxInt32, math.MaxInt32
+	for _, c := range packerCandidates {
+		pages, area := 0, 0
+		f := newPageFiller(opt, c, func(p *atlasPage) {
+			w, h := p.size()
+			pages++
+			area += w * h
+		})
+		f.add(images)
+		f.flush()
+		if pages < bestPages || (pages == bestPages && area < bestArea) {
+			best, bestPages, bestArea = c, pages, area
+		}
+	}
+	return best
+}
+
+// drawPage hands the glyphs of the page to the fonts they belong to and
+// returns the page image.
+func (s *SharedBitmapFontGenerater) drawPage(fonts []*BitmapFont, p *atlasPage, page int) image.Image {
+	width, height := p.size()
 
-	for _, node := range res.PlacedRects {
-		img := maps[node.Index]
+	dbg := gg.NewContextForImage(newPageImage(s.Opt, width, height))
+
+	for i, node := range p.nodes {
+		img := p.images[i]
 		if node.Rotated {
 			img.image = imaging.Rotate90(img.image)
 		}
+		font := fonts[img.source]
 		fnt := img.font
 		fnt.X = node.X
 		fnt.Y = node.Y
+		fnt.XOffset = int(math.Round(img.plane.Bounds[0]))
+		fnt.YOffset = int(math.Round(s.gens[img.source].baseline() - img.plane.Bounds[3]))
 		fnt.Page = page
-		chars = append(chars, fnt)
+		font.Chars = append(font.Chars, fnt)
+		font.planes = append(font.planes, img.plane)
 		dbg.DrawImage(img.image, node.X, node.Y)
 	}
 
-	return dbg.Image(), chars
+	return dbg.Image()
+}
+
+// baseline is the distance in pixels from the top of a line to the baseline,
+// glyph boxes extend half the distance range above the ascender.
+func (g *BitmapFontGenerater) baseline() float64 {
+	fontmetric := g.font.GetFontMetrics()
+	return fontmetric.AscenderY*(float64(g.fontSize)/fontmetric.EmSize) + (0.5 * g.distanceRange)
+}
+
+func newPageImage(opt BitmapFontOptions, width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	if opt.FieldType == MOD_MTSDF || opt.FieldType == MOD_MSDF {
+		draw.Draw(img, img.Bounds(), image.NewUniform(color.Black), image.Point{}, draw.Src)
+	}
+	return img
+}
+
+func pageSize(opt BitmapFontOptions, width, height int) (int, int) {
+	switch opt.PageSize {
+	case PageSizePowerOfTwo:
+		return NextPowerOfTwo(width), NextPowerOfTwo(height)
+	case PageSizeSquare:
+		side := Max(width, height)
+		return side, side
+	case PageSizePowerOfTwoSquare:
+		side := NextPowerOfTwo(Max(width, height))
+		return side, side
+	case PageSizeFixed:
+		return Max(width, opt.TextureSize[0]), Max(height, opt.TextureSize[1])
+	default:
+		return width, height
+	}
+}
+
+// resizePages grows every page to the same size as required by PageSize,
+// cropped pages are left as they are.
+func resizePages(opt BitmapFontOptions, sheets map[int]image.Image) {
+	if opt.PageSize == PageSizeCrop {
+		return
+	}
+	width, height := 0, 0
+	for _, sheet := range sheets {
+		width = Max(width, sheet.Bounds().Dx())
+		height = Max(height, sheet.Bounds().Dy())
+	}
+	width, height = pageSize(opt, width, height)
+
+	for p, sheet := range sheets {
+		if sheet.Bounds().Dx() == width && sheet.Bounds().Dy() == height {
+			continue
+		}
+		img := newPageImage(opt, width, height)
+		draw.Draw(img, sheet.Bounds(), sheet, sheet.Bounds().Min, draw.Src)
+		sheets[p] = img
+	}
 }
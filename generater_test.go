@@ -0,0 +1,374 @@
+package fontcatalog
+
+import (
+	"bytes"
+	"image"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestBitmapFontGeneraterWorkers(t *testing.T) {
+	data, err := ioutil.ReadFile("./fonts/FiraGO_Map.ttf")
+	if err != nil {
+		t.FailNow()
+	}
+
+	generate := func(workers int) (string, [][]byte) {
+		opts := DefaultBitmapFontOptions("Basic_Latin")
+		opts.FieldType = MOD_MSDF
+		opts.Workers = workers
+
+		gen := NewBitmapFontGenerater(NewFontHolder(data), NewCharsetsASCII(), 32, 8, opts)
+		bmfont := gen.Generate()
+		if gen.Err() != nil || bmfont == nil {
+			t.FailNow()
+		}
+		js, err := bmfont.ToJson()
+		if err != nil {
+			t.FailNow()
+		}
+		pages := make([][]byte, len(bmfont.Pages))
+		for i := range pages {
+			pages[i] = bmfont.GetPageSheet(i).(*image.RGBA).Pix
+		}
+		return js, pages
+	}
+
+	js1, pages1 := generate(1)
+	js4, pages4 := generate(4)
+	if js1 != js4 || len(pages1) != len(pages4) {
+		t.FailNow()
+	}
+	for i := range pages1 {
+		if !bytes.Equal(pages1[i], pages4[i]) {
+			t.Fatalf("page %d differs", i)
+		}
+	}
+}
+
+func TestBitmapFontGeneraterReusesWorkers(t *testing.T) {
+	data, err := ioutil.ReadFile("./fonts/FiraGO_Map.ttf")
+	if err != nil {
+		t.FailNow()
+	}
+
+	opts := DefaultBitmapFontOptions("Basic_Latin")
+	opts.Workers = 2
+	gen := NewBitmapFontGenerater(NewFontHolder(data), NewCharsetsASCII(), 32, 8, opts)
+	chars := gen.Charsets.GetRunes()
+	if _, err := gen.mapCharsets(0, 10, chars); err != nil || len(gen.workers) != 2 {
+		t.FailNow()
+	}
+	holder := gen.workers[0].holder
+	if _, err := gen.mapCharsets(10, 20, chars); err != nil || gen.workers[0].holder != holder {
+		t.FailNow()
+	}
+
+	if gen.Generate() == nil || gen.workers != nil {
+		t.FailNow()
+	}
+}
+
+func TestBitmapFontGeneraterGlyphIndex(t *testing.T) {
+	data, err := ioutil.ReadFile("./fonts/FiraGO_Map.ttf")
+	if err != nil {
+		t.FailNow()
+	}
+	holder := NewFontHolder(data)
+
+	codepoints := NewCharsets()
+	for r := rune(0x0621); r <= 0x064A; r++ {
+		codepoints.Add(r)
+	}
+	glyphs := holder.GlyphClosure(codepoints)
+	if glyphs == nil || glyphs.Size() <= codepoints.Size() {
+		t.FailNow()
+	}
+
+	shaped, err := Shape(holder, "مرحبا", "Arab", "ar", DirectionRTL, 32)
+	if err != nil {
+		t.FailNow()
+	}
+	closure := make(map[rune]bool)
+	for _, r := range glyphs.GetRunes() {
+		closure[r] = true
+	}
+	for _, sg := range shaped {
+		if !closure[rune(sg.Index)] {
+			t.FailNow()
+		}
+	}
+
+	opts := DefaultBitmapFontOptions("Arabic")
+	opts.Identifier = GLYPH_INDEX
+	gen := NewBitmapFontGenerater(holder, glyphs, 32, 8, opts)
+	bmfont := gen.Generate()
+	if bmfont == nil || bmfont.Info.Unicode {
+		t.FailNow()
+	}
+
+	ids := make(map[int]bool)
+	for _, c := range bmfont.Chars {
+		ids[c.ID] = true
+	}
+	for _, sg := range shaped {
+		if !ids[int(sg.Index)] {
+			t.FailNow()
+		}
+	}
+}
+
+func TestBitmapFontGeneraterCodePoints(t *testing.T) {
+	data, err := ioutil.ReadFile("./fonts/FiraGO_Map.ttf")
+	if err != nil {
+		t.FailNow()
+	}
+
+	// options not built by DefaultBitmapFontOptions identify glyphs by code point
+	opts := BitmapFontOptions{
+		Filename:       "Basic_Latin",
+		FontSpacing:    []int{0, 0},
+		TextureSize:    []int{512, 512},
+		TexturePadding: []int{1, 1},
+		FieldType:      MOD_SDF,
+	}
+	bmfont := NewBitmapFontGenerater(NewFontHolder(data), NewCharsetsASCII(), 32, 8, opts).Generate()
+	if bmfont == nil || !bmfont.Info.Unicode {
+		t.FailNow()
+	}
+	found := false
+	for _, c := range bmfont.Chars {
+		if c.ID == 'A' && c.Char == "A" {
+			found = true
+		}
+	}
+	if !found {
+		t.FailNow()
+	}
+}
+
+func TestBitmapFontGeneraterPages(t *testing.T) {
+	data, err := ioutil.ReadFile("./fonts/FiraGO_Map.ttf")
+	if err != nil {
+		t.FailNow()
+	}
+
+	charsets := NewCharsets()
+	for r := rune(0x20); r <= 0xff; r++ {
+		charsets.Add(r)
+	}
+
+	single := DefaultBitmapFontOptions("Latin")
+	single.TextureSize = []int{2048, 2048}
+	all := NewBitmapFontGenerater(NewFontHolder(data), charsets, 32, 8, single).Generate()
+	if all == nil || len(all.Pages) != 1 {
+		t.FailNow()
+	}
+
+	opts := DefaultBitmapFontOptions("Latin")
+	opts.TextureSize = []int{128, 128}
+	bmfont := NewBitmapFontGenerater(NewFontHolder(data), charsets, 32, 8, opts).Generate()
+	if bmfont == nil || len(bmfont.Pages) < 2 || bmfont.Common.Pages != len(bmfont.Pages) {
+		t.FailNow()
+	}
+	if len(bmfont.Chars) != len(all.Chars) {
+		t.Fatalf("%d glyphs packed, expected %d", len(bmfont.Chars), len(all.Chars))
+	}
+	if bmfont.Pages[0] != "Latin" || bmfont.Pages[1] != "Latin.1" {
+		t.FailNow()
+	}
+
+	for _, c := range bmfont.Chars {
+		sheet := bmfont.GetPageSheet(c.Page)
+		if sheet == nil || c.X+c.Width > 128 || c.Y+c.Height > 128 {
+			t.Fatalf("glyph %q outside of page %d", c.Char, c.Page)
+		}
+	}
+}
+
+func TestBitmapFontGeneraterTryAllPackers(t *testing.T) {
+	data, err := ioutil.ReadFile("./fonts/FiraGO_Map.ttf")
+	if err != nil {
+		t.FailNow()
+	}
+
+	area := func(packer PackerAlgorithm) (int, int) {
+		opts := DefaultBitmapFontOptions("Basic_Latin")
+		opts.Packer = packer
+		opts.TextureSize = []int{256, 256}
+		bmfont := NewBitmapFontGenerater(NewFontHolder(data), NewCharsetsASCII(), 32, 8, opts).Generate()
+		if bmfont == nil || len(bmfont.Chars) == 0 {
+			t.FailNow()
+		}
+		total := 0
+		for p := range bmfont.Pages {
+			b := bmfont.GetPageSheet(p).Bounds()
+			total += b.Dx() * b.Dy()
+		}
+		return len(bmfont.Pages), total
+	}
+
+	pages, total := area(PackerTryAll)
+	for _, packer := range []PackerAlgorithm{PackerMaxRects, PackerSkyline, PackerGuillotine} {
+		p, a := area(packer)
+		if pages > p || (pages == p && total > a) {
+			t.Fatalf("try all packed %d pages of %d pixels, packer %d needs %d pages of %d pixels", pages, total, packer, p, a)
+		}
+	}
+
+	// larger fonts stream the batches after the first one
+	charsets := NewCharsets()
+	for r := rune(0x20); r < 0x250; r++ {
+		charsets.Add(r)
+	}
+	count := func(packer PackerAlgorithm) int {
+		opts := DefaultBitmapFontOptions("Latin")
+		opts.Packer = packer
+		opts.TextureSize = []int{256, 256}
+		bmfont := NewBitmapFontGenerater(NewFontHolder(data), charsets, 32, 8, opts).Generate()
+		if bmfont == nil || len(bmfont.Pages) < 2 {
+			t.FailNow()
+		}
+		return len(bmfont.Chars)
+	}
+	if n := count(PackerTryAll); charsets.Size() <= packBatchSize || n != count(PackerMaxRects) {
+		t.Fatalf("%d glyphs packed", n)
+	}
+}
+
+func TestBitmapFontGeneraterPageSize(t *testing.T) {
+	data, err := ioutil.ReadFile("./fonts/FiraGO_Map.ttf")
+	if err != nil {
+		t.FailNow()
+	}
+
+	charsets := NewCharsets()
+	for r := rune(0x20); r <= 0xff; r++ {
+		charsets.Add(r)
+	}
+
+	for _, mode := range []PageSizeMode{PageSizePowerOfTwo, PageSizeSquare, PageSizePowerOfTwoSquare, PageSizeFixed} {
+		opts := DefaultBitmapFontOptions("Latin")
+		opts.TextureSize = []int{200, 200}
+		opts.PageSize = mode
+		bmfont := NewBitmapFontGenerater(NewFontHolder(data), charsets, 32, 8, opts).Generate()
+		if bmfont == nil || len(bmfont.Pages) < 2 {
+			t.FailNow()
+		}
+
+		w, h := bmfont.Common.ScaleW, bmfont.Common.ScaleH
+		for p := range bmfont.Pages {
+			b := bmfont.GetPageSheet(p).Bounds()
+			if b.Dx() != w || b.Dy() != h {
+				t.Fatalf("mode %d: page %d is %dx%d, expected %dx%d", mode, p, b.Dx(), b.Dy(), w, h)
+			}
+		}
+		switch mode {
+		case PageSizePowerOfTwo:
+			if w != NextPowerOfTwo(w) || h != NextPowerOfTwo(h) {
+				t.Fatalf("mode %d: %dx%d", mode, w, h)
+			}
+		case PageSizeSquare:
+			if w != h {
+				t.Fatalf("mode %d: %dx%d", mode, w, h)
+			}
+		case PageSizePowerOfTwoSquare:
+			if w != h || w != NextPowerOfTwo(w) {
+				t.Fatalf("mode %d: %dx%d", mode, w, h)
+			}
+		case PageSizeFixed:
+			if w != 200 || h != 200 {
+				t.Fatalf("mode %d: %dx%d", mode, w, h)
+			}
+		}
+	}
+}
+
+func TestBitmapFontGeneraterSpaces(t *testing.T) {
+	data, err := ioutil.ReadFile("./fonts/FiraGO_Map.ttf")
+	if err != nil {
+		t.FailNow()
+	}
+
+	for _, workers := range []int{1, 4} {
+		opts := DefaultBitmapFontOptions("Basic_Latin")
+		opts.Workers = workers
+		gen := NewBitmapFontGenerater(NewFontHolder(data), NewCharsetsASCII(), 32, 8, opts)
+		bmfont := gen.Generate()
+		if bmfont == nil {
+			t.FailNow()
+		}
+		found := false
+		for _, c := range bmfont.Chars {
+			if c.Char != " " {
+				continue
+			}
+			found = true
+			if c.XAdvance <= 0 || c.Width != 0 || c.Height != 0 || c.Page != 0 {
+				t.Fatalf("%+v", c)
+			}
+		}
+		if !found {
+			t.Fatal("no space")
+		}
+	}
+}
+
+func TestBitmapFontGeneraterGlyphError(t *testing.T) {
+	data, err := ioutil.ReadFile("./fonts/FiraGO_Map.ttf")
+	if err != nil {
+		t.FailNow()
+	}
+
+	for _, workers := range []int{1, 4} {
+		opts := DefaultBitmapFontOptions("Basic_Latin")
+		opts.FieldType = "bogus"
+		opts.Workers = workers
+		gen := NewBitmapFontGenerater(NewFontHolder(data), NewCharsetsASCII(), 32, 8, opts)
+		if gen.Generate() != nil || gen.Err() == nil {
+			t.FailNow()
+		}
+		if msg := gen.Err().Error(); !strings.Contains(msg, "glyph U+") || !strings.Contains(msg, "Basic_Latin") {
+			t.Fatal(msg)
+		}
+	}
+}
+
+func TestBitmapFontGeneraterKerning(t *testing.T) {
+	data, err := ioutil.ReadFile("./fonts/SignTextNarrow_Bold.ttf")
+	if err != nil {
+		t.FailNow()
+	}
+
+	gen := NewBitmapFontGenerater(NewFontHolder(data), NewCharsetsASCII(), 32, 8, DefaultBitmapFontOptions("Basic_Latin"))
+	bmfont := gen.Generate()
+	if bmfont == nil || len(bmfont.Kerning) == 0 {
+		t.FailNow()
+	}
+
+	// the font geometry keys pairs by glyph index, the font by char id
+	km := gen.font.GetKerning()
+	pairs := km.GetKernings()
+	if len(pairs) == 0 {
+		t.FailNow()
+	}
+	indices := make(map[int]int)
+	for _, c := range bmfont.Chars {
+		indices[c.ID] = c.Index
+	}
+	amounts := make(map[[2]int]float64)
+	for _, k := range pairs {
+		amounts[[2]int{int(k.First), int(k.Second)}] = k.Amount
+	}
+	for _, k := range bmfont.Kerning {
+		first, ok1 := indices[int(k.First)]
+		second, ok2 := indices[int(k.Second)]
+		if !ok1 || !ok2 {
+			t.Fatalf("pair %q %q is not between chars", k.First, k.Second)
+		}
+		if amount, ok := amounts[[2]int{first, second}]; !ok || amount != k.Amount {
+			t.Fatalf("pair %q %q", k.First, k.Second)
+		}
+	}
+}
@@ -20,11 +20,23 @@ const (
 
 type GlyphIdentifierType uint32
 
+// IDENTIFIER_UNSET is the zero value, options leaving it unset identify
+// glyphs by code point.
 const (
-	GLYPH_INDEX       GlyphIdentifierType = 0
-	UNICODE_CODEPOINT GlyphIdentifierType = 1
+	IDENTIFIER_UNSET GlyphIdentifierType = iota
+	GLYPH_INDEX
+	UNICODE_CODEPOINT
 )
 
+// cType returns the identifier type of the C library, which numbers glyph
+// indices 0 and code points 1.
+func (t GlyphIdentifierType) cType() C.uint {
+	if t == GLYPH_INDEX {
+		return 0
+	}
+	return 1
+}
+
 type GlyphIndex uint32
 
 type GlyphBox struct {
@@ -81,7 +93,7 @@ func (h *GlyphGeometry) GetCodePoint() rune {
 }
 
 func (h *GlyphGeometry) GetIdentifier(id GlyphIdentifierType) int {
-	return int(C.fc_glyph_geometry_get_identifier(h.m, C.uint(id)))
+	return int(C.fc_glyph_geometry_get_identifier(h.m, id.cType()))
 }
 
 func (h *GlyphGeometry) GetAdvance() float64 {
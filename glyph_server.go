@@ -0,0 +1,522 @@
+package fontcatalog
+
+import (
+	"container/list"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// glyphRangeSize is the number of code points of a served range, ranges
+// start at a multiple of it like the glyph ranges of Mapbox GL.
+const glyphRangeSize = 256
+
+// maxFontstackFonts is the number of fonts a fontstack may combine.
+const maxFontstackFonts = 8
+
+// maxRanges bounds the number of ranges kept in memory, DefaultGlyphCacheSize
+// their total size.
+const (
+	maxRanges             = 4096
+	DefaultGlyphCacheSize = 256 << 20
+)
+
+var (
+	errFontNotFound = errors.New("font not found")
+	errFontstack    = errors.New("invalid fontstack")
+)
+
+type glyphRange struct {
+	key   string
+	done  chan struct{}
+	files map[string][]byte
+	size  int
+	err   error
+}
+
+// serverFont holds the styles of a font of the description, loaded once by
+// the first request for one of them.
+type serverFont struct {
+	done   chan struct{}
+	styles map[string]*fontStyle
+	err    error
+}
+
+// GlyphServer serves the glyphs of a catalog by code point range. A range is
+// generated the first time it is requested and kept in memory, and on disk
+// when a cache directory is set. Ranges in memory are evicted least
+// recently used first beyond DefaultGlyphCacheSize bytes, see SetCacheSize.
+//
+//	/{Name}_FontCatalog.json        the catalog, see FontCatalogGenerater.Describe
+//	/{font}/{start}-{end}.json      the bitmap font of a range
+//	/{font}/{start}-{end}[.N].png   its pages
+//	/{font}/{start}-{end}.pbf       the range as Mapbox GL glyph protobuf
+//
+// font is the name of a font style of the description, e.g. FiraGO_MapBold.
+// Protobuf ranges also take a comma separated fontstack, every glyph comes
+// from the first font of the stack that has it.
+type GlyphServer struct {
+	gen       *FontCatalogGenerater
+	cacheDir  string
+	cacheSize int
+	catalogMu sync.Mutex
+	catalog   []byte
+	mu        sync.Mutex
+	fonts     map[string]*serverFont
+	ranges    map[string]*list.Element
+	lru       *list.List
+	size      int
+}
+
+func NewGlyphServer(desc *FontCatalogDescription, opts BitmapFontOptions) *GlyphServer {
+	return &GlyphServer{
+		gen:       NewFontCatalogGenerater(desc, &opts),
+		cacheSize: DefaultGlyphCacheSize,
+		fonts:     make(map[string]*serverFont),
+		ranges:    make(map[string]*list.Element),
+		lru:       list.New(),
+	}
+}
+
+// SetCacheDir keeps the generated ranges in dir so they survive a restart.
+func (s *GlyphServer) SetCacheDir(dir string) {
+	s.cacheDir = dir
+}
+
+// SetCacheSize sets the number of bytes of ranges kept in memory.
+func (s *GlyphServer) SetCacheSize(size int) {
+	s.mu.Lock()
+	s.cacheSize = size
+	s.evict()
+	s.mu.Unlock()
+}
+
+func (s *GlyphServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := strings.TrimPrefix(r.URL.Path, "/")
+	if name == s.gen.fontDesc.Name+"_FontCatalog.json" {
+		data, err := s.catalogJson()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeResponse(w, r, "application/json", data)
+		return
+	}
+
+	i := strings.LastIndex(name, "/")
+	if i < 0 {
+		http.NotFound(w, r)
+		return
+	}
+	fontstack, file := name[:i], name[i+1:]
+
+	var contentType string
+	switch path.Ext(file) {
+	case ".json":
+		contentType = "application/json"
+	case ".png":
+		contentType = "image/png"
+	case ".pbf":
+		contentType = "application/x-protobuf"
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	rangeName := strings.TrimSuffix(file, path.Ext(file))
+	if i := strings.Index(rangeName, "."); i >= 0 {
+		rangeName = rangeName[:i]
+	}
+	start, end, err := parseGlyphRange(rangeName)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	pbf := path.Ext(file) == ".pbf"
+	fontstack, err = s.normalizeFontstack(fontstack, pbf)
+	if errors.Is(err, errFontstack) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, errFontNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+
+	files, err := s.glyphRange(fontstack, start, end, pbf)
+	if errors.Is(err, errFontNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	data, ok := files[file]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	writeResponse(w, r, contentType, data)
+}
+
+func writeResponse(w http.ResponseWriter, r *http.Request, contentType string, data []byte) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	if r.Method != http.MethodHead {
+		w.Write(data)
+	}
+}
+
+func parseGlyphRange(name string) (int, int, error) {
+	parts := strings.Split(name, "-")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid glyph range %q", name)
+	}
+	start, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	end, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, err
+	}
+	if start < 0 || start%glyphRangeSize != 0 || end != start+glyphRangeSize-1 || end > 0x10ffff {
+		return 0, 0, fmt.Errorf("invalid glyph range %q", name)
+	}
+	return start, end, nil
+}
+
+// normalizeFontstack trims and dedupes the names of a fontstack, so that
+// spellings of the same stack share their ranges. Only protobuf ranges take
+// more than one font and every font must be in the description.
+func (s *GlyphServer) normalizeFontstack(fontstack string, pbf bool) (string, error) {
+	names := []string{}
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(fontstack, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		if len(names) == maxFontstackFonts {
+			return "", fmt.Errorf("%w: more than %d fonts", errFontstack, maxFontstackFonts)
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return "", fmt.Errorf("%w: no font", errFontstack)
+	}
+	for _, name := range names {
+		if s.fontOf(name) == nil {
+			return "", fmt.Errorf("%s: %w", name, errFontNotFound)
+		}
+	}
+	if len(names) > 1 && !pbf {
+		return "", fmt.Errorf("%s: %w", fontstack, errFontNotFound)
+	}
+	return strings.Join(names, ","), nil
+}
+
+// fontOf returns the font of the description with a style called name.
+func (s *GlyphServer) fontOf(name string) *UnicodeBlockDescription {
+	for i, ufont := range s.gen.fontDesc.Fonts {
+		for _, n := range []*string{&ufont.Name, ufont.Bold, ufont.Italic, ufont.BoldItalic} {
+			if n != nil && *n == name {
+				return &s.gen.fontDesc.Fonts[i]
+			}
+		}
+	}
+	return nil
+}
+
+func (s *GlyphServer) catalogJson() ([]byte, error) {
+	s.catalogMu.Lock()
+	defer s.catalogMu.Unlock()
+	if s.catalog != nil {
+		return s.catalog, nil
+	}
+	catalog, err := s.gen.Describe()
+	if err != nil {
+		return nil, err
+	}
+	data, err := catalog.ToJson()
+	if err != nil {
+		return nil, err
+	}
+	s.catalog = []byte(data)
+	return s.catalog, nil
+}
+
+// fontStyle returns the loaded font style called name, loading all styles
+// of its font on first use. Fonts are read without holding the lock, a font
+// requested while loading waits for it.
+func (s *GlyphServer) fontStyle(name string) (*fontStyle, error) {
+	ufont := s.fontOf(name)
+	if ufont == nil {
+		return nil, fmt.Errorf("%s: %w", name, errFontNotFound)
+	}
+
+	s.mu.Lock()
+	sf, ok := s.fonts[ufont.Name]
+	if !ok {
+		sf = &serverFont{done: make(chan struct{})}
+		s.fonts[ufont.Name] = sf
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		var styles []*fontStyle
+		_, styles, sf.err = s.gen.loadFontStyles(*ufont)
+		sf.styles = make(map[string]*fontStyle)
+		for _, fs := range styles {
+			sf.styles[fs.name] = fs
+		}
+		if sf.err != nil {
+			s.mu.Lock()
+			delete(s.fonts, ufont.Name)
+			s.mu.Unlock()
+		}
+		close(sf.done)
+	}
+	<-sf.done
+	if sf.err != nil {
+		return nil, sf.err
+	}
+	return sf.styles[name], nil
+}
+
+// glyphRange returns the files of a range by name, generating them once even
+// when requested concurrently. A range without glyphs has no bitmap font
+// files, its protobuf has no glyphs.
+func (s *GlyphServer) glyphRange(fontstack string, start, end int, pbf bool) (map[string][]byte, error) {
+	key := fmt.Sprintf("%s/%d-%d", fontstack, start, end)
+	if pbf {
+		key += ".pbf"
+	}
+
+	s.mu.Lock()
+	e, ok := s.ranges[key]
+	if ok {
+		s.lru.MoveToBack(e)
+	} else {
+		e = s.lru.PushBack(&glyphRange{key: key, done: make(chan struct{})})
+		s.ranges[key] = e
+	}
+	gr := e.Value.(*glyphRange)
+	s.mu.Unlock()
+
+	if ok {
+		<-gr.done
+		return gr.files, gr.err
+	}
+
+	if pbf {
+		gr.files, gr.err = s.generatePbf(fontstack, start, end)
+	} else {
+		gr.files, gr.err = s.generateRange(fontstack, start, end)
+	}
+	s.mu.Lock()
+	if gr.err != nil {
+		s.remove(e)
+	} else {
+		for _, data := range gr.files {
+			gr.size += len(data)
+		}
+		s.size += gr.size
+		s.evict()
+	}
+	s.mu.Unlock()
+	close(gr.done)
+	return gr.files, gr.err
+}
+
+// evict drops the least recently used ranges beyond the cache size or the
+// number of ranges, ranges being generated stay. Callers hold s.mu.
+func (s *GlyphServer) evict() {
+	for e := s.lru.Front(); e != nil && (s.size > s.cacheSize || s.lru.Len() > maxRanges); {
+		next := e.Next()
+		select {
+		case <-e.Value.(*glyphRange).done:
+			s.remove(e)
+		default:
+		}
+		e = next
+	}
+}
+
+func (s *GlyphServer) remove(e *list.Element) {
+	gr := s.lru.Remove(e).(*glyphRange)
+	if s.ranges[gr.key] == e {
+		delete(s.ranges, gr.key)
+	}
+	s.size -= gr.size
+}
+
+func (s *GlyphServer) generateRange(fontstack string, start, end int) (map[string][]byte, error) {
+	fs, err := s.fontStyle(fontstack)
+	if err != nil {
+		return nil, err
+	}
+
+	rangeName := fmt.Sprintf("%d-%d", start, end)
+	charset := blockCharset(fs.characterSet, &UnicodeRanges{Category: rangeName, Range: [2]int{start, end}})
+	if charset == "" {
+		return map[string][]byte{}, nil
+	}
+
+	opts := *s.gen.opts
+	opts.Filename = rangeName
+	inputs := BuildInputs{
+		FontHash:  fs.fontHash,
+		Face:      fs.file.Face,
+		Instance:  fs.file.Instance,
+		Variation: fs.file.Variation,
+		Block:     rangeName,
+		Style:     styleName(fs.bold, fs.italic),
+		Size:      s.gen.fontDesc.Size,
+		Distance:  s.gen.fontDesc.Distance,
+		FieldType: opts.FieldType,
+		Output:    fontstack,
+		Options:   opts,
+	}
+	dir := ""
+	if s.cacheDir != "" {
+		dir = filepath.Join(s.cacheDir, inputs.Key())
+		if files, ok := readCachedRange(dir, rangeName); ok {
+			return files, nil
+		}
+	}
+
+	gen, err := s.gen.newGenerater(fs, charset, opts)
+	if err != nil {
+		return nil, err
+	}
+	bmfont := gen.Generate()
+	if err := gen.Err(); err != nil {
+		return nil, err
+	}
+	if bmfont == nil {
+		return map[string][]byte{}, nil
+	}
+	out := NewMemoryOutput()
+	if err := bmfont.Write(out, "", rangeName); err != nil {
+		return nil, err
+	}
+
+	files := make(map[string][]byte)
+	for _, name := range out.Names() {
+		files[name], _ = out.ReadFile(name)
+	}
+
+	if dir != "" {
+		// the descriptor goes last, a range is only read back once it exists
+		names := out.Names()
+		sort.SliceStable(names, func(i, j int) bool { return path.Ext(names[i]) != ".json" && path.Ext(names[j]) == ".json" })
+		disk := NewDirOutput(dir)
+		for _, name := range names {
+			if err := disk.WriteFile(name, files[name]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return files, nil
+}
+
+func (s *GlyphServer) generatePbf(fontstack string, start, end int) (map[string][]byte, error) {
+	name := fmt.Sprintf("%d-%d.pbf", start, end)
+
+	if names := strings.Split(fontstack, ","); len(names) > 1 {
+		stacks := make([]*MapboxFontstack, len(names))
+		for i, n := range names {
+			files, err := s.glyphRange(n, start, end, true)
+			if err != nil {
+				return nil, err
+			}
+			glyphs, err := ReadMapboxGlyphs(files[name])
+			if err != nil {
+				return nil, err
+			}
+			stacks[i] = &glyphs.Stacks[0]
+		}
+		combined := CombineMapboxFontstacks(fontstack, stacks)
+		return map[string][]byte{name: (&MapboxGlyphs{Stacks: []MapboxFontstack{*combined}}).ToPbf()}, nil
+	}
+
+	fs, err := s.fontStyle(fontstack)
+	if err != nil {
+		return nil, err
+	}
+
+	inputs := BuildInputs{
+		FontHash:  fs.fontHash,
+		Face:      fs.file.Face,
+		Instance:  fs.file.Instance,
+		Variation: fs.file.Variation,
+		Block:     name,
+		Style:     styleName(fs.bold, fs.italic),
+		Size:      MapboxGlyphSize,
+		Distance:  MapboxGlyphBuffer,
+		FieldType: MOD_SDF,
+		Output:    fontstack,
+	}
+	file := ""
+	if s.cacheDir != "" {
+		file = filepath.Join(s.cacheDir, inputs.Key(), name)
+		if data, err := ioutil.ReadFile(file); err == nil {
+			return map[string][]byte{name: data}, nil
+		}
+	}
+
+	holder, err := fs.newHolder()
+	if err != nil {
+		return nil, err
+	}
+	stack, err := NewMapboxFontstack(holder, fs.characterSet, fontstack, start, end)
+	if err != nil {
+		return nil, err
+	}
+	data := (&MapboxGlyphs{Stacks: []MapboxFontstack{*stack}}).ToPbf()
+
+	if file != "" {
+		if err := NewDirOutput(filepath.Dir(file)).WriteFile(name, data); err != nil {
+			return nil, err
+		}
+	}
+	return map[string][]byte{name: data}, nil
+}
+
+func readCachedRange(dir, rangeName string) (map[string][]byte, bool) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, rangeName+".json"))
+	if err != nil {
+		return nil, false
+	}
+	var bmfont BitmapFont
+	if err := json.Unmarshal(data, &bmfont); err != nil {
+		return nil, false
+	}
+	files := map[string][]byte{rangeName + ".json": data}
+	for _, page := range bmfont.Pages {
+		name := pageFile(page)
+		png, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, false
+		}
+		files[name] = png
+	}
+	return files, true
+}
@@ -0,0 +1,127 @@
+package fontcatalog
+
+import (
+	"bytes"
+	"image/png"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGlyphServer(t *testing.T) {
+	fcd, err := ReadFontCatalogDescription(strings.NewReader(`{"name":"Test","size":32,"distance":8,"type":"sdf","fontsDir":"./fonts","fonts":[{"name":"FiraGO_Map","bold":"FiraGO_MapBold","blocks":["Basic Latin"]}]}`))
+	if err != nil {
+		t.FailNow()
+	}
+	dir := t.TempDir()
+
+	get := func(s *GlyphServer, method, url string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(method, url, nil))
+		return w
+	}
+
+	s := NewGlyphServer(fcd, DefaultBitmapFontOptions(""))
+	s.SetCacheDir(dir)
+
+	w := get(s, http.MethodGet, "/FiraGO_MapBold/0-255.json")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("status %d", w.Code)
+	}
+	bmfont, err := ReadBitmapFont(w.Body.Bytes())
+	if err != nil || bmfont.Pages[0] != "0-255" || len(bmfont.Chars) == 0 {
+		t.FailNow()
+	}
+
+	w = get(s, http.MethodGet, "/FiraGO_MapBold/0-255.png")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d", w.Code)
+	}
+	if _, err := png.Decode(bytes.NewReader(w.Body.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+
+	w = get(s, http.MethodGet, "/FiraGO_Map/0-255.pbf")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/x-protobuf" {
+		t.Fatalf("status %d", w.Code)
+	}
+	glyphs, err := ReadMapboxGlyphs(w.Body.Bytes())
+	if err != nil || len(glyphs.Stacks) != 1 || glyphs.Stacks[0].Name != "FiraGO_Map" || len(glyphs.Stacks[0].Glyphs) == 0 {
+		t.FailNow()
+	}
+
+	w = get(s, http.MethodGet, "/FiraGO_MapBold,FiraGO_Map/0-255.pbf")
+	combined, err := ReadMapboxGlyphs(w.Body.Bytes())
+	if err != nil || combined.Stacks[0].Name != "FiraGO_MapBold,FiraGO_Map" || len(combined.Stacks[0].Glyphs) != len(glyphs.Stacks[0].Glyphs) {
+		t.FailNow()
+	}
+
+	w = get(s, http.MethodGet, "/Test_FontCatalog.json")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d", w.Code)
+	}
+	catalog, err := ReadFontCatalog(w.Body)
+	if err != nil || len(catalog.Fonts) != 2 || catalog.Fonts[0].Metrics.LineHeight == 0 || len(catalog.SupportedBlocks) != 2 {
+		t.FailNow()
+	}
+
+	for _, url := range []string{"/Missing/0-255.json", "/FiraGO_Map/1-256.json", "/FiraGO_Map/0-255.txt", "/FiraGO_Map/0-255.9.png", "/FiraGO_Map/57344-57599.json"} {
+		if w := get(s, http.MethodGet, url); w.Code != http.StatusNotFound {
+			t.Fatalf("%s: status %d", url, w.Code)
+		}
+	}
+	if w := get(s, http.MethodPost, "/FiraGO_Map/0-255.json"); w.Code != http.StatusMethodNotAllowed {
+		t.FailNow()
+	}
+
+	// spellings of a fontstack share one range, unknown or too many fonts
+	// are not cached
+	ranges := len(s.ranges)
+	w = get(s, http.MethodGet, "/FiraGO_MapBold,%20FiraGO_Map,FiraGO_MapBold,/0-255.pbf")
+	if w.Code != http.StatusOK || len(s.ranges) != ranges {
+		t.Fatalf("status %d, %d ranges", w.Code, len(s.ranges))
+	}
+	if w := get(s, http.MethodGet, "/FiraGO_Map,Missing/0-255.pbf"); w.Code != http.StatusNotFound || len(s.ranges) != ranges {
+		t.Fatalf("status %d", w.Code)
+	}
+	if w := get(s, http.MethodGet, "/a,b,c,d,e,f,g,h,i/0-255.pbf"); w.Code != http.StatusBadRequest || len(s.ranges) != ranges {
+		t.Fatalf("status %d", w.Code)
+	}
+	if w := get(s, http.MethodGet, "/FiraGO_Map,FiraGO_MapBold/0-255.json"); w.Code != http.StatusNotFound {
+		t.Fatalf("status %d", w.Code)
+	}
+
+	// ranges beyond the cache size are evicted, least recently used first
+	s.SetCacheSize(1)
+	if len(s.ranges) != 0 || s.size != 0 || s.lru.Len() != 0 {
+		t.FailNow()
+	}
+	get(s, http.MethodGet, "/FiraGO_Map/0-255.pbf")
+	get(s, http.MethodGet, "/FiraGO_MapBold/0-255.pbf")
+	if _, ok := s.ranges["FiraGO_MapBold/0-255.pbf"]; !ok || len(s.ranges) != 1 {
+		t.FailNow()
+	}
+
+	// a new server reads the range back from the cache directory
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil || len(entries) != 4 {
+		t.FailNow()
+	}
+	for _, entry := range entries {
+		if _, err := os.Stat(filepath.Join(dir, entry.Name(), "0-255.pbf")); err == nil {
+			continue
+		}
+		if err := os.WriteFile(filepath.Join(dir, entry.Name(), "0-255.json"), []byte(`{"pages":[]}`), os.ModePerm); err != nil {
+			t.FailNow()
+		}
+	}
+	s = NewGlyphServer(fcd, DefaultBitmapFontOptions(""))
+	s.SetCacheDir(dir)
+	if w := get(s, http.MethodGet, "/FiraGO_MapBold/0-255.json"); w.Body.String() != `{"pages":[]}` {
+		t.FailNow()
+	}
+}
@@ -0,0 +1,168 @@
+package fontcatalog
+
+import (
+	"math"
+)
+
+// GuillotineBinPacker splits the free rect a glyph is placed in along the
+// shorter leftover axis and merges neighbouring free rects after each split.
+type GuillotineBinPacker struct {
+	binWidth, binHeight int
+	paddingX, paddingY  int
+	allowRotation       bool
+	method              FreeRectChoiceHeuristic
+	usedRectangles      []RectNode
+	freeRectangles      []RectNode
+}
+
+func NewGuillotineBinPacker(width, height int, paddingX, paddingY int, allowRotation bool, method FreeRectChoiceHeuristic) *GuillotineBinPacker {
+	return &GuillotineBinPacker{
+		binWidth:       width,
+		binHeight:      height,
+		paddingX:       paddingX,
+		paddingY:       paddingY,
+		allowRotation:  allowRotation,
+		method:         method,
+		usedRectangles: make([]RectNode, 0),
+		freeRectangles: []RectNode{NewRectNode(-1, width, height)},
+	}
+}
+
+func (gb *GuillotineBinPacker) Pack(inputRects []RectNode) *MaxRectsBinResult {
+	rects := inputRects
+	for len(rects) > 0 {
+		bestFreeRect := -1
+		bestRectIndex := -1
+		var bestNode RectNode
+		bestScore := math.MaxInt32
+
+		for i := 0; i < len(gb.freeRectangles); i++ {
+			free := gb.freeRectangles[i]
+			for j := 0; j < len(rects); j++ {
+				width := rects[j].W + gb.paddingX
+				height := rects[j].H + gb.paddingY
+
+				if width <= free.W && height <= free.H {
+					score := gb.scoreByHeuristic(width, height, free.Rect)
+					if score < bestScore {
+						bestNode = RectNode{Rect: Rect{X: free.X, Y: free.Y, W: width, H: height}, Index: rects[j].Index}
+						bestScore = score
+						bestFreeRect = i
+						bestRectIndex = j
+					}
+				}
+				if gb.allowRotation && height <= free.W && width <= free.H {
+					score := gb.scoreByHeuristic(height, width, free.Rect)
+					if score < bestScore {
+						bestNode = RectNode{Rect: Rect{X: free.X, Y: free.Y, W: height, H: width}, Index: rects[j].Index, Rotated: true}
+						bestScore = score
+						bestFreeRect = i
+						bestRectIndex = j
+					}
+				}
+			}
+		}
+
+		if bestRectIndex == -1 {
+			break
+		}
+
+		free := gb.freeRectangles[bestFreeRect]
+		gb.freeRectangles = append(gb.freeRectangles[:bestFreeRect], gb.freeRectangles[bestFreeRect+1:]...)
+		gb.splitFreeRect(free.Rect, bestNode.Rect)
+		gb.mergeFreeList()
+
+		gb.usedRectangles = append(gb.usedRectangles, bestNode)
+		rects = append(rects[:bestRectIndex], rects[bestRectIndex+1:]...)
+	}
+
+	result := &MaxRectsBinResult{
+		PlacedRects:    gb.usedRectangles,
+		NotPlacedRects: rects,
+		Method:         gb.method,
+	}
+	for i := 0; i < len(gb.usedRectangles); i++ {
+		rect := gb.usedRectangles[i]
+		result.Width = Max(result.Width, rect.Right())
+		result.Height = Max(result.Height, rect.Bottom())
+	}
+	return result
+}
+
+func (gb *GuillotineBinPacker) Occupancy() float32 {
+	usedSurfaceArea := 0
+	for i := 0; i < len(gb.usedRectangles); i++ {
+		usedSurfaceArea += gb.usedRectangles[i].W * gb.usedRectangles[i].H
+	}
+	return float32(usedSurfaceArea) / float32(gb.binWidth*gb.binHeight)
+}
+
+func (gb *GuillotineBinPacker) scoreByHeuristic(width, height int, free Rect) int {
+	leftoverH := Abs(free.W - width)
+	leftoverV := Abs(free.H - height)
+	switch gb.method {
+	case RectBestShortSideFit:
+		return Min(leftoverH, leftoverV)
+	case RectBestLongSideFit:
+		return Max(leftoverH, leftoverV)
+	default:
+		return free.W*free.H - width*height
+	}
+}
+
+func (gb *GuillotineBinPacker) splitFreeRect(free, placed Rect) {
+	w := free.W - placed.W
+	h := free.H - placed.H
+	splitHorizontal := w <= h
+
+	bottom := RectNode{Index: -1, Rect: Rect{X: free.X, Y: free.Y + placed.H, H: free.H - placed.H}}
+	right := RectNode{Index: -1, Rect: Rect{X: free.X + placed.W, Y: free.Y, W: free.W - placed.W}}
+
+	if splitHorizontal {
+		bottom.W = free.W
+		right.H = placed.H
+	} else {
+		bottom.W = placed.W
+		right.H = free.H
+	}
+
+	if bottom.W > 0 && bottom.H > 0 {
+		gb.freeRectangles = append(gb.freeRectangles, bottom)
+	}
+	if right.W > 0 && right.H > 0 {
+		gb.freeRectangles = append(gb.freeRectangles, right)
+	}
+}
+
+func (gb *GuillotineBinPacker) mergeFreeList() {
+	for i := 0; i < len(gb.freeRectangles); i++ {
+		for j := i + 1; j < len(gb.freeRectangles); j++ {
+			a := &gb.freeRectangles[i]
+			b := gb.freeRectangles[j]
+			merged := false
+			if a.W == b.W && a.X == b.X {
+				if a.Y == b.Bottom() {
+					a.Y -= b.H
+					a.H += b.H
+					merged = true
+				} else if a.Bottom() == b.Y {
+					a.H += b.H
+					merged = true
+				}
+			} else if a.H == b.H && a.Y == b.Y {
+				if a.X == b.Right() {
+					a.X -= b.W
+					a.W += b.W
+					merged = true
+				} else if a.Right() == b.X {
+					a.W += b.W
+					merged = true
+				}
+			}
+			if merged {
+				gb.freeRectangles = append(gb.freeRectangles[:j], gb.freeRectangles[j+1:]...)
+				j--
+			}
+		}
+	}
+}
@@ -45,3 +45,11 @@ func Sign(value int) int {
 	}
 	return 1
 }
+
+func NextPowerOfTwo(value int) int {
+	p := 1
+	for p < value {
+		p <<= 1
+	}
+	return p
+}
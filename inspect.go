@@ -0,0 +1,81 @@
+package fontcatalog
+
+import "encoding/json"
+
+type BlockCoverage struct {
+	Name  string `json:"name"`
+	Min   int    `json:"min"`
+	Max   int    `json:"max"`
+	Count int    `json:"count"`
+	Total int    `json:"total"`
+}
+
+type FontInspection struct {
+	UnitsPerEm int                `json:"unitsPerEm"`
+	Bold       bool               `json:"bold"`
+	Italic     bool               `json:"italic"`
+	LineHeight int                `json:"lineHeight"`
+	LineGap    int                `json:"lineGap"`
+	BaseLine   int                `json:"baseLine"`
+	FontHeight int                `json:"fontHeight"`
+	Ascent     int                `json:"ascent"`
+	Descent    int                `json:"descent"`
+	Characters int                `json:"characters"`
+	Blocks     []BlockCoverage    `json:"blocks"`
+	Details    *FontDetails       `json:"details"`
+	Axes       []VariationAxis    `json:"axes,omitempty"`
+	Instances  []NamedInstance    `json:"instances,omitempty"`
+	Variation  map[string]float64 `json:"variation,omitempty"`
+}
+
+func InspectFont(holder *FontHolder) *FontInspection {
+	info := holder.getFontInfo()
+	ret := &FontInspection{
+		UnitsPerEm: info.UnitsPerEm,
+		Bold:       info.Bold,
+		Italic:     info.Italic,
+		LineHeight: info.LineHeight,
+		LineGap:    info.LineGap,
+		BaseLine:   info.BaseLine,
+		FontHeight: info.FontHeight,
+		Ascent:     info.Ascent,
+		Descent:    info.Descent,
+		Details:    holder.Info(),
+		Axes:       holder.VariationAxes(),
+		Instances:  holder.NamedInstances(),
+		Variation:  holder.Variation(),
+	}
+
+	counts := make([]int, len(unicodeBlocks))
+	for _, r := range info.CharacterSet {
+		if r == 0 {
+			continue
+		}
+		ret.Characters++
+		for i := range unicodeBlocks {
+			if int(r) >= unicodeBlocks[i].Range[0] && int(r) <= unicodeBlocks[i].Range[1] {
+				counts[i]++
+				break
+			}
+		}
+	}
+
+	for i := range unicodeBlocks {
+		if counts[i] == 0 {
+			continue
+		}
+		ret.Blocks = append(ret.Blocks, BlockCoverage{
+			Name:  unicodeBlocks[i].Category,
+			Min:   unicodeBlocks[i].Range[0],
+			Max:   unicodeBlocks[i].Range[1],
+			Count: counts[i],
+			Total: unicodeBlocks[i].Range[1] - unicodeBlocks[i].Range[0] + 1,
+		})
+	}
+	return ret
+}
+
+func (ur *FontInspection) ToJson() (string, error) {
+	b, e := json.Marshal(ur)
+	return string(b), e
+}
@@ -0,0 +1,495 @@
+package fontcatalog
+
+import (
+	"fmt"
+	"image"
+	"io/fs"
+	"math"
+	"path"
+	"unicode"
+	"unicode/utf8"
+)
+
+type LayoutOptions struct {
+	// Size is the font size in pixels, zero keeps the size of the catalog.
+	Size float64
+	// MaxWidth wraps lines longer than it, zero never wraps.
+	MaxWidth float64
+	// Balance wraps lines to similar lengths instead of filling them, as
+	// wanted for map labels.
+	Balance bool
+	// Align places lines horizontally, only the AlignmentH flags are used.
+	// Without them lines go to the start of their paragraph.
+	Align Alignment
+	// Direction is the direction of paragraphs, DirectionInvalid takes it
+	// from their first strong character.
+	Direction Direction
+	// LineSpacing multiplies the line height, zero means one.
+	LineSpacing float64
+	Bold        bool
+	Italic      bool
+}
+
+// TextRect is an area in pixels from the top left of the text with y down.
+type TextRect struct {
+	X, Y, W, H float64
+}
+
+// GlyphQuad is a glyph placed by Layout, Atlas is its area in the page
+// image Texture and Rect where it is drawn.
+type GlyphQuad struct {
+	Rune    rune
+	Font    string
+	Page    int
+	Texture string
+	Atlas   image.Rectangle
+	Rect    TextRect
+	Line    int
+}
+
+// TextLine is a line of a TextLayout, its glyphs are Glyphs[Start:End].
+// Baseline is the y of the baseline.
+type TextLine struct {
+	Start, End int
+	Rect       TextRect
+	Baseline   float64
+}
+
+type TextLayout struct {
+	Glyphs []GlyphQuad
+	Lines  []TextLine
+	Width  float64
+	Height float64
+}
+
+// layoutFont is a bitmap font of the catalog with its chars and kerning
+// indexed by code point.
+type layoutFont struct {
+	name    string
+	asset   string
+	font    *BitmapFont
+	chars   map[rune]int
+	kerning map[CharPair]float64
+}
+
+// TextLayouter lays out text with the bitmap fonts of a generated catalog,
+// asset paths of the catalog are read from fsys. Loaded fonts are kept for
+// the next layouts.
+type TextLayouter struct {
+	catalog *FontCatalog
+	fsys    fs.FS
+	fonts   map[string]*layoutFont
+}
+
+func NewTextLayouter(catalog *FontCatalog, fsys fs.FS) *TextLayouter {
+	return &TextLayouter{catalog: catalog, fsys: fsys, fonts: make(map[string]*layoutFont)}
+}
+
+// Layout lays out text with the catalog whose assets are in fsys.
+func Layout(text string, catalog *FontCatalog, fsys fs.FS, opts LayoutOptions) (*TextLayout, error) {
+	return NewTextLayouter(catalog, fsys).Layout(text, opts)
+}
+
+func (l *TextLayouter) loadFont(name, asset string) (*layoutFont, error) {
+	if f, ok := l.fonts[asset]; ok {
+		return f, nil
+	}
+	data, err := fs.ReadFile(l.fsys, asset)
+	if err != nil {
+		return nil, err
+	}
+	bmfont, err := ReadBitmapFont(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", asset, err)
+	}
+	if !bmfont.Info.Unicode {
+		return nil, fmt.Errorf("%s: chars are not identified by code point", asset)
+	}
+	f := &layoutFont{name: name, asset: asset, font: bmfont, chars: make(map[rune]int), kerning: make(map[CharPair]float64)}
+	for i, c := range bmfont.Chars {
+		f.chars[rune(c.ID)] = i
+	}
+	for _, k := range bmfont.Kerning {
+		f.kerning[CharPair{k.First, k.Second}] = k.Amount
+	}
+	l.fonts[asset] = f
+	return f, nil
+}
+
+// glyph returns the font and char drawing r, nil when neither the catalog
+// nor its replacement font have one.
+func (l *TextLayouter) glyph(r rune, bold, italic bool) (*layoutFont, *Charset, error) {
+	font, style := l.catalog.Resolve(r, bold, italic)
+	for font != nil {
+		if font.Name == ReplacementFontName {
+			r = unicode.ReplacementChar
+		}
+		if asset := l.asset(r, style); asset != "" {
+			f, err := l.loadFont(font.Name, asset)
+			if err != nil {
+				return nil, nil, err
+			}
+			if i, ok := f.chars[r]; ok {
+				return f, &f.font.Chars[i], nil
+			}
+		}
+		if font.Name == ReplacementFontName {
+			break
+		}
+		font, style = l.catalog.replacement(bold, italic)
+	}
+	return nil, nil, nil
+}
+
+// asset returns the descriptor of the style holding r.
+func (l *TextLayouter) asset(r rune, style string) string {
+	for _, block := range l.catalog.SupportedBlocks {
+		if int(r) >= block.Min && int(r) <= block.Max {
+			return block.Assets[style]
+		}
+	}
+	return ""
+}
+
+// softHyphen marks where a word may be hyphenated, it is only drawn as a
+// hyphen at the end of a line.
+const softHyphen = 0x00ad
+
+type layoutGlyph struct {
+	r     rune
+	font  *layoutFont
+	char  *Charset
+	scale float64
+	// kern is the kerning with the previous glyph
+	kern float64
+	// offset is the byte offset of r in the text
+	offset int
+}
+
+func (l *TextLayouter) layoutGlyph(r rune, opts LayoutOptions) (*layoutGlyph, error) {
+	f, c, err := l.glyph(r, opts.Bold, opts.Italic)
+	if err != nil || c == nil {
+		return nil, err
+	}
+	g := &layoutGlyph{r: r, font: f, char: c, scale: 1}
+	if opts.Size > 0 && f.font.Info.Size > 0 {
+		g.scale = opts.Size / float64(f.font.Info.Size)
+	}
+	return g, nil
+}
+
+func (g *layoutGlyph) advance() float64 {
+	return float64(g.char.XAdvance) * g.scale
+}
+
+func (g *layoutGlyph) space() bool {
+	return unicode.IsSpace(g.r)
+}
+
+// lineWidth is the width of glyphs without their trailing spaces.
+func lineWidth(glyphs []layoutGlyph) float64 {
+	end := len(glyphs)
+	for end > 0 && glyphs[end-1].space() {
+		end--
+	}
+	width := 0.0
+	for i := 0; i < end; i++ {
+		if i > 0 {
+			width += glyphs[i].kern
+		}
+		width += glyphs[i].advance()
+	}
+	return width
+}
+
+// Layout places the glyphs of text. Lines break at mandatory breaks and, when
+// MaxWidth is set, at the last line break opportunity of UAX #14 that keeps
+// them within MaxWidth or inside words too long for a line of their own.
+// Lines are then reordered following UAX #9 so that glyphs are in visual
+// order. Glyphs are scaled from the size of their bitmap font, kerning
+// applies between glyphs of the same bitmap font and lines are as high as
+// their highest font.
+func (l *TextLayouter) Layout(text string, opts LayoutOptions) (*TextLayout, error) {
+	spacing := opts.LineSpacing
+	if spacing == 0 {
+		spacing = 1
+	}
+
+	glyphs := []layoutGlyph{}
+	offsets := []int{}
+	for offset, r := range text {
+		switch lineBreakClass(r) {
+		case lbBK, lbCR, lbLF, lbNL:
+			continue
+		}
+		if r == softHyphen {
+			continue
+		}
+		g, err := l.layoutGlyph(r, opts)
+		if err != nil {
+			return nil, err
+		}
+		if g == nil {
+			continue
+		}
+		if n := len(glyphs); n > 0 && glyphs[n-1].font == g.font {
+			g.kern = g.font.kerning[CharPair{rune(glyphs[n-1].char.ID), rune(g.char.ID)}] * g.scale
+		}
+		g.offset = offset
+		glyphs = append(glyphs, *g)
+		offsets = append(offsets, offset)
+	}
+
+	var hyphen *layoutGlyph
+	if opts.MaxWidth > 0 {
+		g, err := l.layoutGlyph('-', opts)
+		if err != nil {
+			return nil, err
+		}
+		if g != nil && g.font.name != ReplacementFontName {
+			hyphen = g
+		}
+	}
+
+	// split the glyphs at mandatory breaks and wrap the paragraphs at the
+	// other opportunities
+	lines := [][]layoutGlyph{}
+	start, next := 0, 0
+	breaks := []glyphBreak{}
+	for _, brk := range LineBreaks(text) {
+		for next < len(offsets) && offsets[next] < brk.Offset {
+			next++
+		}
+		if !brk.Mandatory {
+			if next > start && next < len(glyphs) {
+				breaks = append(breaks, glyphBreak{next - start, brk.Hyphen})
+			}
+			continue
+		}
+		p := glyphs[start:next]
+		if opts.Balance {
+			lines = append(lines, balanceLines(p, breaks, opts.MaxWidth, hyphen)...)
+		} else {
+			wrapped, _ := wrapLines(p, breaks, opts.MaxWidth, hyphen)
+			lines = append(lines, wrapped...)
+		}
+		start, breaks = next, breaks[:0]
+	}
+
+	bidi := NewBidi(text, opts.Direction)
+	directions := make([]Direction, len(lines))
+	for i, line := range lines {
+		directions[i] = opts.Direction
+		if len(line) > 0 {
+			directions[i] = bidi.Direction(line[0].offset)
+			reordered, err := l.reorder(bidi, line, text, opts)
+			if err != nil {
+				return nil, err
+			}
+			lines[i] = reordered
+		}
+	}
+
+	ret := &TextLayout{}
+	widths := make([]float64, len(lines))
+	for i, line := range lines {
+		widths[i] = lineWidth(line)
+		ret.Width = math.Max(ret.Width, widths[i])
+	}
+	boxWidth := ret.Width
+	if opts.MaxWidth > 0 {
+		boxWidth = opts.MaxWidth
+	}
+
+	// empty lines are as high as the first glyph of the text
+	emptyBase, emptyHeight := 0.0, 0.0
+	for _, line := range lines {
+		if len(line) > 0 {
+			emptyBase, emptyHeight = lineMetrics(line)
+			break
+		}
+	}
+
+	y := 0.0
+	for i, line := range lines {
+		base, height := emptyBase, emptyHeight
+		if len(line) > 0 {
+			base, height = lineMetrics(line)
+		}
+
+		x := 0.0
+		switch {
+		case opts.Align&AlignmentHCenter != 0:
+			x = (boxWidth - widths[i]) / 2
+		case opts.Align&AlignmentHRight != 0:
+			x = boxWidth - widths[i]
+		case opts.Align&AlignmentHLeft == 0 && directions[i] == DirectionRTL:
+			x = boxWidth - widths[i]
+		}
+
+		tl := TextLine{Start: len(ret.Glyphs), Rect: TextRect{X: x, Y: y, W: widths[i], H: height}, Baseline: y + base}
+		for j, g := range line {
+			if j > 0 {
+				x += g.kern
+			}
+			c := g.char
+			if c.Width > 0 && c.Height > 0 {
+				top := tl.Baseline - float64(g.font.font.Common.Base)*g.scale
+				ret.Glyphs = append(ret.Glyphs, GlyphQuad{
+					Rune:    g.r,
+					Font:    g.font.name,
+					Page:    c.Page,
+					Texture: path.Join(path.Dir(g.font.asset), pageFile(g.font.font.Pages[c.Page])),
+					Atlas:   c.Bounds(),
+					Rect: TextRect{
+						X: x + float64(c.XOffset)*g.scale,
+						Y: top + float64(c.YOffset)*g.scale,
+						W: float64(c.Width) * g.scale,
+						H: float64(c.Height) * g.scale,
+					},
+					Line: i,
+				})
+			}
+			x += g.advance()
+		}
+		tl.End = len(ret.Glyphs)
+		ret.Lines = append(ret.Lines, tl)
+
+		ret.Height = y + height
+		y += height * spacing
+	}
+	return ret, nil
+}
+
+// reorder puts the glyphs of a line in visual order following the runs of
+// bidi. Glyphs of right-to-left runs draw the mirror of their code point when
+// the catalog has it, and kerning applies between the glyphs now adjacent.
+// Trailing spaces are dropped, they are not drawn.
+func (l *TextLayouter) reorder(bidi *Bidi, line []layoutGlyph, text string, opts LayoutOptions) ([]layoutGlyph, error) {
+	end := len(line)
+	for end > 0 && line[end-1].space() {
+		end--
+	}
+	if end == 0 {
+		return line[:0], nil
+	}
+	last := line[end-1].offset
+	_, size := utf8.DecodeRuneInString(text[last:])
+
+	ret := make([]layoutGlyph, 0, end)
+	for _, run := range bidi.Runs(line[0].offset, last+size) {
+		n := len(ret)
+		for _, g := range line[:end] {
+			if g.offset < run.Start || g.offset >= run.End {
+				continue
+			}
+			if run.Level%2 == 1 {
+				if m, ok := bidiMirror(g.r); ok {
+					mg, err := l.layoutGlyph(m, opts)
+					if err != nil {
+						return nil, err
+					}
+					if mg != nil && mg.font.name != ReplacementFontName {
+						mg.offset = g.offset
+						g = *mg
+					}
+				}
+			}
+			ret = append(ret, g)
+		}
+		if run.Level%2 == 1 {
+			for a, z := n, len(ret)-1; a < z; a, z = a+1, z-1 {
+				ret[a], ret[z] = ret[z], ret[a]
+			}
+		}
+	}
+	for i := range ret {
+		ret[i].kern = 0
+		if i > 0 && ret[i-1].font == ret[i].font {
+			ret[i].kern = ret[i].font.kerning[CharPair{rune(ret[i-1].char.ID), rune(ret[i].char.ID)}] * ret[i].scale
+		}
+	}
+	return ret, nil
+}
+
+// lineMetrics returns the base and the height of a line of glyphs.
+func lineMetrics(glyphs []layoutGlyph) (float64, float64) {
+	base, height := 0.0, 0.0
+	for _, g := range glyphs {
+		base = math.Max(base, float64(g.font.font.Common.Base)*g.scale)
+		height = math.Max(height, float64(g.font.font.Common.LineHeight)*g.scale)
+	}
+	return base, height
+}
+
+// glyphBreak is a line break opportunity before the glyph At of a paragraph.
+type glyphBreak struct {
+	At     int
+	Hyphen bool
+}
+
+// wrapLines splits a paragraph into lines no wider than maxWidth, breaking
+// at the last opportunity that fits or, in words too long for a line, before
+// the glyph that overflows. Lines ending at a soft hyphen get hyphen. It also
+// returns the number of breaks inside words.
+func wrapLines(glyphs []layoutGlyph, breaks []glyphBreak, maxWidth float64, hyphen *layoutGlyph) ([][]layoutGlyph, int) {
+	if maxWidth <= 0 {
+		return [][]layoutGlyph{glyphs}, 0
+	}
+	lines := [][]layoutGlyph{}
+	forced := 0
+	start, next, last := 0, 0, -1
+	for i := range glyphs {
+		for next < len(breaks) && breaks[next].At <= i {
+			if breaks[next].At > start {
+				last = next
+			}
+			next++
+		}
+		if i == start || glyphs[i].space() || lineWidth(glyphs[start:i+1]) <= maxWidth {
+			continue
+		}
+		if last < 0 {
+			// keep combining marks with their base
+			end := i
+			for end > start+1 && unicode.In(glyphs[end].r, unicode.Mn, unicode.Me, unicode.Mc) {
+				end--
+			}
+			lines = append(lines, glyphs[start:end])
+			start = end
+			forced++
+			continue
+		}
+		brk := breaks[last]
+		line := glyphs[start:brk.At]
+		if brk.Hyphen && hyphen != nil {
+			h := *hyphen
+			h.offset = line[len(line)-1].offset
+			line = append(append([]layoutGlyph{}, line...), h)
+		}
+		lines = append(lines, line)
+		start, last = brk.At, -1
+	}
+	return append(lines, glyphs[start:]), forced
+}
+
+// balanceLines wraps a paragraph into as many lines as wrapLines does at
+// maxWidth, with the narrowest width that needs no more lines and no more
+// breaks inside words, so that lines have similar lengths.
+func balanceLines(glyphs []layoutGlyph, breaks []glyphBreak, maxWidth float64, hyphen *layoutGlyph) [][]layoutGlyph {
+	lines, forced := wrapLines(glyphs, breaks, maxWidth, hyphen)
+	if len(lines) < 2 {
+		return lines
+	}
+	lo, hi := 0.0, maxWidth
+	for hi-lo > 0.5 {
+		mid := (lo + hi) / 2
+		if l, f := wrapLines(glyphs, breaks, mid, hyphen); len(l) <= len(lines) && f <= forced {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+	lines, _ = wrapLines(glyphs, breaks, hi, hyphen)
+	return lines
+}
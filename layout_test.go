@@ -0,0 +1,207 @@
+package fontcatalog
+
+import (
+	"encoding/json"
+	"image"
+	"image/color"
+	"math"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestLayout(t *testing.T) {
+	dir := t.TempDir()
+	fcd, err := ReadFontCatalogDescription(strings.NewReader(`{"name":"Test","size":32,"distance":8,"type":"sdf","fontsDir":"./fonts",
+		"fonts":[{"name":"SignTextNarrow_Bold","blocks":["Basic Latin"]}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := DefaultBitmapFontOptions("")
+	if err := NewFontCatalogGenerater(fcd, &opts).Generate(dir); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(dir + "/Test_FontCatalog.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var catalog FontCatalog
+	if err := json.Unmarshal(data, &catalog); err != nil {
+		t.Fatal(err)
+	}
+
+	layouter := NewTextLayouter(&catalog, os.DirFS(dir))
+	font, err := layouter.loadFont("SignTextNarrow_Bold", "Test_Assets/SignTextNarrow_Bold/Basic_Latin.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(font.font.Kerning) == 0 {
+		t.Fatal("no kerning")
+	}
+	if _, ok := font.chars[' ']; !ok {
+		t.Fatal("no space")
+	}
+	var k Kerning
+	for _, k = range font.font.Kerning {
+		if k.First != ' ' && k.Second != ' ' {
+			break
+		}
+	}
+	pair := string([]rune{k.First, k.Second})
+
+	l, err := layouter.Layout(pair, LayoutOptions{Size: 64})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(l.Glyphs) != 2 || len(l.Lines) != 1 {
+		t.Fatalf("%+v", l)
+	}
+	first, second := font.font.Chars[font.chars[k.First]], font.font.Chars[font.chars[k.Second]]
+	want := 2 * (float64(first.XAdvance) + k.Amount + float64(second.XOffset-first.XOffset))
+	if got := l.Glyphs[1].Rect.X - l.Glyphs[0].Rect.X; math.Abs(got-want) > 1e-9 {
+		t.Fatalf("kerned advance %v, want %v", got, want)
+	}
+	if l.Glyphs[0].Rect.W != 2*float64(first.Width) || l.Glyphs[0].Atlas != first.Bounds() || l.Glyphs[0].Texture != "Test_Assets/SignTextNarrow_Bold/Basic_Latin.png" {
+		t.Fatalf("%+v", l.Glyphs[0])
+	}
+	if l.Lines[0].Baseline != 2*float64(font.font.Common.Base) || l.Height != 2*float64(font.font.Common.LineHeight) {
+		t.Fatalf("%+v", l.Lines[0])
+	}
+
+	// a line of "HELLO" fits, two of them do not
+	word, err := layouter.Layout("HELLO", LayoutOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err = layouter.Layout("HELLO HELLO\nHI", LayoutOptions{MaxWidth: word.Width + 1, Align: AlignmentHRight, LineSpacing: 1.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(l.Lines) != 3 || l.Lines[0].End != 5 || l.Lines[1].End != 10 || l.Lines[2].End != 12 {
+		t.Fatalf("%+v", l.Lines)
+	}
+	lineHeight := float64(font.font.Common.LineHeight)
+	if l.Lines[1].Rect.Y != 1.5*lineHeight || l.Height != 3*lineHeight+lineHeight {
+		t.Fatalf("%+v", l.Lines)
+	}
+	if math.Abs(l.Lines[2].Rect.X+l.Lines[2].Rect.W-(word.Width+1)) > 1e-9 || l.Glyphs[10].Line != 2 {
+		t.Fatalf("%+v", l.Lines[2])
+	}
+
+	// a soft hyphen is only drawn where the line breaks
+	l, err = layouter.Layout("HEL\u00adLO", LayoutOptions{MaxWidth: word.Width * 0.8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(l.Lines) != 2 || l.Lines[0].End != 4 || l.Glyphs[3].Rune != '-' || l.Lines[1].End != 6 {
+		t.Fatalf("%+v", l.Lines)
+	}
+	l, err = layouter.Layout("HEL\u00adLO", LayoutOptions{MaxWidth: word.Width + 1})
+	if err != nil || len(l.Glyphs) != 5 || l.Width != word.Width {
+		t.Fatal(err)
+	}
+
+	// balanced lines keep the line count with similar lengths
+	text := "HI HI HI HI HI HI HI"
+	l, err = layouter.Layout(text, LayoutOptions{MaxWidth: word.Width * 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	balanced, err := layouter.Layout(text, LayoutOptions{MaxWidth: word.Width * 2, Balance: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(l.Lines) != 2 || len(balanced.Lines) != 2 || balanced.Width >= l.Width {
+		t.Fatalf("%+v %+v", l.Lines, balanced.Lines)
+	}
+	if d := balanced.Lines[0].Rect.W - balanced.Lines[1].Rect.W; d < 0 || d > balanced.Lines[0].Rect.W/3 {
+		t.Fatalf("%+v", balanced.Lines)
+	}
+
+	// layouts draw with the pages of the catalog
+	l, err = layouter.Layout("I", LayoutOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	if err := layouter.Draw(dst, l, RenderOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	r := l.Glyphs[0].Rect
+	if got := dst.RGBAAt(int(r.X+r.W/2), int(r.Y+r.H/2)); got != (color.RGBA{A: 255}) {
+		t.Fatalf("%v", got)
+	}
+
+	// code points no font has use the replacement character
+	l, err = layouter.Layout("A一", LayoutOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(l.Glyphs) != 2 || l.Glyphs[1].Rune != '一' || l.Glyphs[1].Font != ReplacementFontName {
+		t.Fatalf("%+v", l.Glyphs)
+	}
+}
+
+func TestLayoutBidi(t *testing.T) {
+	dir := t.TempDir()
+	fcd, err := ReadFontCatalogDescription(strings.NewReader(`{"name":"Test","size":32,"distance":8,"type":"sdf","fontsDir":"./fonts",
+		"fonts":[{"name":"FiraGO_Map","blocks":["Basic Latin","Hebrew"]}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := DefaultBitmapFontOptions("")
+	if err := NewFontCatalogGenerater(fcd, &opts).Generate(dir); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(dir + "/Test_FontCatalog.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var catalog FontCatalog
+	if err := json.Unmarshal(data, &catalog); err != nil {
+		t.Fatal(err)
+	}
+	layouter := NewTextLayouter(&catalog, os.DirFS(dir))
+
+	runes := func(l *TextLayout) string {
+		rs := []rune{}
+		for _, g := range l.Glyphs {
+			rs = append(rs, g.Rune)
+		}
+		return string(rs)
+	}
+
+	l, err := layouter.Layout("שלום (ab)", LayoutOptions{MaxWidth: 500})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := runes(l); got != "(ab)םולש" {
+		t.Fatalf("%q", got)
+	}
+	for i := 1; i < len(l.Glyphs); i++ {
+		if l.Glyphs[i].Rect.X <= l.Glyphs[i-1].Rect.X {
+			t.Fatalf("%+v", l.Glyphs)
+		}
+	}
+	// right-to-left paragraphs start on the right
+	if math.Abs(l.Lines[0].Rect.X+l.Lines[0].Rect.W-500) > 1e-9 {
+		t.Fatalf("%+v", l.Lines[0])
+	}
+
+	// brackets of right-to-left runs are mirrored
+	l, err = layouter.Layout("ab (שלום)", LayoutOptions{Direction: DirectionRTL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := runes(l); got != "(םולש)ab" {
+		t.Fatalf("%q", got)
+	}
+
+	l, err = layouter.Layout("ab (שלום)", LayoutOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := runes(l); got != "ab(םולש)" || l.Lines[0].Rect.X != 0 {
+		t.Fatalf("%q", got)
+	}
+}
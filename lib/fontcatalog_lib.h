@@ -46,11 +46,58 @@ typedef struct _fc_font_info_t {
   int unitsPerEm;
   int baseLine;
   int lineHeight;
+  int lineGap;
   int flags;
   int *characterSet;
   int charSize;
 } fc_font_info_t;
 
+typedef struct _fc_font_details_t {
+  int numGlyphs;
+  int unitsPerEm;
+  int ascender, descender, lineGap;
+  int os2Version;
+  int avgCharWidth;
+  int weightClass, widthClass;
+  int fsType, fsSelection;
+  int typoAscender, typoDescender, typoLineGap;
+  int winAscent, winDescent;
+  int xHeight, capHeight;
+  int strikeoutSize, strikeoutPosition;
+  int underlinePosition, underlineThickness;
+  double italicAngle;
+  _Bool fixedPitch;
+  unsigned char panose[10];
+  char vendor[5];
+  uint32_t unicodeRange[4];
+  uint32_t codePageRange[2];
+} fc_font_details_t;
+
+typedef struct _fc_layout_tag_t {
+  uint32_t script;
+  uint32_t language;
+} fc_layout_tag_t;
+
+typedef struct _fc_var_axis_t {
+  uint32_t tag;
+  double minimum, def, maximum;
+  unsigned int nameId;
+  _Bool hidden;
+} fc_var_axis_t;
+
+typedef struct _fc_sfnt_name_t {
+  unsigned short platformId, encodingId, languageId, nameId;
+  const unsigned char *string;
+  unsigned int length;
+} fc_sfnt_name_t;
+
+typedef struct _fc_shaped_glyph_t {
+  fc_glyph_index_t index;
+  uint32_t cluster;
+  double xAdvance, yAdvance;
+  double xOffset, yOffset;
+} fc_shaped_glyph_t;
+
 typedef struct _fc_font_holder_t fc_font_holder_t;
 typedef struct _fc_font_geometry_t fc_font_geometry_t;
 typedef struct _fc_font_geometry_list_t fc_font_geometry_list_t;
@@ -65,8 +112,41 @@ typedef struct _fc_kerning_map_t fc_kerning_map_t;
 
 FC_LIB_EXPORT fc_font_holder_t *
 fc_font_holder_load_font_memory(const unsigned char *data, long size);
+FC_LIB_EXPORT fc_font_holder_t *
+fc_font_holder_load_font_memory_face(const unsigned char *data, long size,
+                                     int face);
+FC_LIB_EXPORT fc_font_holder_t *fc_font_holder_load_font_file(const char *filename,
+                                                              int face);
+FC_LIB_EXPORT int fc_font_holder_num_faces(fc_font_holder_t *handle);
 FC_LIB_EXPORT void fc_font_holder_free(fc_font_holder_t *handle);
 FC_LIB_EXPORT struct _fc_font_info_t fc_font_holder_get_font_info(fc_font_holder_t *handle);
+FC_LIB_EXPORT fc_shaped_glyph_t *
+fc_font_holder_shape(fc_font_holder_t *handle, double fontScale,
+                     const char *text, int length, const char *script,
+                     const char *language, int direction, size_t *si);
+FC_LIB_EXPORT _Bool fc_font_holder_glyph_closure(fc_font_holder_t *handle,
+                                                 fc_charset_t *codepoints,
+                                                 fc_charset_t *glyphset);
+FC_LIB_EXPORT struct _fc_font_details_t
+fc_font_holder_get_font_details(fc_font_holder_t *handle);
+FC_LIB_EXPORT fc_layout_tag_t *
+fc_font_holder_get_layout_tags(fc_font_holder_t *handle, size_t *si);
+FC_LIB_EXPORT fc_sfnt_name_t *fc_font_holder_get_names(fc_font_holder_t *handle,
+                                                       size_t *si);
+FC_LIB_EXPORT fc_var_axis_t *fc_font_holder_get_var_axes(fc_font_holder_t *handle,
+                                                         size_t *si);
+FC_LIB_EXPORT int fc_font_holder_num_named_instances(fc_font_holder_t *handle);
+FC_LIB_EXPORT _Bool fc_font_holder_get_named_instance(fc_font_holder_t *handle,
+                                                      int index,
+                                                      unsigned int *nameId,
+                                                      unsigned int *psNameId,
+                                                      double *coords);
+FC_LIB_EXPORT _Bool fc_font_holder_set_named_instance(fc_font_holder_t *handle,
+                                                      int index);
+FC_LIB_EXPORT _Bool fc_font_holder_get_var_coords(fc_font_holder_t *handle,
+                                                  double *coords, int n);
+FC_LIB_EXPORT _Bool fc_font_holder_set_var_coords(fc_font_holder_t *handle,
+                                                  const double *coords, int n);
 
 FC_LIB_EXPORT fc_glyph_geometry_t *fc_new_glyph_geometry_from_glyph_index(
     fc_font_holder_t *handle, double geometryScale, fc_glyph_index_t index);
@@ -0,0 +1,448 @@
+package fontcatalog
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// lbClass is a line breaking class of UAX #14.
+type lbClass uint8
+
+const (
+	lbAL lbClass = iota
+	lbBK
+	lbCR
+	lbLF
+	lbNL
+	lbSP
+	lbZW
+	lbZWJ
+	lbWJ
+	lbGL
+	lbCM
+	lbBA
+	lbBB
+	lbB2
+	lbHY
+	lbCB
+	lbCL
+	lbCP
+	lbOP
+	lbQU
+	lbEX
+	lbIS
+	lbSY
+	lbNS
+	lbIN
+	lbNU
+	lbPR
+	lbPO
+	lbHL
+	lbID
+	lbEB
+	lbEM
+	lbH2
+	lbH3
+	lbJL
+	lbJV
+	lbJT
+	lbRI
+	lbSA
+)
+
+// lbClasses lists the code points whose class does not follow from their
+// general category or script.
+var lbClasses = map[lbClass]*unicode.RangeTable{
+	lbBK:  {R16: []unicode.Range16{{0x000b, 0x000c, 1}, {0x2028, 0x2029, 1}}},
+	lbCR:  {R16: []unicode.Range16{{0x000d, 0x000d, 1}}},
+	lbLF:  {R16: []unicode.Range16{{0x000a, 0x000a, 1}}},
+	lbNL:  {R16: []unicode.Range16{{0x0085, 0x0085, 1}}},
+	lbSP:  {R16: []unicode.Range16{{0x0020, 0x0020, 1}}},
+	lbZW:  {R16: []unicode.Range16{{0x200b, 0x200b, 1}}},
+	lbZWJ: {R16: []unicode.Range16{{0x200d, 0x200d, 1}}},
+	lbWJ:  {R16: []unicode.Range16{{0x2060, 0x2060, 1}, {0xfeff, 0xfeff, 1}}},
+	lbGL: {R16: []unicode.Range16{{0x00a0, 0x00a0, 1}, {0x034f, 0x034f, 1}, {0x0f08, 0x0f08, 1}, {0x0f0c, 0x0f0c, 1}, {0x0f12, 0x0f12, 1},
+		{0x180e, 0x180e, 1}, {0x2007, 0x2007, 1}, {0x2011, 0x2011, 1}, {0x202f, 0x202f, 1}}},
+	lbBA: {R16: []unicode.Range16{{0x0009, 0x0009, 1}, {0x007c, 0x007c, 1}, {0x00ad, 0x00ad, 1}, {0x058a, 0x058a, 1}, {0x05be, 0x05be, 1},
+		{0x0964, 0x0965, 1}, {0x0e5a, 0x0e5b, 1}, {0x1680, 0x1680, 1}, {0x1735, 0x1736, 1}, {0x17d4, 0x17d5, 1}, {0x17d8, 0x17d8, 1},
+		{0x17da, 0x17da, 1}, {0x1804, 0x1805, 1}, {0x2000, 0x2006, 1}, {0x2008, 0x200a, 1}, {0x2010, 0x2010, 1}, {0x2012, 0x2013, 1},
+		{0x2027, 0x2027, 1}, {0x205f, 0x205f, 1}, {0x2cfa, 0x2cfd, 1}, {0x2cff, 0x2cff, 1}, {0x2e0e, 0x2e15, 1}, {0x2e17, 0x2e17, 1},
+		{0x2e19, 0x2e19, 1}, {0x2e2a, 0x2e2d, 1}, {0x2e30, 0x2e31, 1}, {0x2e33, 0x2e34, 1}, {0x2e3c, 0x2e3e, 1}, {0x2e40, 0x2e41, 1},
+		{0x2e43, 0x2e4a, 1}, {0x3000, 0x3000, 1}}},
+	lbBB: {R16: []unicode.Range16{{0x00b4, 0x00b4, 1}, {0x02c8, 0x02c8, 1}, {0x02cc, 0x02cc, 1}, {0x02df, 0x02df, 1}, {0x0f01, 0x0f04, 1},
+		{0x0f06, 0x0f07, 1}, {0x0f09, 0x0f0a, 1}, {0x0fd0, 0x0fd1, 1}, {0x0fd3, 0x0fd3, 1}, {0x1806, 0x1806, 1}, {0x1ffd, 0x1ffd, 1},
+		{0xa874, 0xa875, 1}}},
+	lbB2: {R16: []unicode.Range16{{0x2014, 0x2014, 1}, {0x2e3a, 0x2e3b, 1}}},
+	lbHY: {R16: []unicode.Range16{{0x002d, 0x002d, 1}}},
+	lbCB: {R16: []unicode.Range16{{0xfffc, 0xfffc, 1}}},
+	lbCL: {R16: []unicode.Range16{{0x3001, 0x3002, 1}, {0xfe11, 0xfe12, 1}, {0xfe50, 0xfe50, 1}, {0xfe52, 0xfe52, 1}, {0xff0c, 0xff0c, 1},
+		{0xff0e, 0xff0e, 1}, {0xff61, 0xff61, 1}, {0xff64, 0xff64, 1}}},
+	lbCP: {R16: []unicode.Range16{{0x0029, 0x0029, 1}, {0x005d, 0x005d, 1}}},
+	lbOP: {R16: []unicode.Range16{{0x00a1, 0x00a1, 1}, {0x00bf, 0x00bf, 1}, {0x2e18, 0x2e18, 1}}},
+	lbQU: {R16: []unicode.Range16{{0x0022, 0x0022, 1}, {0x0027, 0x0027, 1}, {0x275b, 0x2760, 1}, {0x2e00, 0x2e0d, 1}, {0x2e1c, 0x2e1d, 1},
+		{0x2e20, 0x2e21, 1}}},
+	lbEX: {R16: []unicode.Range16{{0x0021, 0x0021, 1}, {0x003f, 0x003f, 1}, {0x05c6, 0x05c6, 1}, {0x061b, 0x061b, 1}, {0x061e, 0x061f, 1},
+		{0x06d4, 0x06d4, 1}, {0x07f9, 0x07f9, 1}, {0x0f0d, 0x0f11, 1}, {0x0f14, 0x0f14, 1}, {0x1802, 0x1803, 1}, {0x1808, 0x1809, 1},
+		{0x1944, 0x1945, 1}, {0x2762, 0x2763, 1}, {0x2cf9, 0x2cf9, 1}, {0x2cfe, 0x2cfe, 1}, {0x2e2e, 0x2e2e, 1}, {0xa60e, 0xa60e, 1},
+		{0xa876, 0xa877, 1}, {0xfe15, 0xfe16, 1}, {0xfe56, 0xfe57, 1}, {0xff01, 0xff01, 1}, {0xff1f, 0xff1f, 1}}},
+	lbIS: {R16: []unicode.Range16{{0x002c, 0x002c, 1}, {0x002e, 0x002e, 1}, {0x003a, 0x003b, 1}, {0x037e, 0x037e, 1}, {0x0589, 0x0589, 1},
+		{0x060c, 0x060d, 1}, {0x07f8, 0x07f8, 1}, {0x2044, 0x2044, 1}, {0xfe10, 0xfe10, 1}, {0xfe13, 0xfe14, 1}}},
+	lbSY: {R16: []unicode.Range16{{0x002f, 0x002f, 1}}},
+	// NS, including the small kana of CJ which are resolved to NS
+	lbNS: {R16: []unicode.Range16{{0x17d6, 0x17d6, 1}, {0x203c, 0x203d, 1}, {0x2047, 0x2049, 1}, {0x3005, 0x3005, 1}, {0x301c, 0x301c, 1},
+		{0x303b, 0x303c, 1}, {0x3041, 0x3049, 2}, {0x3063, 0x3083, 32}, {0x3085, 0x3087, 2}, {0x308e, 0x308e, 1}, {0x3095, 0x3096, 1},
+		{0x309b, 0x309e, 1}, {0x30a0, 0x30a1, 1}, {0x30a3, 0x30a9, 2}, {0x30c3, 0x30e3, 32}, {0x30e5, 0x30e7, 2}, {0x30ee, 0x30ee, 1},
+		{0x30f5, 0x30f6, 1}, {0x30fb, 0x30fe, 1}, {0x31f0, 0x31ff, 1}, {0xa015, 0xa015, 1}, {0xfe54, 0xfe55, 1}, {0xff1a, 0xff1b, 1},
+		{0xff65, 0xff65, 1}, {0xff67, 0xff70, 1}, {0xff9e, 0xff9f, 1}}},
+	lbIN: {R16: []unicode.Range16{{0x2024, 0x2026, 1}, {0x22ef, 0x22ef, 1}, {0xfe19, 0xfe19, 1}}},
+	lbNU: {R16: []unicode.Range16{{0x066b, 0x066c, 1}}},
+	lbPO: {R16: []unicode.Range16{{0x0025, 0x0025, 1}, {0x00a2, 0x00a2, 1}, {0x00b0, 0x00b0, 1}, {0x060b, 0x060b, 1}, {0x066a, 0x066a, 1},
+		{0x2030, 0x2037, 1}, {0x20a7, 0x20a7, 1}, {0x20b6, 0x20b6, 1}, {0x20bb, 0x20bb, 1}, {0x20be, 0x20be, 1}, {0x2103, 0x2103, 1},
+		{0x2109, 0x2109, 1}, {0xfdfc, 0xfdfc, 1}, {0xfe6a, 0xfe6a, 1}, {0xff05, 0xff05, 1}, {0xffe0, 0xffe0, 1}}},
+	lbPR: {R16: []unicode.Range16{{0x002b, 0x002b, 1}, {0x005c, 0x005c, 1}, {0x00b1, 0x00b1, 1}, {0x2116, 0x2116, 1}, {0x2212, 0x2213, 1}}},
+	lbHL: {R16: []unicode.Range16{{0x05d0, 0x05ea, 1}, {0x05ef, 0x05f2, 1}, {0xfb1d, 0xfb1d, 1}, {0xfb1f, 0xfb28, 1}, {0xfb2a, 0xfb4f, 1}}},
+	lbEB: {R16: []unicode.Range16{{0x261d, 0x261d, 1}, {0x26f9, 0x26f9, 1}, {0x270a, 0x270d, 1}},
+		R32: []unicode.Range32{{0x1f385, 0x1f385, 1}, {0x1f3c2, 0x1f3c4, 1}, {0x1f3c7, 0x1f3c7, 1}, {0x1f3ca, 0x1f3cc, 1}, {0x1f442, 0x1f443, 1},
+			{0x1f446, 0x1f450, 1}, {0x1f466, 0x1f478, 1}, {0x1f47c, 0x1f47c, 1}, {0x1f481, 0x1f483, 1}, {0x1f485, 0x1f487, 1},
+			{0x1f4aa, 0x1f4aa, 1}, {0x1f574, 0x1f575, 1}, {0x1f57a, 0x1f57a, 1}, {0x1f590, 0x1f590, 1}, {0x1f595, 0x1f596, 1},
+			{0x1f645, 0x1f647, 1}, {0x1f64b, 0x1f64f, 1}, {0x1f6a3, 0x1f6a3, 1}, {0x1f6b4, 0x1f6b6, 1}, {0x1f6c0, 0x1f6c0, 1},
+			{0x1f6cc, 0x1f6cc, 1}, {0x1f90c, 0x1f90c, 1}, {0x1f90f, 0x1f90f, 1}, {0x1f918, 0x1f91f, 1}, {0x1f926, 0x1f926, 1},
+			{0x1f930, 0x1f939, 1}, {0x1f93c, 0x1f93e, 1}, {0x1f977, 0x1f977, 1}, {0x1f9b5, 0x1f9b6, 1}, {0x1f9b8, 0x1f9b9, 1},
+			{0x1f9bb, 0x1f9bb, 1}, {0x1f9cd, 0x1f9cf, 1}, {0x1f9d1, 0x1f9dd, 1}}},
+	lbEM: {R32: []unicode.Range32{{0x1f3fb, 0x1f3ff, 1}}},
+	lbRI: {R32: []unicode.Range32{{0x1f1e6, 0x1f1ff, 1}}},
+}
+
+// lbOrder is the order lbClasses are looked up in.
+var lbOrder = []lbClass{lbBK, lbCR, lbLF, lbNL, lbSP, lbZW, lbZWJ, lbWJ, lbGL, lbBA, lbBB, lbB2, lbHY, lbCB, lbCL, lbCP, lbOP, lbQU,
+	lbEX, lbIS, lbSY, lbNS, lbIN, lbNU, lbPO, lbPR, lbHL, lbEB, lbEM, lbRI}
+
+// lbComplexScripts need a dictionary to find words, they have the SA class.
+var lbComplexScripts = []*unicode.RangeTable{unicode.Thai, unicode.Lao, unicode.Myanmar, unicode.Khmer, unicode.Tai_Tham, unicode.New_Tai_Lue,
+	unicode.Tai_Le, unicode.Tai_Viet}
+
+// lbIdeographic holds the ideographic ranges with the ID class.
+var lbIdeographic = &unicode.RangeTable{
+	R16: []unicode.Range16{{0x2e80, 0x2fff, 1}, {0x3003, 0x3004, 1}, {0x3006, 0x3007, 1}, {0x3012, 0x3013, 1}, {0x3020, 0x3029, 1},
+		{0x3030, 0x303a, 1}, {0x303d, 0x33ff, 1}, {0x3400, 0x4dbf, 1}, {0x4e00, 0x9fff, 1}, {0xa000, 0xa4cf, 1}, {0xf900, 0xfaff, 1},
+		{0xfe30, 0xfe4f, 1}, {0xff02, 0xff60, 1}, {0xffe0, 0xffe6, 1}},
+	R32: []unicode.Range32{{0x1f000, 0x1faff, 1}, {0x20000, 0x3fffd, 1}},
+}
+
+// lineBreakClass returns the class of r after the resolution of LB1, AI,
+// SG and XX are AL, CJ is NS and SA is CM for combining marks.
+func lineBreakClass(r rune) lbClass {
+	for _, c := range lbOrder {
+		if unicode.Is(lbClasses[c], r) {
+			return c
+		}
+	}
+	switch {
+	case r >= 0xac00 && r <= 0xd7a3:
+		if (r-0xac00)%28 == 0 {
+			return lbH2
+		}
+		return lbH3
+	case r >= 0x1100 && r <= 0x115f, r >= 0xa960 && r <= 0xa97c:
+		return lbJL
+	case r >= 0x1160 && r <= 0x11a7, r >= 0xd7b0 && r <= 0xd7c6:
+		return lbJV
+	case r >= 0x11a8 && r <= 0x11ff, r >= 0xd7cb && r <= 0xd7fb:
+		return lbJT
+	case unicode.In(r, unicode.Mn, unicode.Mc, unicode.Me, unicode.Cc), r == 0x200c:
+		return lbCM
+	case unicode.In(r, lbComplexScripts...) && unicode.IsLetter(r):
+		return lbSA
+	case unicode.Is(unicode.Nd, r):
+		if r >= 0xff10 && r <= 0xff19 {
+			return lbID
+		}
+		return lbNU
+	case unicode.Is(unicode.Ps, r):
+		return lbOP
+	case unicode.Is(unicode.Pe, r):
+		return lbCL
+	case unicode.In(r, unicode.Pi, unicode.Pf):
+		return lbQU
+	case unicode.Is(lbIdeographic, r), unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Bopomofo):
+		return lbID
+	case unicode.Is(unicode.Sc, r):
+		return lbPR
+	case unicode.Is(unicode.Zs, r):
+		return lbBA
+	}
+	return lbAL
+}
+
+// wideOpening tells whether r is an East Asian wide opening punctuation,
+// LB30 does not apply to them.
+func wideOpening(r rune) bool {
+	return r >= 0x2e80
+}
+
+// thaiLeading and thaiFollowing are the vowels of Thai and Lao written before
+// or after their consonant, no line starts with a following vowel or ends with
+// a leading one.
+func thaiLeading(r rune) bool {
+	return (r >= 0x0e40 && r <= 0x0e44) || (r >= 0x0ec0 && r <= 0x0ec4)
+}
+
+func thaiFollowing(r rune) bool {
+	switch r {
+	case 0x0e30, 0x0e32, 0x0e33, 0x0e45, 0x0e46, 0x0eb0, 0x0eb2, 0x0eb3, 0x0ec6:
+		return true
+	}
+	return false
+}
+
+// LineBreak is a line break opportunity before the byte Offset of a text.
+// Mandatory breaks follow a newline or paragraph separator, Hyphen breaks
+// follow a soft hyphen and need a visible hyphen at the end of the line.
+type LineBreak struct {
+	Offset    int
+	Mandatory bool
+	Hyphen    bool
+}
+
+// LineBreaker iterates the line break opportunities of a text following the
+// rules of UAX #14. Classes are taken from the general category and script of
+// code points with the exceptions of LineBreak.txt for punctuation, East
+// Asian widths are approximated by the CJK blocks. Without a dictionary the
+// SA scripts (Thai, Lao, Khmer, Myanmar...) break between any two letters
+// except around the vowels written before or after their consonant.
+type LineBreaker struct {
+	text string
+	pos  int
+
+	// prev is the class of the previous code point after LB9 and LB10, raw
+	// its class before LB10 and prevRune the code point. Combining marks
+	// are not counted, zwj tells whether the last code point was a ZWJ.
+	prev     lbClass
+	raw      lbClass
+	prevRune rune
+	zwj      bool
+	// base is the class before the spaces preceding pos, prevPrev the class
+	// before prev
+	base     lbClass
+	prevPrev lbClass
+	riCount  int
+	started  bool
+}
+
+func NewLineBreaker(text string) *LineBreaker {
+	return &LineBreaker{text: text}
+}
+
+// Next returns the next break opportunity, the end of the text is the last
+// one.
+func (b *LineBreaker) Next() (LineBreak, bool) {
+	for b.pos < len(b.text) {
+		r, size := utf8.DecodeRuneInString(b.text[b.pos:])
+		offset := b.pos
+		b.pos += size
+		cls := lineBreakClass(r)
+
+		if !b.started {
+			b.started = true
+			// LB10 for a leading combining mark
+			if cls == lbCM || cls == lbZWJ {
+				cls = lbAL
+			}
+			b.push(r, cls, cls)
+			continue
+		}
+
+		brk, absorbed := b.allowed(r, cls)
+		prev, prevRune, raw := b.prev, b.prevRune, b.raw
+		if !absorbed {
+			eff := cls
+			if cls == lbCM || cls == lbZWJ {
+				eff = lbAL
+			}
+			b.push(r, cls, eff)
+		}
+		b.zwj = cls == lbZWJ
+		if brk {
+			return LineBreak{
+				Offset:    offset,
+				Mandatory: raw == lbBK || raw == lbCR || raw == lbLF || raw == lbNL,
+				Hyphen:    prevRune == 0x00ad && prev == lbBA,
+			}, true
+		}
+	}
+	if b.pos == len(b.text) && b.started {
+		b.pos++
+		return LineBreak{Offset: len(b.text), Mandatory: true}, true
+	}
+	return LineBreak{}, false
+}
+
+func (b *LineBreaker) push(r rune, raw, eff lbClass) {
+	if eff == lbRI {
+		if b.prev == lbRI {
+			b.riCount++
+		} else {
+			b.riCount = 1
+		}
+	}
+	if raw != lbSP {
+		b.base = eff
+	}
+	b.prevPrev = b.prev
+	b.prev = eff
+	b.prevRune = r
+	b.raw = raw
+}
+
+// allowed tells whether the text may break before r, absorbed is set when r
+// is a combining mark taking the class of the code point before it (LB9).
+func (b *LineBreaker) allowed(r rune, cls lbClass) (bool, bool) {
+	a, raw := b.prev, b.raw
+
+	// LB4, LB5
+	switch raw {
+	case lbBK, lbLF, lbNL:
+		return true, false
+	case lbCR:
+		return cls != lbLF, false
+	}
+	// LB6, LB7
+	switch cls {
+	case lbBK, lbCR, lbLF, lbNL, lbSP, lbZW:
+		return false, false
+	}
+	// LB8
+	if b.base == lbZW && (raw == lbZW || raw == lbSP) {
+		return true, false
+	}
+	// LB8a
+	if b.zwj {
+		return false, (cls == lbCM || cls == lbZWJ) && raw != lbSP && raw != lbZW
+	}
+	// LB9
+	if (cls == lbCM || cls == lbZWJ) && raw != lbSP && raw != lbZW {
+		return false, true
+	}
+	if cls == lbCM || cls == lbZWJ {
+		cls = lbAL
+	}
+	spaces := raw == lbSP
+	base := b.base
+
+	// dictionary-less fallback, SA letters break like ideographs except
+	// around vowels bound to their consonant, otherwise SA is AL
+	if a == lbSA && cls == lbSA {
+		return !thaiLeading(b.prevRune) && !thaiFollowing(r), false
+	}
+	if a == lbSA {
+		a = lbAL
+	}
+	if cls == lbSA {
+		cls = lbAL
+	}
+
+	switch {
+	// LB11
+	case cls == lbWJ, a == lbWJ && !spaces:
+		return false, false
+	// LB12
+	case a == lbGL && !spaces:
+		return false, false
+	// LB12a
+	case cls == lbGL && !spaces && a != lbBA && a != lbHY:
+		return false, false
+	// LB13
+	case cls == lbCL, cls == lbCP, cls == lbEX, cls == lbIS, cls == lbSY:
+		return false, false
+	// LB14
+	case base == lbOP:
+		return false, false
+	// LB15
+	case base == lbQU && cls == lbOP:
+		return false, false
+	// LB16
+	case (base == lbCL || base == lbCP) && cls == lbNS:
+		return false, false
+	// LB17
+	case base == lbB2 && cls == lbB2:
+		return false, false
+	// LB18
+	case spaces:
+		return true, false
+	// LB19
+	case cls == lbQU, a == lbQU:
+		return false, false
+	// LB20
+	case cls == lbCB, a == lbCB:
+		return true, false
+	// LB21
+	case cls == lbBA, cls == lbHY, cls == lbNS, a == lbBB:
+		return false, false
+	// LB21a
+	case b.prevPrev == lbHL && (a == lbHY || a == lbBA):
+		return false, false
+	// LB21b
+	case a == lbSY && cls == lbHL:
+		return false, false
+	// LB22
+	case cls == lbIN:
+		return false, false
+	// LB23
+	case (a == lbAL || a == lbHL) && cls == lbNU, a == lbNU && (cls == lbAL || cls == lbHL):
+		return false, false
+	// LB23a
+	case a == lbPR && (cls == lbID || cls == lbEB || cls == lbEM), (a == lbID || a == lbEB || a == lbEM) && cls == lbPO:
+		return false, false
+	// LB24
+	case (a == lbPR || a == lbPO) && (cls == lbAL || cls == lbHL), (a == lbAL || a == lbHL) && (cls == lbPR || cls == lbPO):
+		return false, false
+	// LB25, the pairs of the example tailoring
+	case (a == lbCL || a == lbCP || a == lbNU) && (cls == lbPO || cls == lbPR),
+		(a == lbPO || a == lbPR) && (cls == lbOP || cls == lbNU),
+		(a == lbHY || a == lbIS || a == lbNU || a == lbSY) && cls == lbNU:
+		return false, false
+	// LB26
+	case a == lbJL && (cls == lbJL || cls == lbJV || cls == lbH2 || cls == lbH3),
+		(a == lbJV || a == lbH2) && (cls == lbJV || cls == lbJT),
+		(a == lbJT || a == lbH3) && cls == lbJT:
+		return false, false
+	// LB27
+	case isHangul(a) && cls == lbPO, a == lbPR && isHangul(cls):
+		return false, false
+	// LB28
+	case (a == lbAL || a == lbHL) && (cls == lbAL || cls == lbHL):
+		return false, false
+	// LB29
+	case a == lbIS && (cls == lbAL || cls == lbHL):
+		return false, false
+	// LB30
+	case (a == lbAL || a == lbHL || a == lbNU) && cls == lbOP && !wideOpening(r),
+		a == lbCP && (cls == lbAL || cls == lbHL || cls == lbNU):
+		return false, false
+	// LB30a
+	case a == lbRI && cls == lbRI:
+		return b.riCount%2 == 0, false
+	// LB30b
+	case a == lbEB && cls == lbEM:
+		return false, false
+	}
+	// LB31
+	return true, false
+}
+
+func isHangul(c lbClass) bool {
+	return c == lbJL || c == lbJV || c == lbJT || c == lbH2 || c == lbH3
+}
+
+// LineBreaks returns all line break opportunities of text.
+func LineBreaks(text string) []LineBreak {
+	ret := []LineBreak{}
+	b := NewLineBreaker(text)
+	for {
+		brk, ok := b.Next()
+		if !ok {
+			return ret
+		}
+		ret = append(ret, brk)
+	}
+}
@@ -0,0 +1,54 @@
+package fontcatalog
+
+import (
+	"strings"
+	"testing"
+)
+
+// segments writes text with | at break opportunities, ! after mandatory ones
+// and - after hyphen ones.
+func segments(text string) string {
+	var b strings.Builder
+	last := 0
+	for _, brk := range LineBreaks(text) {
+		b.WriteString(text[last:brk.Offset])
+		b.WriteByte('|')
+		if brk.Mandatory {
+			b.WriteByte('!')
+		}
+		if brk.Hyphen {
+			b.WriteByte('-')
+		}
+		last = brk.Offset
+	}
+	return b.String()
+}
+
+func TestLineBreaks(t *testing.T) {
+	for _, tc := range []struct{ text, want string }{
+		{"", ""},
+		{"Hello world", "Hello |world|!"},
+		{"a\r\nb\n", "a\r\n|!b\n|!"},
+		{"a\n\nb", "a\n|!\n|!b|!"},
+		{"100 km/h (approx.)", "100 |km/|h |(approx.)|!"},
+		{"$100 50% off!", "$100 |50% |off!|!"},
+		{"e.g. \"quoted\" text", "e.g. |\"quoted\" |text|!"},
+		{"well-known", "well-|known|!"},
+		{"שלום-עולם", "שלום-עולם|!"},
+		{"co­op", "co­|-op|!"},
+		{"a b c", "a b |c|!"},
+		{"a​b", "a​|b|!"},
+		{"a⁠b", "a⁠b|!"},
+		{"日本語のテキスト。次", "日|本|語|の|テ|キ|ス|ト。|次|!"},
+		{"「東京」へ", "「東|京」|へ|!"},
+		{"한국어 텍스트", "한|국|어 |텍|스|트|!"},
+		{"ไปเมือง", "ไป|เมื|อ|ง|!"},
+		{"é x", "é |x|!"},
+		{"\U0001F1E9\U0001F1EA\U0001F1EB\U0001F1F7", "\U0001F1E9\U0001F1EA|\U0001F1EB\U0001F1F7|!"},
+		{"\U0001F44B\U0001F3FD\U0001F44B", "\U0001F44B\U0001F3FD|\U0001F44B|!"},
+	} {
+		if got := segments(tc.text); got != tc.want {
+			t.Errorf("%q: got %q, want %q", tc.text, got, tc.want)
+		}
+	}
+}
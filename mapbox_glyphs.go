@@ -0,0 +1,377 @@
+package fontcatalog
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"path"
+	"sort"
+)
+
+// Mapbox GL and MapLibre expect glyphs rendered at 24px with a 3px buffer
+// around every bitmap, the distance field covers 8px on either side of the
+// outline with the edge at 192.
+const (
+	MapboxGlyphSize   = 24
+	MapboxGlyphBuffer = 3
+	mapboxGlyphRadius = 8
+	mapboxGlyphCutoff = 0.25
+)
+
+type MapboxGlyph struct {
+	ID      uint32
+	Bitmap  []byte
+	Width   uint32
+	Height  uint32
+	Left    int32
+	Top     int32
+	Advance uint32
+}
+
+type MapboxFontstack struct {
+	Name   string
+	Range  string
+	Glyphs []MapboxGlyph
+}
+
+// MapboxGlyphs is the glyphs protobuf message of Mapbox GL glyph ranges.
+type MapboxGlyphs struct {
+	Stacks []MapboxFontstack
+}
+
+// NewMapboxFontstack renders the code points of charset, the character set of
+// holder, from start to end included the way Mapbox GL clients expect them. Left and top are in pixels from the
+// pen position to the top left corner of the glyph without buffer, top is
+// measured from the em box top like fontnik does.
+func NewMapboxFontstack(holder *FontHolder, charset []rune, name string, start, end int) (*MapboxFontstack, error) {
+	stack := &MapboxFontstack{Name: name, Range: fmt.Sprintf("%d-%d", start, end)}
+
+	geometry := NewFontGeometryWithGlyphs(NewGlyphGeometryList())
+	if !geometry.LoadMetrics(holder, MapboxGlyphSize) {
+		return stack, nil
+	}
+	scale := geometry.GetGeometryScale()
+	attr := NewGeneratorAttributes()
+
+	for _, cp := range charset {
+		if int(cp) < start || int(cp) > end {
+			continue
+		}
+		glyph := NewGlyphGeometryWithCodePoint(holder, scale, cp)
+		if glyph.m == nil {
+			continue
+		}
+		mg := MapboxGlyph{ID: uint32(cp), Advance: uint32(math.Round(glyph.GetAdvance()))}
+
+		cimg, err := generateGlyphImage(glyph, string(cp), MOD_SDF, 2*mapboxGlyphRadius, EdgeColoringSimple, 3, 0, attr)
+		if err != nil {
+			return nil, fmt.Errorf("glyph %U of font %s: %w", cp, name, err)
+		}
+		if cimg != nil && cimg.image != nil {
+			mg.setBitmap(cimg)
+		}
+		stack.Glyphs = append(stack.Glyphs, mg)
+	}
+	return stack, nil
+}
+
+// CombineMapboxFontstacks merges the same range of several fonts into the
+// range of a fontstack, each glyph comes from the first font that has it.
+func CombineMapboxFontstacks(name string, stacks []*MapboxFontstack) *MapboxFontstack {
+	combined := &MapboxFontstack{Name: name}
+	seen := make(map[uint32]bool)
+	for _, stack := range stacks {
+		if combined.Range == "" {
+			combined.Range = stack.Range
+		}
+		for _, glyph := range stack.Glyphs {
+			if !seen[glyph.ID] {
+				seen[glyph.ID] = true
+				combined.Glyphs = append(combined.Glyphs, glyph)
+			}
+		}
+	}
+	sort.Slice(combined.Glyphs, func(i, j int) bool { return combined.Glyphs[i].ID < combined.Glyphs[j].ID })
+	return combined
+}
+
+// WriteMapboxGlyphs writes the protobuf of every 256 code point range of
+// holder that has glyphs as {name}/{start}-{end}.pbf.
+func WriteMapboxGlyphs(out OutputWriter, holder *FontHolder, name string) error {
+	charset := holder.getFontInfo().CharacterSet
+	seen := make(map[int]bool)
+	starts := []int{}
+	for _, cp := range charset {
+		start := int(cp) / glyphRangeSize * glyphRangeSize
+		if !seen[start] {
+			seen[start] = true
+			starts = append(starts, start)
+		}
+	}
+	sort.Ints(starts)
+
+	for _, start := range starts {
+		stack, err := NewMapboxFontstack(holder, charset, name, start, start+glyphRangeSize-1)
+		if err != nil {
+			return err
+		}
+		data := (&MapboxGlyphs{Stacks: []MapboxFontstack{*stack}}).ToPbf()
+		if err := out.WriteFile(path.Join(name, stack.Range+".pbf"), data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setBitmap crops the glyph box, padded by the radius, down to the buffer.
+func (g *MapboxGlyph) setBitmap(cimg *CharsetImage) {
+	img, ok := cimg.image.(*image.Gray)
+	if !ok {
+		return
+	}
+	pad := mapboxGlyphRadius - MapboxGlyphBuffer
+	w, h := img.Bounds().Dx()-2*pad, img.Bounds().Dy()-2*pad
+	if w <= 2*MapboxGlyphBuffer || h <= 2*MapboxGlyphBuffer {
+		return
+	}
+
+	g.Width = uint32(w - 2*MapboxGlyphBuffer)
+	g.Height = uint32(h - 2*MapboxGlyphBuffer)
+	g.Left = int32(math.Round(cimg.plane.Bounds[0])) + mapboxGlyphRadius
+	g.Top = int32(math.Round(cimg.plane.Bounds[3])) - mapboxGlyphRadius - MapboxGlyphSize
+	g.Bitmap = make([]byte, 0, w*h)
+	for y := pad; y < pad+h; y++ {
+		for x := pad; x < pad+w; x++ {
+			g.Bitmap = append(g.Bitmap, mapboxDistance(img.GrayAt(x, y).Y))
+		}
+	}
+}
+
+// mapboxDistance converts a MOD_SDF value with a range of twice the radius
+// to the encoding of fontnik, 255 - 255 * (distance / radius + cutoff) with
+// the distance positive outside.
+func mapboxDistance(v uint8) byte {
+	outside := (0.5 - float64(v)/255) * 2 * mapboxGlyphRadius
+	d := 255 - 255*(outside/mapboxGlyphRadius+mapboxGlyphCutoff)
+	return byte(math.Max(0, math.Min(255, math.Round(d))))
+}
+
+func (g *MapboxGlyphs) ToPbf() []byte {
+	var buf pbfBuffer
+	for i := range g.Stacks {
+		buf.message(1, g.Stacks[i].pbf())
+	}
+	return buf
+}
+
+func (s *MapboxFontstack) pbf() []byte {
+	var buf pbfBuffer
+	buf.bytes(1, []byte(s.Name))
+	buf.bytes(2, []byte(s.Range))
+	for i := range s.Glyphs {
+		buf.message(3, s.Glyphs[i].pbf())
+	}
+	return buf
+}
+
+func (g *MapboxGlyph) pbf() []byte {
+	var buf pbfBuffer
+	buf.uint(1, uint64(g.ID))
+	if g.Bitmap != nil {
+		buf.bytes(2, g.Bitmap)
+	}
+	buf.uint(3, uint64(g.Width))
+	buf.uint(4, uint64(g.Height))
+	buf.sint(5, int64(g.Left))
+	buf.sint(6, int64(g.Top))
+	buf.uint(7, uint64(g.Advance))
+	return buf
+}
+
+func ReadMapboxGlyphs(data []byte) (*MapboxGlyphs, error) {
+	g := &MapboxGlyphs{}
+	err := readPbf(data, func(field int, r *pbfReader) error {
+		if field != 1 {
+			return r.skip()
+		}
+		stack := MapboxFontstack{}
+		err := readPbf(r.bytes(), func(field int, r *pbfReader) error {
+			switch field {
+			case 1:
+				stack.Name = string(r.bytes())
+			case 2:
+				stack.Range = string(r.bytes())
+			case 3:
+				glyph := MapboxGlyph{}
+				err := readPbf(r.bytes(), func(field int, r *pbfReader) error {
+					switch field {
+					case 1:
+						glyph.ID = uint32(r.uint())
+					case 2:
+						glyph.Bitmap = append([]byte(nil), r.bytes()...)
+					case 3:
+						glyph.Width = uint32(r.uint())
+					case 4:
+						glyph.Height = uint32(r.uint())
+					case 5:
+						glyph.Left = int32(r.sint())
+					case 6:
+						glyph.Top = int32(r.sint())
+					case 7:
+						glyph.Advance = uint32(r.uint())
+					default:
+						return r.skip()
+					}
+					return r.err
+				})
+				if err != nil {
+					return err
+				}
+				stack.Glyphs = append(stack.Glyphs, glyph)
+			default:
+				return r.skip()
+			}
+			return r.err
+		})
+		if err != nil {
+			return err
+		}
+		g.Stacks = append(g.Stacks, stack)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// pbfBuffer writes the protobuf wire format, only the varint and length
+// delimited types the glyph messages use.
+type pbfBuffer []byte
+
+func (b *pbfBuffer) varint(v uint64) {
+	for v >= 0x80 {
+		*b = append(*b, byte(v)|0x80)
+		v >>= 7
+	}
+	*b = append(*b, byte(v))
+}
+
+func (b *pbfBuffer) uint(field int, v uint64) {
+	b.varint(uint64(field) << 3)
+	b.varint(v)
+}
+
+func (b *pbfBuffer) sint(field int, v int64) {
+	b.uint(field, uint64((v<<1)^(v>>63)))
+}
+
+func (b *pbfBuffer) bytes(field int, data []byte) {
+	b.varint(uint64(field)<<3 | 2)
+	b.varint(uint64(len(data)))
+	*b = append(*b, data...)
+}
+
+func (b *pbfBuffer) message(field int, data []byte) {
+	b.bytes(field, data)
+}
+
+var errPbfTruncated = errors.New("truncated protobuf message")
+
+type pbfReader struct {
+	data []byte
+	wire int
+	err  error
+}
+
+func readPbf(data []byte, field func(field int, r *pbfReader) error) error {
+	r := &pbfReader{data: data}
+	for len(r.data) > 0 {
+		key := r.varint()
+		if r.err != nil {
+			return r.err
+		}
+		r.wire = int(key & 7)
+		if err := field(int(key>>3), r); err != nil {
+			return err
+		}
+		if r.err != nil {
+			return r.err
+		}
+	}
+	return nil
+}
+
+func (r *pbfReader) varint() uint64 {
+	var v uint64
+	for shift := uint(0); shift < 64; shift += 7 {
+		if len(r.data) == 0 {
+			r.err = errPbfTruncated
+			return 0
+		}
+		c := r.data[0]
+		r.data = r.data[1:]
+		v |= uint64(c&0x7f) << shift
+		if c < 0x80 {
+			return v
+		}
+	}
+	r.err = errors.New("invalid protobuf varint")
+	return 0
+}
+
+func (r *pbfReader) uint() uint64 {
+	if r.wire != 0 {
+		r.err = fmt.Errorf("unexpected protobuf wire type %d", r.wire)
+		return 0
+	}
+	return r.varint()
+}
+
+func (r *pbfReader) sint() int64 {
+	v := r.uint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (r *pbfReader) bytes() []byte {
+	if r.wire != 2 {
+		r.err = fmt.Errorf("unexpected protobuf wire type %d", r.wire)
+		return nil
+	}
+	n := r.varint()
+	if r.err != nil {
+		return nil
+	}
+	if uint64(len(r.data)) < n {
+		r.err = errPbfTruncated
+		return nil
+	}
+	data := r.data[:n]
+	r.data = r.data[n:]
+	return data
+}
+
+func (r *pbfReader) skip() error {
+	switch r.wire {
+	case 0:
+		r.varint()
+	case 1:
+		r.fixed(8)
+	case 2:
+		r.bytes()
+	case 5:
+		r.fixed(4)
+	default:
+		r.err = fmt.Errorf("unexpected protobuf wire type %d", r.wire)
+	}
+	return r.err
+}
+
+func (r *pbfReader) fixed(n int) {
+	if len(r.data) < n {
+		r.err = errPbfTruncated
+		return
+	}
+	r.data = r.data[n:]
+}
@@ -0,0 +1,88 @@
+package fontcatalog
+
+import (
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+func TestMapboxGlyphs(t *testing.T) {
+	data, err := ioutil.ReadFile("./fonts/FiraGO_Map.ttf")
+	if err != nil {
+		t.FailNow()
+	}
+
+	holder := NewFontHolder(data)
+	stack, err := NewMapboxFontstack(holder, holder.getFontInfo().CharacterSet, "FiraGO Map", 0, 255)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stack.Name != "FiraGO Map" || stack.Range != "0-255" || len(stack.Glyphs) < 95 {
+		t.FailNow()
+	}
+
+	glyphs := map[uint32]MapboxGlyph{}
+	for i, g := range stack.Glyphs {
+		if i > 0 && g.ID <= stack.Glyphs[i-1].ID {
+			t.Fatalf("glyph %d out of order", g.ID)
+		}
+		if g.Bitmap != nil && len(g.Bitmap) != int((g.Width+2*MapboxGlyphBuffer)*(g.Height+2*MapboxGlyphBuffer)) {
+			t.Fatalf("glyph %d bitmap size %d", g.ID, len(g.Bitmap))
+		}
+		glyphs[g.ID] = g
+	}
+
+	space := glyphs[' ']
+	if space.Bitmap != nil || space.Width != 0 || space.Advance == 0 {
+		t.FailNow()
+	}
+
+	// the stem of T is inside the outline, the buffer corner outside, and the
+	// bar is at the top
+	tg := glyphs['T']
+	stride := int(tg.Width + 2*MapboxGlyphBuffer)
+	at := func(x, y int) byte { return tg.Bitmap[y*stride+x] }
+	if at(stride/2, int(tg.Height)/2+MapboxGlyphBuffer) < 192 || at(0, 0) >= 192 {
+		t.FailNow()
+	}
+	inside := func(y int) int {
+		n := 0
+		for x := 0; x < stride; x++ {
+			if at(x, y) >= 192 {
+				n++
+			}
+		}
+		return n
+	}
+	if inside(MapboxGlyphBuffer+1) <= inside(int(tg.Height)+MapboxGlyphBuffer-2) {
+		t.FailNow()
+	}
+	if tg.Top >= 0 || tg.Top < -MapboxGlyphSize || tg.Advance == 0 {
+		t.Fatalf("T top %d advance %d", tg.Top, tg.Advance)
+	}
+
+	msg := &MapboxGlyphs{Stacks: []MapboxFontstack{*stack}}
+	read, err := ReadMapboxGlyphs(msg.ToPbf())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(read, msg) {
+		t.FailNow()
+	}
+	if _, err := ReadMapboxGlyphs(msg.ToPbf()[:10]); err == nil {
+		t.FailNow()
+	}
+}
+
+func TestCombineMapboxFontstacks(t *testing.T) {
+	a := &MapboxFontstack{Name: "A", Range: "0-255", Glyphs: []MapboxGlyph{{ID: 'b', Advance: 1}, {ID: 'c', Advance: 1}}}
+	b := &MapboxFontstack{Name: "B", Range: "0-255", Glyphs: []MapboxGlyph{{ID: 'a', Advance: 2}, {ID: 'b', Advance: 2}}}
+
+	stack := CombineMapboxFontstacks("A,B", []*MapboxFontstack{a, b})
+	if stack.Name != "A,B" || stack.Range != "0-255" || len(stack.Glyphs) != 3 {
+		t.FailNow()
+	}
+	if stack.Glyphs[0].ID != 'a' || stack.Glyphs[0].Advance != 2 || stack.Glyphs[1].Advance != 1 {
+		t.FailNow()
+	}
+}
@@ -38,6 +38,8 @@ const (
 	RectBestShortSideFit FreeRectChoiceHeuristic = iota
 	RectBestAreaFit
 	RectBottomLeftRule
+	RectBestLongSideFit
+	RectContactPointRule
 )
 
 type MaxRectsBinPacker struct {
@@ -95,6 +97,7 @@ func (mr *MaxRectsBinPacker) Pack(inputRects []RectNode, method FreeRectChoiceHe
 	result := &MaxRectsBinResult{
 		PlacedRects:    mr.usedRectangles,
 		NotPlacedRects: rects,
+		Method:         method,
 	}
 	for i := 0; i < len(mr.usedRectangles); i++ {
 		rect := mr.usedRectangles[i]
@@ -134,6 +137,11 @@ func (mr *MaxRectsBinPacker) scoreRect(rect RectNode, method FreeRectChoiceHeuri
 		newNode = mr.findPositionForNewNodeBottomLeft(width, height, rotatedWidth, rotatedHeight, mr.allowRotation, score1, score2)
 	case RectBestAreaFit:
 		newNode = mr.findPositionForNewNodeBestAreaFit(width, height, rotatedWidth, rotatedHeight, mr.allowRotation, score1, score2)
+	case RectBestLongSideFit:
+		newNode = mr.findPositionForNewNodeBestLongSideFit(width, height, rotatedWidth, rotatedHeight, mr.allowRotation, score2, score1)
+	case RectContactPointRule:
+		newNode = mr.findPositionForNewNodeContactPoint(width, height, rotatedWidth, rotatedHeight, mr.allowRotation, score1)
+		*score1 = -*score1
 	default:
 		panic("Unknown free-rect choice heuristic")
 	}
@@ -146,6 +154,26 @@ func (mr *MaxRectsBinPacker) scoreRect(rect RectNode, method FreeRectChoiceHeuri
 	return newNode
 }
 
+// Release frees the space of a placed node. The free list is rebuilt from the
+// remaining nodes so the released area merges with its free neighbours.
+func (mr *MaxRectsBinPacker) Release(node RectNode) bool {
+	for i := range mr.usedRectangles {
+		if mr.usedRectangles[i].Rect != node.Rect {
+			continue
+		}
+		used := append([]RectNode(nil), mr.usedRectangles[:i]...)
+		used = append(used, mr.usedRectangles[i+1:]...)
+
+		mr.usedRectangles = make([]RectNode, 0, len(used))
+		mr.freeRectangles = []RectNode{NewRectNode(-1, mr.binWidth, mr.binHeight)}
+		for _, n := range used {
+			mr.placeRect(n)
+		}
+		return true
+	}
+	return false
+}
+
 func (mr *MaxRectsBinPacker) Occupancy() float32 {
 	usedSurfaceArea := 0
 	for i := 0; i < len(mr.usedRectangles); i++ {
@@ -241,6 +269,110 @@ func (mr *MaxRectsBinPacker) findPositionForNewNodeBestShortSideFit(width, heigh
 	return bestNode
 }
 
+func (mr *MaxRectsBinPacker) findPositionForNewNodeBestLongSideFit(width, height, rotatedWidth, rotatedHeight int, rotate bool, bestShortSideFit, bestLongSideFit *int) RectNode {
+	bestNode := RectNode{}
+	*bestShortSideFit = math.MaxInt32
+	*bestLongSideFit = math.MaxInt32
+
+	for i := 0; i < len(mr.freeRectangles); i++ {
+		if mr.freeRectangles[i].W >= width && mr.freeRectangles[i].H >= height {
+			leftoverH := Abs(mr.freeRectangles[i].W - width)
+			leftoverV := Abs(mr.freeRectangles[i].H - height)
+			shortSideFit := Min(leftoverH, leftoverV)
+			longSideFit := Max(leftoverH, leftoverV)
+
+			if longSideFit < *bestLongSideFit || (longSideFit == *bestLongSideFit && shortSideFit < *bestShortSideFit) {
+				bestNode.X = mr.freeRectangles[i].X
+				bestNode.Y = mr.freeRectangles[i].Y
+				bestNode.W = width
+				bestNode.H = height
+				*bestShortSideFit = shortSideFit
+				*bestLongSideFit = longSideFit
+				bestNode.Rotated = false
+			}
+		}
+
+		if rotate && mr.freeRectangles[i].W >= rotatedWidth && mr.freeRectangles[i].H >= rotatedHeight {
+			leftoverH := Abs(mr.freeRectangles[i].W - rotatedWidth)
+			leftoverV := Abs(mr.freeRectangles[i].H - rotatedHeight)
+			shortSideFit := Min(leftoverH, leftoverV)
+			longSideFit := Max(leftoverH, leftoverV)
+
+			if longSideFit < *bestLongSideFit || (longSideFit == *bestLongSideFit && shortSideFit < *bestShortSideFit) {
+				bestNode.X = mr.freeRectangles[i].X
+				bestNode.Y = mr.freeRectangles[i].Y
+				bestNode.W = rotatedWidth
+				bestNode.H = rotatedHeight
+				*bestShortSideFit = shortSideFit
+				*bestLongSideFit = longSideFit
+				bestNode.Rotated = true
+			}
+		}
+	}
+	return bestNode
+}
+
+func commonIntervalLength(i1start, i1end, i2start, i2end int) int {
+	if i1end < i2start || i2end < i1start {
+		return 0
+	}
+	return Min(i1end, i2end) - Max(i1start, i2start)
+}
+
+func (mr *MaxRectsBinPacker) contactPointScoreNode(x, y, width, height int) int {
+	score := 0
+
+	if x == 0 || x+width == mr.binWidth {
+		score += height
+	}
+	if y == 0 || y+height == mr.binHeight {
+		score += width
+	}
+
+	for i := 0; i < len(mr.usedRectangles); i++ {
+		used := mr.usedRectangles[i]
+		if used.X == x+width || used.Right() == x {
+			score += commonIntervalLength(used.Y, used.Bottom(), y, y+height)
+		}
+		if used.Y == y+height || used.Bottom() == y {
+			score += commonIntervalLength(used.X, used.Right(), x, x+width)
+		}
+	}
+	return score
+}
+
+func (mr *MaxRectsBinPacker) findPositionForNewNodeContactPoint(width, height, rotatedWidth, rotatedHeight int, rotate bool, bestContactScore *int) RectNode {
+	bestNode := RectNode{}
+	*bestContactScore = -1
+
+	for i := 0; i < len(mr.freeRectangles); i++ {
+		if mr.freeRectangles[i].W >= width && mr.freeRectangles[i].H >= height {
+			score := mr.contactPointScoreNode(mr.freeRectangles[i].X, mr.freeRectangles[i].Y, width, height)
+			if score > *bestContactScore {
+				bestNode.X = mr.freeRectangles[i].X
+				bestNode.Y = mr.freeRectangles[i].Y
+				bestNode.W = width
+				bestNode.H = height
+				*bestContactScore = score
+				bestNode.Rotated = false
+			}
+		}
+
+		if rotate && mr.freeRectangles[i].W >= rotatedWidth && mr.freeRectangles[i].H >= rotatedHeight {
+			score := mr.contactPointScoreNode(mr.freeRectangles[i].X, mr.freeRectangles[i].Y, rotatedWidth, rotatedHeight)
+			if score > *bestContactScore {
+				bestNode.X = mr.freeRectangles[i].X
+				bestNode.Y = mr.freeRectangles[i].Y
+				bestNode.W = rotatedWidth
+				bestNode.H = rotatedHeight
+				*bestContactScore = score
+				bestNode.Rotated = true
+			}
+		}
+	}
+	return bestNode
+}
+
 func (mr *MaxRectsBinPacker) findPositionForNewNodeBottomLeft(width, height, rotatedWidth, rotatedHeight int, rotate bool, bestY, bestX *int) RectNode {
 	bestNode := RectNode{}
 	*bestX = math.MaxInt32
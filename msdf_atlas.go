@@ -0,0 +1,171 @@
+package fontcatalog
+
+import (
+	"encoding/json"
+)
+
+// glyphPlane keeps the unrounded layout of a generated glyph, bounds are
+// left, bottom, right, top in pixels relative to the pen position with y up.
+type glyphPlane struct {
+	Bounds  [4]float64
+	Advance float64
+}
+
+type MsdfAtlasInfo struct {
+	Type                string  `json:"type"`
+	DistanceRange       float64 `json:"distanceRange"`
+	DistanceRangeMiddle float64 `json:"distanceRangeMiddle"`
+	Size                float64 `json:"size"`
+	Width               int     `json:"width"`
+	Height              int     `json:"height"`
+	YOrigin             string  `json:"yOrigin"`
+}
+
+type MsdfAtlasMetrics struct {
+	EmSize             float64 `json:"emSize"`
+	LineHeight         float64 `json:"lineHeight"`
+	Ascender           float64 `json:"ascender"`
+	Descender          float64 `json:"descender"`
+	UnderlineY         float64 `json:"underlineY"`
+	UnderlineThickness float64 `json:"underlineThickness"`
+}
+
+type MsdfAtlasBounds struct {
+	Left   float64 `json:"left"`
+	Bottom float64 `json:"bottom"`
+	Right  float64 `json:"right"`
+	Top    float64 `json:"top"`
+}
+
+type MsdfAtlasGlyph struct {
+	Unicode     *int             `json:"unicode,omitempty"`
+	Index       *int             `json:"index,omitempty"`
+	Advance     float64          `json:"advance"`
+	PlaneBounds *MsdfAtlasBounds `json:"planeBounds,omitempty"`
+	AtlasBounds *MsdfAtlasBounds `json:"atlasBounds,omitempty"`
+	Page        int              `json:"page,omitempty"`
+}
+
+type MsdfAtlasKerning struct {
+	Unicode1 *int    `json:"unicode1,omitempty"`
+	Unicode2 *int    `json:"unicode2,omitempty"`
+	Index1   *int    `json:"index1,omitempty"`
+	Index2   *int    `json:"index2,omitempty"`
+	Advance  float64 `json:"advance"`
+}
+
+// MsdfAtlas follows the JSON layout written by msdf-atlas-gen with a top
+// y origin, so it matches the orientation of the generated pages. Plane
+// bounds, advances and metrics are in em, atlas bounds in pixels. Glyphs of
+// multi page fonts carry the page they are on.
+type MsdfAtlas struct {
+	Atlas   MsdfAtlasInfo      `json:"atlas"`
+	Name    string             `json:"name,omitempty"`
+	Metrics MsdfAtlasMetrics   `json:"metrics"`
+	Glyphs  []MsdfAtlasGlyph   `json:"glyphs"`
+	Kerning []MsdfAtlasKerning `json:"kerning"`
+}
+
+func (ur *MsdfAtlas) ToJson() (string, error) {
+	b, err := json.Marshal(ur)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func intRef(v int) *int {
+	return &v
+}
+
+// ToMsdfAtlas converts the font to the msdf-atlas-gen layout. Fonts built in
+// this process use the exact glyph bounds of the generation run, fonts read
+// from a file fall back to their integer offsets.
+func (ur *BitmapFont) ToMsdfAtlas() *MsdfAtlas {
+	size := float64(ur.Info.Size)
+	if size <= 0 {
+		size = 1
+	}
+	em := func(v float64) float64 { return v / size }
+
+	atlas := &MsdfAtlas{
+		Atlas: MsdfAtlasInfo{
+			Type:          ur.DistanceField.FieldType,
+			DistanceRange: ur.DistanceField.DistanceRange,
+			Size:          size,
+			Width:         ur.Common.ScaleW,
+			Height:        ur.Common.ScaleH,
+			YOrigin:       "top",
+		},
+		Name:    ur.Info.Face,
+		Glyphs:  make([]MsdfAtlasGlyph, 0, len(ur.Chars)),
+		Kerning: make([]MsdfAtlasKerning, 0, len(ur.Kerning)),
+	}
+
+	baseline := float64(ur.Common.Base)
+	if m := ur.metrics; m != nil {
+		scale := size / m.EmSize
+		baseline = m.AscenderY*scale + 0.5*ur.DistanceField.DistanceRange
+		atlas.Metrics = MsdfAtlasMetrics{
+			EmSize:             1,
+			LineHeight:         em(m.LineHeight * scale),
+			Ascender:           -em(m.AscenderY * scale),
+			Descender:          -em(m.DescenderY * scale),
+			UnderlineY:         -em(m.UnderlineY * scale),
+			UnderlineThickness: em(m.UnderlineThickness * scale),
+		}
+	} else {
+		atlas.Metrics = MsdfAtlasMetrics{
+			EmSize:     1,
+			LineHeight: em(float64(ur.Common.LineHeight)),
+			Ascender:   -em(baseline - 0.5*ur.DistanceField.DistanceRange),
+		}
+	}
+
+	exact := len(ur.planes) == len(ur.Chars)
+	for i, c := range ur.Chars {
+		var plane glyphPlane
+		if exact {
+			plane = ur.planes[i]
+		} else {
+			top := baseline - float64(c.YOffset)
+			plane = glyphPlane{
+				Bounds:  [4]float64{float64(c.XOffset), top - float64(c.Height), float64(c.XOffset + c.Width), top},
+				Advance: float64(c.XAdvance),
+			}
+		}
+
+		glyph := MsdfAtlasGlyph{Advance: em(plane.Advance), Page: c.Page}
+		if ur.Info.Unicode {
+			glyph.Unicode = intRef(c.ID)
+		} else {
+			glyph.Index = intRef(c.Index)
+		}
+		if c.Width > 0 && c.Height > 0 {
+			glyph.PlaneBounds = &MsdfAtlasBounds{
+				Left:   em(plane.Bounds[0]),
+				Bottom: -em(plane.Bounds[1]),
+				Right:  em(plane.Bounds[2]),
+				Top:    -em(plane.Bounds[3]),
+			}
+			glyph.AtlasBounds = &MsdfAtlasBounds{
+				Left:   float64(c.X) + 0.5,
+				Bottom: float64(c.Y+c.Height) - 0.5,
+				Right:  float64(c.X+c.Width) - 0.5,
+				Top:    float64(c.Y) + 0.5,
+			}
+		}
+		atlas.Glyphs = append(atlas.Glyphs, glyph)
+	}
+
+	for _, k := range ur.Kerning {
+		kerning := MsdfAtlasKerning{Advance: em(k.Amount)}
+		if ur.Info.Unicode {
+			kerning.Unicode1, kerning.Unicode2 = intRef(int(k.First)), intRef(int(k.Second))
+		} else {
+			kerning.Index1, kerning.Index2 = intRef(int(k.First)), intRef(int(k.Second))
+		}
+		atlas.Kerning = append(atlas.Kerning, kerning)
+	}
+	return atlas
+}
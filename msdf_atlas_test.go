@@ -0,0 +1,76 @@
+package fontcatalog
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"testing"
+)
+
+func TestMsdfAtlasExport(t *testing.T) {
+	data, err := ioutil.ReadFile("./fonts/FiraGO_Map.ttf")
+	if err != nil {
+		t.FailNow()
+	}
+
+	opts := DefaultBitmapFontOptions("Basic_Latin")
+	opts.FieldType = MOD_MSDF
+	bmfont := NewBitmapFontGenerater(NewFontHolder(data), NewCharsetsASCII(), 32, 4, opts).Generate()
+	if bmfont == nil {
+		t.FailNow()
+	}
+
+	encoded, err := bmfont.Encode(BitmapFontMsdfAtlas)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var atlas MsdfAtlas
+	if err := json.Unmarshal(encoded, &atlas); err != nil {
+		t.Fatal(err)
+	}
+	if atlas.Atlas.Type != MOD_MSDF || atlas.Atlas.Size != 32 || atlas.Atlas.Width != bmfont.Common.ScaleW || atlas.Metrics.EmSize != 1 {
+		t.FailNow()
+	}
+	if atlas.Metrics.Ascender >= 0 || atlas.Metrics.LineHeight <= 1 || len(atlas.Glyphs) != len(bmfont.Chars) {
+		t.FailNow()
+	}
+
+	// the json round trip loses the exact bounds, the fallback must stay
+	// within the rounding of the integer offsets
+	decoded, err := ReadBitmapFont([]byte(mustJson(t, bmfont)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	approx := decoded.ToMsdfAtlas()
+
+	for i, g := range atlas.Glyphs {
+		c := bmfont.Chars[i]
+		if c.Char == " " {
+			if g.PlaneBounds != nil || g.AtlasBounds != nil || g.Advance <= 0 {
+				t.Fatalf("space: %+v", g)
+			}
+			continue
+		}
+		if g.Unicode == nil || *g.Unicode != c.ID || g.PlaneBounds == nil || g.AtlasBounds == nil {
+			t.FailNow()
+		}
+		if int(math.Round(g.PlaneBounds.Left*32)) != c.XOffset {
+			t.Fatalf("%q: plane left %v does not match xoffset %d", c.Char, g.PlaneBounds.Left, c.XOffset)
+		}
+		if g.PlaneBounds.Top >= g.PlaneBounds.Bottom || g.AtlasBounds.Right-g.AtlasBounds.Left != float64(c.Width-1) {
+			t.Fatalf("%q: bad bounds %+v %+v", c.Char, g.PlaneBounds, g.AtlasBounds)
+		}
+		a := approx.Glyphs[i].PlaneBounds
+		if math.Abs(a.Left-g.PlaneBounds.Left) > 0.5/32 || math.Abs(a.Top-g.PlaneBounds.Top) > 1.0/32 {
+			t.Fatalf("%q: approximated bounds %+v differ from %+v", c.Char, a, g.PlaneBounds)
+		}
+	}
+}
+
+func mustJson(t *testing.T, bmfont *BitmapFont) string {
+	data, err := bmfont.ToJson()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
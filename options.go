@@ -1,5 +1,15 @@
 package fontcatalog
 
+type PageSizeMode int
+
+const (
+	PageSizeCrop PageSizeMode = iota
+	PageSizePowerOfTwo
+	PageSizeSquare
+	PageSizePowerOfTwoSquare
+	PageSizeFixed
+)
+
 type BitmapFontOptions struct {
 	Filename       string
 	FontSpacing    []int
@@ -7,11 +17,16 @@ type BitmapFontOptions struct {
 	TexturePadding []int
 	FieldType      string
 	AllowRotation  bool
+	Packer         PackerAlgorithm
 	PackerMethod   FreeRectChoiceHeuristic
 	Limit          int
+	PageSize       PageSizeMode
 	EdgeColoring   EdgeColoring
 	AngleThreshold float64
 	Seed           uint64
+	Workers        int
+	// Identifier is how charsets identify glyphs, by code point when unset.
+	Identifier GlyphIdentifierType
 }
 
 func DefaultBitmapFontOptions(filename string) BitmapFontOptions {
@@ -22,10 +37,14 @@ func DefaultBitmapFontOptions(filename string) BitmapFontOptions {
 		TexturePadding: []int{1, 1},
 		FieldType:      MOD_SDF,
 		AllowRotation:  false,
+		Packer:         PackerMaxRects,
 		PackerMethod:   RectBestShortSideFit,
-		Limit:          100,
+		Limit:          0,
+		PageSize:       PageSizeCrop,
 		EdgeColoring:   EdgeColoringInkTrap,
 		AngleThreshold: 3.0,
 		Seed:           6364136223846793005,
+		Workers:        1,
+		Identifier:     UNICODE_CODEPOINT,
 	}
 }
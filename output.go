@@ -0,0 +1,186 @@
+package fontcatalog
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"image"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/flywave/imaging"
+)
+
+type OutputWriter interface {
+	WriteFile(name string, data []byte) error
+}
+
+func writeImage(out OutputWriter, name string, img image.Image) error {
+	var buf bytes.Buffer
+	if err := imaging.Encode(&buf, img, imaging.PNG); err != nil {
+		return err
+	}
+	return out.WriteFile(name, buf.Bytes())
+}
+
+func checkOutputName(name string) error {
+	if !fs.ValidPath(name) || name == "." {
+		return fmt.Errorf("invalid output name %q", name)
+	}
+	return nil
+}
+
+type DirOutput struct {
+	root string
+}
+
+func NewDirOutput(root string) *DirOutput {
+	return &DirOutput{root: root}
+}
+
+func (o *DirOutput) WriteFile(name string, data []byte) error {
+	if err := checkOutputName(name); err != nil {
+		return err
+	}
+	p := filepath.Join(o.root, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(p), os.ModePerm); err != nil {
+		return err
+	}
+	return os.WriteFile(p, data, os.ModePerm)
+}
+
+type MemoryOutput struct {
+	mu    sync.Mutex
+	files map[string][]byte
+}
+
+func NewMemoryOutput() *MemoryOutput {
+	return &MemoryOutput{files: make(map[string][]byte)}
+}
+
+func (o *MemoryOutput) WriteFile(name string, data []byte) error {
+	if err := checkOutputName(name); err != nil {
+		return err
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.files[name] = append([]byte(nil), data...)
+	return nil
+}
+
+func (o *MemoryOutput) ReadFile(name string) ([]byte, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	data, ok := o.files[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrNotExist}
+	}
+	return append([]byte(nil), data...), nil
+}
+
+func (o *MemoryOutput) Names() []string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	names := make([]string, 0, len(o.files))
+	for name := range o.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+type ZipOutput struct {
+	mu sync.Mutex
+	w  *zip.Writer
+}
+
+func NewZipOutput(w io.Writer) *ZipOutput {
+	return &ZipOutput{w: zip.NewWriter(w)}
+}
+
+func (o *ZipOutput) WriteFile(name string, data []byte) error {
+	if err := checkOutputName(name); err != nil {
+		return err
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	method := zip.Deflate
+	if strings.HasSuffix(name, ".png") {
+		method = zip.Store
+	}
+	fw, err := o.w.CreateHeader(&zip.FileHeader{Name: name, Method: method})
+	if err != nil {
+		return err
+	}
+	_, err = fw.Write(data)
+	return err
+}
+
+func (o *ZipOutput) Close() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.w.Close()
+}
+
+type TarOutput struct {
+	mu   sync.Mutex
+	w    *tar.Writer
+	dirs map[string]bool
+}
+
+func NewTarOutput(w io.Writer) *TarOutput {
+	return &TarOutput{w: tar.NewWriter(w), dirs: make(map[string]bool)}
+}
+
+func (o *TarOutput) WriteFile(name string, data []byte) error {
+	if err := checkOutputName(name); err != nil {
+		return err
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if err := o.writeDir(path.Dir(name)); err != nil {
+		return err
+	}
+	hdr := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     0644,
+		Size:     int64(len(data)),
+		ModTime:  time.Unix(0, 0),
+	}
+	if err := o.w.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err := o.w.Write(data)
+	return err
+}
+
+func (o *TarOutput) writeDir(dir string) error {
+	if dir == "." || o.dirs[dir] {
+		return nil
+	}
+	if err := o.writeDir(path.Dir(dir)); err != nil {
+		return err
+	}
+	o.dirs[dir] = true
+	return o.w.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeDir,
+		Name:     dir + "/",
+		Mode:     0755,
+		ModTime:  time.Unix(0, 0),
+	})
+}
+
+func (o *TarOutput) Close() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.w.Close()
+}
@@ -0,0 +1,84 @@
+package fontcatalog
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestGenerateToMemoryOutput(t *testing.T) {
+	fcd, err := ReadFontCatalogDescription(strings.NewReader(`{"name":"Test","size":32,"distance":8,"type":"sdf","fontsDir":"./fonts","fonts":[{"name":"FiraGO_Map","blocks":["Basic Latin"]}]}`))
+	if err != nil {
+		t.FailNow()
+	}
+
+	opts := DefaultBitmapFontOptions("")
+	out := NewMemoryOutput()
+	if err := NewFontCatalogGenerater(fcd, &opts).GenerateTo(out); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{
+		"Test_FontCatalog.json",
+		"Test_Assets/FiraGO_Map/Basic_Latin.json",
+		"Test_Assets/FiraGO_Map/Basic_Latin.png",
+		"Test_Assets/Extra/Specials.json",
+		"Test_Assets/Extra/Specials.png",
+	} {
+		if _, err := out.ReadFile(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, _ := out.ReadFile("Test_FontCatalog.json")
+	catalog, err := ReadFontCatalog(bytes.NewReader(data))
+	if err != nil || len(catalog.Fonts) != 2 {
+		t.FailNow()
+	}
+}
+
+func TestArchiveOutputs(t *testing.T) {
+	var zbuf bytes.Buffer
+	zout := NewZipOutput(&zbuf)
+	if err := zout.WriteFile("a/b.json", []byte("{}")); err != nil {
+		t.FailNow()
+	}
+	if err := zout.Close(); err != nil {
+		t.FailNow()
+	}
+	zr, err := zip.NewReader(bytes.NewReader(zbuf.Bytes()), int64(zbuf.Len()))
+	if err != nil || len(zr.File) != 1 || zr.File[0].Name != "a/b.json" {
+		t.FailNow()
+	}
+
+	var tbuf bytes.Buffer
+	tout := NewTarOutput(&tbuf)
+	if err := tout.WriteFile("a/b.json", []byte("{}")); err != nil {
+		t.FailNow()
+	}
+	if err := tout.Close(); err != nil {
+		t.FailNow()
+	}
+	tr := tar.NewReader(&tbuf)
+	names := []string{}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.FailNow()
+		}
+		names = append(names, hdr.Name)
+	}
+	if strings.Join(names, ",") != "a/,a/b.json" {
+		t.FailNow()
+	}
+
+	if NewMemoryOutput().WriteFile("../escape", nil) == nil {
+		t.FailNow()
+	}
+}
@@ -0,0 +1,68 @@
+package fontcatalog
+
+type PackerAlgorithm int
+
+const (
+	PackerMaxRects PackerAlgorithm = iota
+	PackerSkyline
+	PackerGuillotine
+	// PackerTryAll packs the first batch of glyphs with every packer and
+	// uses the one needing the fewest pages, then the least page area, for
+	// that batch for the whole font. Later glyphs may pack better with
+	// another packer.
+	PackerTryAll
+)
+
+// BinPacker places rects into a single bin. Pack can be called repeatedly,
+// PlacedRects of the result holds every rect placed so far and NotPlacedRects
+// the ones of this call that did not fit.
+type BinPacker interface {
+	Pack(rects []RectNode) *MaxRectsBinResult
+	Occupancy() float32
+}
+
+// NewBinPacker creates a packer for algorithm. MaxRects uses heuristic as is,
+// Skyline uses the min waste rule for RectBestAreaFit and bottom left
+// otherwise, Guillotine picks free rects by heuristic and falls back to best
+// area fit for the rules it does not know.
+func NewBinPacker(algorithm PackerAlgorithm, heuristic FreeRectChoiceHeuristic, width, height int, paddingX, paddingY int, allowRotation bool) BinPacker {
+	switch algorithm {
+	case PackerSkyline:
+		level := SkylineBottomLeft
+		if heuristic == RectBestAreaFit {
+			level = SkylineMinWasteFit
+		}
+		return NewSkylineBinPacker(width, height, paddingX, paddingY, allowRotation, level)
+	case PackerGuillotine:
+		return NewGuillotineBinPacker(width, height, paddingX, paddingY, allowRotation, heuristic)
+	default:
+		return &maxRectsPacker{NewMaxRectsBinPacker(width, height, paddingX, paddingY, allowRotation), heuristic}
+	}
+}
+
+type maxRectsPacker struct {
+	*MaxRectsBinPacker
+	method FreeRectChoiceHeuristic
+}
+
+func (p *maxRectsPacker) Pack(rects []RectNode) *MaxRectsBinResult {
+	return p.MaxRectsBinPacker.Pack(rects, p.method)
+}
+
+type packerCandidate struct {
+	algorithm PackerAlgorithm
+	heuristic FreeRectChoiceHeuristic
+}
+
+var packerCandidates = []packerCandidate{
+	{PackerMaxRects, RectBestShortSideFit},
+	{PackerMaxRects, RectBestLongSideFit},
+	{PackerMaxRects, RectBestAreaFit},
+	{PackerMaxRects, RectBottomLeftRule},
+	{PackerMaxRects, RectContactPointRule},
+	{PackerSkyline, RectBottomLeftRule},
+	{PackerSkyline, RectBestAreaFit},
+	{PackerGuillotine, RectBestShortSideFit},
+	{PackerGuillotine, RectBestLongSideFit},
+	{PackerGuillotine, RectBestAreaFit},
+}
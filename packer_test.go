@@ -0,0 +1,42 @@
+package fontcatalog
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestBinPackers(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	input := make([]RectNode, 200)
+	for i := range input {
+		input[i] = NewRectNode(i, 4+rnd.Intn(28), 4+rnd.Intn(28))
+	}
+
+	for _, c := range packerCandidates {
+		for _, rotate := range []bool{false, true} {
+			rects := append([]RectNode(nil), input...)
+			res := NewBinPacker(c.algorithm, c.heuristic, 256, 256, 1, 1, rotate).Pack(rects)
+
+			if res.Method != c.heuristic {
+				t.Fatalf("packer %v: method %v", c, res.Method)
+			}
+			if len(res.PlacedRects)+len(res.NotPlacedRects) != len(input) || len(res.PlacedRects) == 0 {
+				t.Fatalf("packer %v: %d placed, %d not placed", c, len(res.PlacedRects), len(res.NotPlacedRects))
+			}
+			for i, a := range res.PlacedRects {
+				if a.X < 0 || a.Y < 0 || a.Right() > 256 || a.Bottom() > 256 {
+					t.Fatalf("packer %v: rect %d outside of the bin", c, a.Index)
+				}
+				src := input[a.Index]
+				if (!a.Rotated && (a.W != src.W+1 || a.H != src.H+1)) || (a.Rotated && (a.W != src.H+1 || a.H != src.W+1)) {
+					t.Fatalf("packer %v: rect %d has the wrong size", c, a.Index)
+				}
+				for _, b := range res.PlacedRects[i+1:] {
+					if a.Intersection(b.Rect).W > 0 && a.Intersection(b.Rect).H > 0 {
+						t.Fatalf("packer %v: rects %d and %d overlap", c, a.Index, b.Index)
+					}
+				}
+			}
+		}
+	}
+}
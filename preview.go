@@ -0,0 +1,141 @@
+package fontcatalog
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"sort"
+
+	"github.com/fogleman/gg"
+)
+
+// ContactSheetOptions sets the layout of BitmapFont.ContactSheet.
+type ContactSheetOptions struct {
+	// Scale multiplies the size of glyphs, zero means one.
+	Scale float64
+	// Columns is the number of glyphs of a row, zero means 16.
+	Columns int
+	// Sample is drawn below the glyphs with the kerning of the font, empty
+	// draws no sample.
+	Sample string
+}
+
+var (
+	previewBox      = color.RGBA{R: 0x30, G: 0x70, B: 0xe0, A: 0xff}
+	previewAdvance  = color.RGBA{R: 0xe0, G: 0x30, B: 0x30, A: 0xff}
+	previewBaseline = color.RGBA{R: 0x20, G: 0xa0, B: 0x40, A: 0xff}
+	previewGrid     = color.RGBA{R: 0xd0, G: 0xd0, B: 0xd0, A: 0xff}
+)
+
+// previewLabel is the height of the code point label of a cell.
+const previewLabel = 16
+
+// RenderPage decodes a page of the font, its glyphs are drawn in black on
+// white where they are in the atlas.
+func (ur *BitmapFont) RenderPage(page int) (*image.RGBA, error) {
+	sheet := ur.pageSheets[page]
+	if sheet == nil {
+		return nil, fmt.Errorf("render page: page %d is not loaded", page)
+	}
+	dst := image.NewRGBA(sheet.Bounds())
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	glyphs := []renderGlyph{}
+	for _, c := range ur.Chars {
+		if c.Page != page || c.Width == 0 || c.Height == 0 {
+			continue
+		}
+		rect := TextRect{X: float64(c.X), Y: float64(c.Y), W: float64(c.Width), H: float64(c.Height)}
+		glyphs = append(glyphs, renderGlyph{sheet: sheet, atlas: c.Bounds(), rect: rect, field: ur.DistanceField})
+	}
+	drawGlyphs(dst, glyphs, RenderOptions{})
+	return dst, nil
+}
+
+// ContactSheet draws every char of the font in a grid, under its code point,
+// or its glyph index when chars are not identified by code point. Each cell
+// shows the box of the char in blue, its origin and advance in red and the
+// baseline in green.
+func (ur *BitmapFont) ContactSheet(opts ContactSheetOptions) (*image.RGBA, error) {
+	scale := opts.Scale
+	if scale == 0 {
+		scale = 1
+	}
+	columns := opts.Columns
+	if columns <= 0 {
+		columns = 16
+	}
+	chars := append([]Charset(nil), ur.Chars...)
+	sort.Slice(chars, func(i, j int) bool { return chars[i].ID < chars[j].ID })
+
+	// cells hold the boxes and advances of every char around a common origin
+	left, right := 0, 0
+	bottom := ur.Common.LineHeight
+	for _, c := range chars {
+		left = Min(left, c.XOffset)
+		right = Max(right, Max(c.XAdvance, c.XOffset+c.Width))
+		bottom = Max(bottom, c.YOffset+c.Height)
+	}
+	const pad = 4
+	cellW := Max(int(math.Ceil(float64(right-left)*scale))+2*pad, 7*8)
+	cellH := int(math.Ceil(float64(bottom)*scale)) + 2*pad + previewLabel
+	rows := (len(chars) + columns - 1) / columns
+
+	sampleH := 0
+	if opts.Sample != "" {
+		sampleH = int(math.Ceil(float64(ur.Common.LineHeight)*scale)) + 2*pad
+	}
+	dc := gg.NewContext(Min(columns, Max(len(chars), 1))*cellW, rows*cellH+sampleH)
+	dc.SetColor(color.White)
+	dc.Clear()
+	dst := dc.Image().(*image.RGBA)
+
+	for i, c := range chars {
+		x0, y0 := float64(i%columns*cellW), float64(i/columns*cellH)
+		originX := x0 + pad - float64(left)*scale
+		top := y0 + previewLabel + pad
+
+		dc.SetColor(previewGrid)
+		dc.DrawRectangle(x0+0.5, y0+0.5, float64(cellW)-1, float64(cellH)-1)
+		dc.SetLineWidth(1)
+		dc.Stroke()
+		dc.SetColor(color.Black)
+		label := fmt.Sprintf("#%d", c.ID)
+		if ur.Info.Unicode {
+			label = fmt.Sprintf("U+%04X", c.ID)
+		}
+		dc.DrawString(label, x0+pad, y0+previewLabel-4)
+
+		if c.Width > 0 && c.Height > 0 {
+			sheet := ur.pageSheets[c.Page]
+			if sheet == nil {
+				return nil, fmt.Errorf("contact sheet: page %d is not loaded", c.Page)
+			}
+			rect := TextRect{X: float64(c.XOffset), Y: float64(c.YOffset), W: float64(c.Width), H: float64(c.Height)}
+			drawGlyphs(dst, []renderGlyph{{sheet: sheet, atlas: c.Bounds(), rect: rect, field: ur.DistanceField}}, RenderOptions{X: originX, Y: top, Scale: scale})
+
+			dc.SetColor(previewBox)
+			dc.DrawRectangle(originX+rect.X*scale, top+rect.Y*scale, rect.W*scale, rect.H*scale)
+			dc.Stroke()
+		}
+
+		baseline := top + float64(ur.Common.Base)*scale
+		dc.SetColor(previewBaseline)
+		dc.DrawLine(x0+1, baseline, x0+float64(cellW)-1, baseline)
+		dc.Stroke()
+		dc.SetColor(previewAdvance)
+		for _, x := range []float64{originX, originX + float64(c.XAdvance)*scale} {
+			dc.DrawLine(x, top, x, top+float64(bottom)*scale)
+		}
+		dc.Stroke()
+	}
+
+	if opts.Sample != "" {
+		err := ur.DrawText(dst, opts.Sample, RenderOptions{X: pad, Y: float64(rows*cellH + pad), Scale: scale})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return dst, nil
+}
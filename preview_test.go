@@ -0,0 +1,99 @@
+package fontcatalog
+
+import (
+	"image/color"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestContactSheet(t *testing.T) {
+	data, err := ioutil.ReadFile("./fonts/SignTextNarrow_Bold.ttf")
+	if err != nil {
+		t.FailNow()
+	}
+	font := NewBitmapFontGenerater(NewFontHolder(data), NewCharsetsASCII(), 32, 8, DefaultBitmapFontOptions("preview")).Generate()
+	if font == nil {
+		t.FailNow()
+	}
+
+	page, err := font.RenderPage(0)
+	if err != nil || page.Bounds() != font.GetPageSheet(0).Bounds() {
+		t.FailNow()
+	}
+	if _, err := font.RenderPage(1); err == nil {
+		t.FailNow()
+	}
+	c := font.Chars[len(font.Chars)-1]
+	if got := page.RGBAAt(c.X, c.Y); got != (color.RGBA{R: 255, G: 255, B: 255, A: 255}) {
+		t.Fatalf("%v", got)
+	}
+
+	sheet, err := font.ContactSheet(ContactSheetOptions{Columns: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := (len(font.Chars) + 9) / 10
+	if sheet.Bounds().Dx()%10 != 0 || sheet.Bounds().Dy()%rows != 0 {
+		t.Fatalf("%v", sheet.Bounds())
+	}
+
+	// the sample strip is drawn below the cells
+	sampled, err := font.ContactSheet(ContactSheetOptions{Columns: 10, Sample: "AV"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sampled.Bounds().Dx() != sheet.Bounds().Dx() || sampled.Bounds().Dy() <= sheet.Bounds().Dy() {
+		t.Fatalf("%v", sampled.Bounds())
+	}
+	dark := 0
+	for y := sheet.Bounds().Dy(); y < sampled.Bounds().Dy(); y++ {
+		for x := 0; x < sampled.Bounds().Dx(); x++ {
+			if sampled.RGBAAt(x, y).R < 128 {
+				dark++
+			}
+		}
+	}
+	if dark == 0 {
+		t.FailNow()
+	}
+}
+
+func TestContactSheetMultiPage(t *testing.T) {
+	data, err := ioutil.ReadFile("./fonts/SignTextNarrow_Bold.ttf")
+	if err != nil {
+		t.FailNow()
+	}
+	opts := DefaultBitmapFontOptions("preview")
+	opts.TextureSize = []int{128, 128}
+	font := NewBitmapFontGenerater(NewFontHolder(data), NewCharsetsASCII(), 32, 8, opts).Generate()
+	if font == nil || len(font.Pages) < 2 {
+		t.FailNow()
+	}
+
+	for _, format := range []BitmapFontFormat{BitmapFontJSON, BitmapFontText, BitmapFontXML, BitmapFontBinary} {
+		dir := t.TempDir()
+		if err := font.WriteFormat(NewDirOutput(dir), "", "preview", format); err != nil {
+			t.Fatal(err)
+		}
+		data, err := ioutil.ReadFile(dir + "/preview" + format.Ext())
+		if err != nil {
+			t.Fatal(err)
+		}
+		read, err := DecodeBitmapFont(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := read.LoadPages(os.DirFS(dir), ""); err != nil {
+			t.Fatalf("format %d: %v", format, err)
+		}
+		last := len(read.Pages) - 1
+		page, err := read.RenderPage(last)
+		if err != nil || page.Bounds() != font.GetPageSheet(last).Bounds() {
+			t.Fatalf("format %d: %v", format, err)
+		}
+		if _, err := read.ContactSheet(ContactSheetOptions{Sample: "AV"}); err != nil {
+			t.Fatalf("format %d: %v", format, err)
+		}
+	}
+}
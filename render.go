@@ -0,0 +1,367 @@
+package fontcatalog
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io/fs"
+	"math"
+	"path"
+)
+
+// RenderOptions sets where and how DrawText and TextLayouter.Draw composite
+// text. Widths and offsets are in pixels of the destination. The outline,
+// halo and shadow come from the distance field of the pages, so they reach
+// at most half the distance range of the font, scaled to the destination,
+// beyond the glyph edges.
+type RenderOptions struct {
+	// X and Y place the top left of the text, it is scaled and rotated
+	// around this point.
+	X, Y float64
+	// Scale multiplies the size of the text, zero means one.
+	Scale float64
+	// Rotation turns the text clockwise, in radians.
+	Rotation float64
+	// Color fills the glyphs, nil is black.
+	Color color.Color
+
+	OutlineWidth float64
+	OutlineColor color.Color
+	// HaloWidth is the width of a glow fading out around the outline.
+	HaloWidth float64
+	HaloColor color.Color
+
+	// ShadowX and ShadowY offset a copy of the outlined text drawn below it,
+	// ShadowBlur is the width of its soft edge.
+	ShadowX, ShadowY float64
+	ShadowBlur       float64
+	ShadowColor      color.Color
+}
+
+// renderGlyph is a glyph to draw, the area atlas of sheet is drawn at rect
+// of the text.
+type renderGlyph struct {
+	sheet image.Image
+	atlas image.Rectangle
+	rect  TextRect
+	field DistanceField
+}
+
+// LoadPages reads the page images of a bitmap font written in dir of fsys,
+// fonts returned by ReadBitmapFont have none.
+func (ur *BitmapFont) LoadPages(fsys fs.FS, dir string) error {
+	if ur.pageSheets == nil {
+		ur.pageSheets = make(map[int]image.Image)
+	}
+	for p, name := range ur.Pages {
+		if _, ok := ur.pageSheets[p]; ok {
+			continue
+		}
+		sheet, err := readPage(fsys, path.Join(dir, pageFile(name)))
+		if err != nil {
+			return err
+		}
+		ur.pageSheets[p] = sheet
+	}
+	return nil
+}
+
+func readPage(fsys fs.FS, name string) (image.Image, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return img, nil
+}
+
+// DrawText draws text with the glyphs and kerning of the font, lines break
+// at '\n'. The font must identify chars by code point and have its pages,
+// code points it has no char for are skipped.
+func (ur *BitmapFont) DrawText(dst draw.Image, text string, opts RenderOptions) error {
+	if !ur.Info.Unicode {
+		return fmt.Errorf("draw text: chars are not identified by code point")
+	}
+	chars := make(map[rune]*Charset, len(ur.Chars))
+	for i := range ur.Chars {
+		chars[rune(ur.Chars[i].ID)] = &ur.Chars[i]
+	}
+	kerning := make(map[CharPair]float64, len(ur.Kerning))
+	for _, k := range ur.Kerning {
+		kerning[CharPair{k.First, k.Second}] = k.Amount
+	}
+
+	glyphs := []renderGlyph{}
+	x, y := 0.0, 0.0
+	var prev *Charset
+	for _, r := range text {
+		if r == '\n' {
+			x, y, prev = 0, y+float64(ur.Common.LineHeight), nil
+			continue
+		}
+		c, ok := chars[r]
+		if !ok {
+			continue
+		}
+		if prev != nil {
+			x += kerning[CharPair{rune(prev.ID), r}]
+		}
+		if c.Width > 0 && c.Height > 0 {
+			sheet := ur.pageSheets[c.Page]
+			if sheet == nil {
+				return fmt.Errorf("draw text: page %d is not loaded", c.Page)
+			}
+			glyphs = append(glyphs, renderGlyph{
+				sheet: sheet,
+				atlas: c.Bounds(),
+				rect:  TextRect{X: x + float64(c.XOffset), Y: y + float64(c.YOffset), W: float64(c.Width), H: float64(c.Height)},
+				field: ur.DistanceField,
+			})
+		}
+		x += float64(c.XAdvance)
+		prev = c
+	}
+	drawGlyphs(dst, glyphs, opts)
+	return nil
+}
+
+// Draw draws a layout of l, the pages of its glyphs are read from the fsys of
+// the layouter and kept for the next draws.
+func (l *TextLayouter) Draw(dst draw.Image, layout *TextLayout, opts RenderOptions) error {
+	fonts := make(map[string]*layoutFont)
+	for _, f := range l.fonts {
+		for _, page := range f.font.Pages {
+			fonts[path.Join(path.Dir(f.asset), pageFile(page))] = f
+		}
+	}
+
+	glyphs := make([]renderGlyph, 0, len(layout.Glyphs))
+	for _, g := range layout.Glyphs {
+		f, ok := fonts[g.Texture]
+		if !ok {
+			return fmt.Errorf("draw: %s is not a page of the layouter", g.Texture)
+		}
+		if f.font.pageSheets == nil {
+			f.font.pageSheets = make(map[int]image.Image)
+		}
+		sheet, ok := f.font.pageSheets[g.Page]
+		if !ok {
+			var err error
+			if sheet, err = readPage(l.fsys, g.Texture); err != nil {
+				return err
+			}
+			f.font.pageSheets[g.Page] = sheet
+		}
+		glyphs = append(glyphs, renderGlyph{sheet: sheet, atlas: g.Atlas, rect: g.Rect, field: f.font.DistanceField})
+	}
+	drawGlyphs(dst, glyphs, opts)
+	return nil
+}
+
+// textTransform maps text coordinates to the destination.
+type textTransform struct {
+	x, y, scale, cos, sin float64
+}
+
+func newTextTransform(opts RenderOptions) textTransform {
+	scale := opts.Scale
+	if scale == 0 {
+		scale = 1
+	}
+	return textTransform{x: opts.X, y: opts.Y, scale: scale, cos: math.Cos(opts.Rotation), sin: math.Sin(opts.Rotation)}
+}
+
+func (t textTransform) apply(x, y float64) (float64, float64) {
+	x, y = x*t.scale, y*t.scale
+	return t.x + x*t.cos - y*t.sin, t.y + x*t.sin + y*t.cos
+}
+
+func (t textTransform) invert(x, y float64) (float64, float64) {
+	x, y = x-t.x, y-t.y
+	return (x*t.cos + y*t.sin) / t.scale, (-x*t.sin + y*t.cos) / t.scale
+}
+
+// bounds is the destination area covering r grown by margin.
+func (t textTransform) bounds(r TextRect, margin float64) image.Rectangle {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, p := range [4][2]float64{{r.X, r.Y}, {r.X + r.W, r.Y}, {r.X, r.Y + r.H}, {r.X + r.W, r.Y + r.H}} {
+		x, y := t.apply(p[0], p[1])
+		minX, minY = math.Min(minX, x), math.Min(minY, y)
+		maxX, maxY = math.Max(maxX, x), math.Max(maxY, y)
+	}
+	return image.Rect(int(math.Floor(minX-margin)), int(math.Floor(minY-margin)), int(math.Ceil(maxX+margin)), int(math.Ceil(maxY+margin)))
+}
+
+// text layers, composited from the shadow up to the fill
+const (
+	layerShadow = iota
+	layerHalo
+	layerOutline
+	layerFill
+	layerCount
+)
+
+// drawGlyphs renders the coverage of every layer of the glyphs into masks,
+// overlapping glyphs keep the highest coverage, then composites the masks
+// over dst with their colors.
+func drawGlyphs(dst draw.Image, glyphs []renderGlyph, opts RenderOptions) {
+	t := newTextTransform(opts)
+	outline := math.Max(opts.OutlineWidth, 0)
+	halo := math.Max(opts.HaloWidth, 0)
+	blur := math.Max(opts.ShadowBlur, 1)
+	shadow := opts.ShadowColor != nil && (opts.ShadowX != 0 || opts.ShadowY != 0 || opts.ShadowBlur > 0)
+	colors := [layerCount]color.Color{opts.ShadowColor, opts.HaloColor, opts.OutlineColor, opts.Color}
+	if colors[layerFill] == nil {
+		colors[layerFill] = color.Black
+	}
+	used := [layerCount]bool{shadow, halo > 0 && opts.HaloColor != nil, outline > 0 && opts.OutlineColor != nil, true}
+
+	margin := outline + halo + blur + 1
+	shift := image.Pt(int(math.Floor(opts.ShadowX)), int(math.Floor(opts.ShadowY)))
+	area := image.Rectangle{}
+	for _, g := range glyphs {
+		area = area.Union(t.bounds(g.rect, margin))
+		if shadow {
+			area = area.Union(t.bounds(g.rect, margin).Add(shift).Inset(-1))
+		}
+	}
+	area = area.Intersect(dst.Bounds())
+	if area.Empty() {
+		return
+	}
+
+	var masks [layerCount]*image.Alpha
+	for i := range masks {
+		if used[i] {
+			masks[i] = image.NewAlpha(area)
+		}
+	}
+	for _, g := range glyphs {
+		// destination pixels per atlas pixel
+		scale := t.scale * g.rect.W / float64(g.atlas.Dx())
+		distanceRange := g.field.DistanceRange
+		if distanceRange <= 0 || g.field.FieldType == MOD_HARD_MASK || g.field.FieldType == MOD_SOFT_MASK {
+			distanceRange = 1
+		}
+		// distance returns the distance in destination pixels to the edge
+		// of the glyph at a destination point, positive inside
+		distance := func(x, y float64) float64 {
+			tx, ty := t.invert(x, y)
+			ax := float64(g.atlas.Min.X) + (tx-g.rect.X)*float64(g.atlas.Dx())/g.rect.W
+			ay := float64(g.atlas.Min.Y) + (ty-g.rect.Y)*float64(g.atlas.Dy())/g.rect.H
+			return (sampleField(g.sheet, g.atlas, g.field.FieldType, ax, ay) - 0.5) * distanceRange * scale
+		}
+
+		bounds := t.bounds(g.rect, margin).Intersect(area)
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				px, py := float64(x)+0.5, float64(y)+0.5
+				d := distance(px, py)
+				var coverage [layerCount]float64
+				coverage[layerFill] = ramp(d, 1)
+				coverage[layerOutline] = ramp(d+outline, 1)
+				if halo > 0 {
+					coverage[layerHalo] = smoothstep((d + outline + halo) / halo)
+				}
+				for i, c := range coverage {
+					if masks[i] != nil && c > 0 {
+						setMax(masks[i], x, y, c)
+					}
+				}
+			}
+		}
+
+		if shadow {
+			bounds := t.bounds(g.rect, margin).Add(shift).Inset(-1).Intersect(area)
+			for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+				for x := bounds.Min.X; x < bounds.Max.X; x++ {
+					d := distance(float64(x)+0.5-opts.ShadowX, float64(y)+0.5-opts.ShadowY)
+					if c := ramp(d+outline, blur); c > 0 {
+						setMax(masks[layerShadow], x, y, c)
+					}
+				}
+			}
+		}
+	}
+
+	for i, mask := range masks {
+		if mask != nil {
+			draw.DrawMask(dst, area, image.NewUniform(colors[i]), image.Point{}, mask, area.Min, draw.Over)
+		}
+	}
+}
+
+// ramp is the coverage of a pixel at distance d inside an edge blurred over
+// width pixels.
+func ramp(d, width float64) float64 {
+	return math.Max(0, math.Min(1, d/width+0.5))
+}
+
+func smoothstep(x float64) float64 {
+	x = math.Max(0, math.Min(1, x))
+	return x * x * (3 - 2*x)
+}
+
+func setMax(mask *image.Alpha, x, y int, coverage float64) {
+	i := mask.PixOffset(x, y)
+	if a := uint8(math.Round(coverage * 255)); a > mask.Pix[i] {
+		mask.Pix[i] = a
+	}
+}
+
+// sampleField interpolates the distance field of sheet at x, y of the
+// atlas area, pixel centers are at half coordinates. Values are in [0, 1]
+// with the edge at 0.5. Multi-channel fields take the median of red, green
+// and blue.
+func sampleField(sheet image.Image, atlas image.Rectangle, fieldType string, x, y float64) float64 {
+	x = math.Max(float64(atlas.Min.X)+0.5, math.Min(float64(atlas.Max.X)-0.5, x)) - 0.5
+	y = math.Max(float64(atlas.Min.Y)+0.5, math.Min(float64(atlas.Max.Y)-0.5, y)) - 0.5
+	x0, y0 := int(math.Floor(x)), int(math.Floor(y))
+	fx, fy := x-float64(x0), y-float64(y0)
+	x1, y1 := x0+1, y0+1
+	if x1 >= atlas.Max.X {
+		x1 = x0
+	}
+	if y1 >= atlas.Max.Y {
+		y1 = y0
+	}
+
+	var v [3]float64
+	for i, p := range [4]image.Point{{x0, y0}, {x1, y0}, {x0, y1}, {x1, y1}} {
+		w := [4]float64{(1 - fx) * (1 - fy), fx * (1 - fy), (1 - fx) * fy, fx * fy}[i]
+		r, g, b := fieldPixel(sheet, p.X, p.Y)
+		v[0] += w * r
+		v[1] += w * g
+		v[2] += w * b
+	}
+	if fieldType == MOD_MSDF || fieldType == MOD_MTSDF {
+		return math.Max(math.Min(v[0], v[1]), math.Min(math.Max(v[0], v[1]), v[2]))
+	}
+	return v[0]
+}
+
+// fieldPixel returns the raw channels of a page pixel, single channel fields
+// are drawn into every channel.
+func fieldPixel(sheet image.Image, x, y int) (float64, float64, float64) {
+	switch img := sheet.(type) {
+	case *image.RGBA:
+		p := img.Pix[img.PixOffset(x, y):]
+		return float64(p[0]) / 255, float64(p[1]) / 255, float64(p[2]) / 255
+	case *image.NRGBA:
+		p := img.Pix[img.PixOffset(x, y):]
+		return float64(p[0]) / 255, float64(p[1]) / 255, float64(p[2]) / 255
+	case *image.Gray:
+		v := float64(img.GrayAt(x, y).Y) / 255
+		return v, v, v
+	default:
+		c := color.NRGBAModel.Convert(sheet.At(x, y)).(color.NRGBA)
+		return float64(c.R) / 255, float64(c.G) / 255, float64(c.B) / 255
+	}
+}
@@ -0,0 +1,101 @@
+package fontcatalog
+
+import (
+	"image"
+	"image/color"
+	"io/ioutil"
+	"math"
+	"os"
+	"testing"
+)
+
+func TestDrawText(t *testing.T) {
+	data, err := ioutil.ReadFile("./fonts/SignTextNarrow_Bold.ttf")
+	if err != nil {
+		t.FailNow()
+	}
+	holder := NewFontHolder(data)
+
+	red := color.RGBA{R: 255, A: 255}
+	for _, fieldType := range []string{MOD_SDF, MOD_MSDF} {
+		opts := DefaultBitmapFontOptions("render")
+		opts.FieldType = fieldType
+		font := NewBitmapFontGenerater(holder, NewCharsetsASCII(), 32, 8, opts).Generate()
+		if font == nil {
+			t.FailNow()
+		}
+		var c Charset
+		for _, c = range font.Chars {
+			if c.ID == 'I' {
+				break
+			}
+		}
+		cx, cy := float64(c.XOffset)+float64(c.Width)/2, float64(c.YOffset)+float64(c.Height)/2
+
+		// the middle of the stem is filled, the outside only outlined
+		dst := image.NewRGBA(image.Rect(0, 0, 200, 200))
+		err := font.DrawText(dst, "I", RenderOptions{X: 10, Y: 10, Scale: 2, Color: red, OutlineWidth: 3, OutlineColor: color.Black})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := dst.RGBAAt(int(10+2*cx), int(10+2*cy)); got != red {
+			t.Fatalf("%s: fill %v", fieldType, got)
+		}
+		if got := dst.RGBAAt(int(10+2*cx), int(10+2*float64(c.YOffset))); got.A != 0 {
+			t.Fatalf("%s: %v above the glyph", fieldType, got)
+		}
+		outlined := false
+		for x := int(10 + 2*cx); x < 200 && !outlined; x++ {
+			outlined = dst.RGBAAt(x, int(10+2*cy)) == color.RGBA{A: 255}
+		}
+		if !outlined {
+			t.Fatalf("%s: no outline", fieldType)
+		}
+
+		// a quarter turn puts the stem below the origin, the shadow follows
+		dst = image.NewRGBA(image.Rect(0, 0, 200, 200))
+		err = font.DrawText(dst, "I", RenderOptions{X: 100, Y: 10, Rotation: math.Pi / 2, Color: red, ShadowX: 30, ShadowColor: color.Black})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := dst.RGBAAt(int(100-cy), int(10+cx)); got != red {
+			t.Fatalf("%s: rotated fill %v", fieldType, got)
+		}
+		if got := dst.RGBAAt(int(130-cy), int(10+cx)); got != (color.RGBA{A: 255}) {
+			t.Fatalf("%s: shadow %v", fieldType, got)
+		}
+	}
+
+	// pages read back from disk draw the same
+	opts := DefaultBitmapFontOptions("render")
+	font := NewBitmapFontGenerater(holder, NewCharsetsASCII(), 32, 8, opts).Generate()
+	dir := t.TempDir()
+	if err := font.Write(NewDirOutput(dir), "", "render"); err != nil {
+		t.Fatal(err)
+	}
+	data, err = ioutil.ReadFile(dir + "/render.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadBitmapFont(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := read.DrawText(image.NewRGBA(image.Rect(0, 0, 10, 10)), "A", RenderOptions{}); err == nil {
+		t.Fatal("drawn without pages")
+	}
+	if err := read.LoadPages(os.DirFS(dir), ""); err != nil {
+		t.Fatal(err)
+	}
+	want := image.NewRGBA(image.Rect(0, 0, 300, 60))
+	got := image.NewRGBA(want.Rect)
+	ropts := RenderOptions{X: 5, Y: 5, Scale: 1.5, Color: red, HaloWidth: 2, HaloColor: color.White}
+	if font.DrawText(want, "Hello AV", ropts) != nil || read.DrawText(got, "Hello AV", ropts) != nil {
+		t.FailNow()
+	}
+	for i := range want.Pix {
+		if d := int(want.Pix[i]) - int(got.Pix[i]); d < -1 || d > 1 {
+			t.Fatalf("pixel %d: %d != %d", i/4, got.Pix[i], want.Pix[i])
+		}
+	}
+}
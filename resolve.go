@@ -0,0 +1,125 @@
+package fontcatalog
+
+import (
+	"sort"
+	"strings"
+)
+
+// ReplacementFontName is the font every catalog ends with, it only has the
+// replacement character.
+const ReplacementFontName = "Extra"
+
+// Font returns the font called name.
+func (ur *FontCatalog) Font(name string) *Font {
+	for i := range ur.Fonts {
+		if ur.Fonts[i].Name == name {
+			return &ur.Fonts[i]
+		}
+	}
+	return nil
+}
+
+// Style returns the font file name of a style, falling back to the closest
+// style the font has.
+func (f *Font) Style(bold, italic bool) string {
+	if bold && italic && f.BoldItalic != nil {
+		return *f.BoldItalic
+	}
+	if bold && f.Bold != nil {
+		return *f.Bold
+	}
+	if italic && f.Italic != nil {
+		return *f.Italic
+	}
+	return f.Name
+}
+
+// Resolve returns the font rendering r and the font file of the requested
+// style. The fonts of the block holding r are tried in the declared order
+// and the first one whose charset has r wins. Code points no font covers go
+// to the replacement font, nil is only returned when the catalog has none.
+func (ur *FontCatalog) Resolve(r rune, bold, italic bool) (*Font, string) {
+	for i := range ur.SupportedBlocks {
+		block := &ur.SupportedBlocks[i]
+		if int(r) < block.Min || int(r) > block.Max {
+			continue
+		}
+		if font := ur.covering(block.Fonts, r); font != nil {
+			return font, font.Style(bold, italic)
+		}
+	}
+	return ur.replacement(bold, italic)
+}
+
+// ResolveStack resolves r like a Mapbox GL fontstack, the first font of stack
+// whose charset has r wins, whatever blocks the catalog lists it for.
+func (ur *FontCatalog) ResolveStack(stack []string, r rune, bold, italic bool) (*Font, string) {
+	if font := ur.covering(stack, r); font != nil {
+		return font, font.Style(bold, italic)
+	}
+	return ur.replacement(bold, italic)
+}
+
+func (ur *FontCatalog) covering(names []string, r rune) *Font {
+	for _, name := range names {
+		if font, ok := ur.lookup(name, r); ok {
+			return font
+		}
+	}
+	return nil
+}
+
+func (ur *FontCatalog) replacement(bold, italic bool) (*Font, string) {
+	font, _ := ur.lookup(ReplacementFontName, 0)
+	if font == nil {
+		return nil, ""
+	}
+	return font, font.Style(bold, italic)
+}
+
+// indexedFont is the position of a font in the catalog and its charset as
+// sorted ranges of code points.
+type indexedFont struct {
+	pos    int
+	ranges [][2]rune
+}
+
+func newIndexedFont(pos int, charset string) indexedFont {
+	runes := []rune(charset)
+	sort.Slice(runes, func(i, j int) bool { return runes[i] < runes[j] })
+	f := indexedFont{pos: pos}
+	for _, r := range runes {
+		if n := len(f.ranges); n > 0 && r <= f.ranges[n-1][1]+1 {
+			if r > f.ranges[n-1][1] {
+				f.ranges[n-1][1] = r
+			}
+			continue
+		}
+		f.ranges = append(f.ranges, [2]rune{r, r})
+	}
+	return f
+}
+
+func (f indexedFont) covers(r rune) bool {
+	i := sort.Search(len(f.ranges), func(i int) bool { return f.ranges[i][1] >= r })
+	return i < len(f.ranges) && f.ranges[i][0] <= r
+}
+
+// lookup returns the font called name and whether its charset has r. The
+// fonts are indexed on the first lookup, fonts added or moved since are
+// looked up without the index.
+func (ur *FontCatalog) lookup(name string, r rune) (*Font, bool) {
+	ur.indexOnce.Do(func() {
+		ur.index = make(map[string]indexedFont, len(ur.Fonts))
+		for i := range ur.Fonts {
+			if _, ok := ur.index[ur.Fonts[i].Name]; !ok {
+				ur.index[ur.Fonts[i].Name] = newIndexedFont(i, ur.Fonts[i].Charset)
+			}
+		}
+	})
+	if f, ok := ur.index[name]; ok && f.pos < len(ur.Fonts) && ur.Fonts[f.pos].Name == name {
+		return &ur.Fonts[f.pos], f.covers(r)
+	}
+	font := ur.Font(name)
+	return font, font != nil && strings.ContainsRune(font.Charset, r)
+}
@@ -0,0 +1,101 @@
+package fontcatalog
+
+// #include <stdlib.h>
+// #include "fontcatalog_lib.h"
+// #cgo CFLAGS: -I ./lib
+// #cgo linux CXXFLAGS: -I ./lib -std=c++14
+// #cgo darwin CXXFLAGS: -I ./lib  -std=gnu++14
+import "C"
+import (
+	"errors"
+	"reflect"
+	"unsafe"
+)
+
+type Direction uint32
+
+const (
+	DirectionInvalid Direction = 0
+	DirectionLTR     Direction = 4
+	DirectionRTL     Direction = 5
+	DirectionTTB     Direction = 6
+	DirectionBTT     Direction = 7
+)
+
+type ShapedGlyph struct {
+	Index    GlyphIndex
+	Cluster  int
+	XAdvance float64
+	YAdvance float64
+	XOffset  float64
+	YOffset  float64
+}
+
+// Shape runs HarfBuzz over text and returns the positioned glyphs. Clusters
+// are byte offsets into text, advances and offsets are scaled the same way
+// FontGeometry scales glyphs when loaded with the same fontScale. Empty script
+// (ISO 15924, e.g. "Arab") or language (BCP 47) and DirectionInvalid are
+// guessed from the text.
+func Shape(holder *FontHolder, text string, script, language string, direction Direction, fontScale float64) ([]ShapedGlyph, error) {
+	if holder == nil || holder.m == nil {
+		return nil, errors.New("shape: invalid font holder")
+	}
+	if len(text) == 0 {
+		return []ShapedGlyph{}, nil
+	}
+
+	ctext := C.CString(text)
+	defer C.free(unsafe.Pointer(ctext))
+	cscript := C.CString(script)
+	defer C.free(unsafe.Pointer(cscript))
+	clanguage := C.CString(language)
+	defer C.free(unsafe.Pointer(clanguage))
+
+	var si C.size_t
+	cglyphs := C.fc_font_holder_shape(holder.m, C.double(fontScale), ctext, C.int(len(text)), cscript, clanguage, C.int(direction), &si)
+	if cglyphs == nil {
+		return nil, errors.New("shape: font has no usable face")
+	}
+	defer C.free(unsafe.Pointer(cglyphs))
+
+	var dSlice []C.struct__fc_shaped_glyph_t
+	dHeader := (*reflect.SliceHeader)((unsafe.Pointer(&dSlice)))
+	dHeader.Cap = int(si)
+	dHeader.Len = int(si)
+	dHeader.Data = uintptr(unsafe.Pointer(cglyphs))
+
+	ret := make([]ShapedGlyph, int(si))
+	for i := range dSlice {
+		ret[i] = ShapedGlyph{
+			Index:    GlyphIndex(dSlice[i].index),
+			Cluster:  int(dSlice[i].cluster),
+			XAdvance: float64(dSlice[i].xAdvance),
+			YAdvance: float64(dSlice[i].yAdvance),
+			XOffset:  float64(dSlice[i].xOffset),
+			YOffset:  float64(dSlice[i].yOffset),
+		}
+	}
+	return ret, nil
+}
+
+// ShapeVisual shapes text with mixed directions. Its embedding levels are
+// resolved with Bidi and every run is shaped in its own direction, glyphs are
+// returned in visual order with each paragraph after the previous one.
+// DirectionInvalid takes the direction of paragraphs from their text.
+func ShapeVisual(holder *FontHolder, text string, script, language string, direction Direction, fontScale float64) ([]ShapedGlyph, error) {
+	bidi := NewBidi(text, direction)
+	ret := []ShapedGlyph{}
+	for _, p := range bidi.paras {
+		for _, run := range bidi.Runs(bidi.offsets[p.start], bidi.offsets[p.end]) {
+			glyphs, err := Shape(holder, text[run.Start:run.End], script, language, run.Direction(), fontScale)
+			if err != nil {
+				return nil, err
+			}
+			for _, g := range glyphs {
+				g.Cluster += run.Start
+				ret = append(ret, g)
+			}
+		}
+	}
+	return ret, nil
+}
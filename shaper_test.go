@@ -0,0 +1,50 @@
+package fontcatalog
+
+import (
+	"io/ioutil"
+	"math"
+	"testing"
+)
+
+func TestShape(t *testing.T) {
+	data, err := ioutil.ReadFile("./fonts/FiraGO_Map.ttf")
+	if err != nil {
+		t.FailNow()
+	}
+	holder := NewFontHolder(data)
+
+	glyphs, err := Shape(holder, "مرحبا", "Arab", "ar", DirectionRTL, 32)
+	if err != nil || len(glyphs) == 0 {
+		t.FailNow()
+	}
+	if glyphs[0].Cluster < glyphs[len(glyphs)-1].Cluster {
+		t.FailNow()
+	}
+
+	// runs of both directions are in visual order
+	glyphs, err = ShapeVisual(holder, "ab مرحبا", "", "", DirectionLTR, 32)
+	if err != nil || len(glyphs) < 4 || glyphs[0].Cluster != 0 || glyphs[1].Cluster != 1 {
+		t.FailNow()
+	}
+	if last := glyphs[len(glyphs)-1]; last.Cluster != 3 {
+		t.FailNow()
+	}
+
+	glyphs, err = Shape(holder, "A", "", "", DirectionInvalid, 32)
+	if err != nil || len(glyphs) != 1 {
+		t.FailNow()
+	}
+
+	cs := NewCharsets()
+	cs.Add('A')
+	fgeom := NewFontGeometryWithGlyphs(NewGlyphGeometryList())
+	fgeom.LoadFromCharset(holder, 32, cs)
+	glyph := fgeom.GetGlyphFromUnicode('A')
+
+	if GlyphIndex(glyph.GetIndex()) != glyphs[0].Index {
+		t.FailNow()
+	}
+	if math.Abs(glyph.GetAdvance()-glyphs[0].XAdvance) > 1e-6 {
+		t.FailNow()
+	}
+}
@@ -0,0 +1,237 @@
+package fontcatalog
+
+import (
+	"math"
+)
+
+type SkylineLevelChoiceHeuristic int
+
+const (
+	SkylineBottomLeft SkylineLevelChoiceHeuristic = iota
+	SkylineMinWasteFit
+)
+
+// heuristic is the free rect rule NewBinPacker maps to the level rule.
+func (h SkylineLevelChoiceHeuristic) heuristic() FreeRectChoiceHeuristic {
+	if h == SkylineMinWasteFit {
+		return RectBestAreaFit
+	}
+	return RectBottomLeftRule
+}
+
+type skylineNode struct {
+	x, y, width int
+}
+
+type SkylineBinPacker struct {
+	binWidth, binHeight int
+	paddingX, paddingY  int
+	allowRotation       bool
+	method              SkylineLevelChoiceHeuristic
+	usedRectangles      []RectNode
+	skyLine             []skylineNode
+	usedSurfaceArea     int
+}
+
+func NewSkylineBinPacker(width, height int, paddingX, paddingY int, allowRotation bool, method SkylineLevelChoiceHeuristic) *SkylineBinPacker {
+	return &SkylineBinPacker{
+		binWidth:       width,
+		binHeight:      height,
+		paddingX:       paddingX,
+		paddingY:       paddingY,
+		allowRotation:  allowRotation,
+		method:         method,
+		usedRectangles: make([]RectNode, 0),
+		skyLine:        []skylineNode{{0, 0, width}},
+	}
+}
+
+func (sb *SkylineBinPacker) Pack(inputRects []RectNode) *MaxRectsBinResult {
+	rects := inputRects
+	for len(rects) > 0 {
+		bestRectIndex := -1
+		bestSkylineIndex := -1
+		var bestNode RectNode
+		bestScore1 := math.MaxInt32
+		bestScore2 := math.MaxInt32
+
+		for i := 0; i < len(rects); i++ {
+			var score1, score2, index int
+			newNode := sb.scoreRect(rects[i], &score1, &score2, &index)
+			if newNode.H != 0 && (score1 < bestScore1 || (score1 == bestScore1 && score2 < bestScore2)) {
+				bestScore1 = score1
+				bestScore2 = score2
+				bestNode = newNode
+				bestNode.Index = rects[i].Index
+				bestRectIndex = i
+				bestSkylineIndex = index
+			}
+		}
+
+		if bestRectIndex == -1 {
+			break
+		}
+
+		sb.addSkylineLevel(bestSkylineIndex, bestNode)
+		sb.usedSurfaceArea += bestNode.W * bestNode.H
+		sb.usedRectangles = append(sb.usedRectangles, bestNode)
+		rects = append(rects[:bestRectIndex], rects[bestRectIndex+1:]...)
+	}
+
+	result := &MaxRectsBinResult{
+		PlacedRects:    sb.usedRectangles,
+		NotPlacedRects: rects,
+		Method:         sb.method.heuristic(),
+	}
+	for i := 0; i < len(sb.usedRectangles); i++ {
+		rect := sb.usedRectangles[i]
+		result.Width = Max(result.Width, rect.Right())
+		result.Height = Max(result.Height, rect.Bottom())
+	}
+	return result
+}
+
+func (sb *SkylineBinPacker) Occupancy() float32 {
+	return float32(sb.usedSurfaceArea) / float32(sb.binWidth*sb.binHeight)
+}
+
+func (sb *SkylineBinPacker) scoreRect(rect RectNode, score1, score2, index *int) RectNode {
+	width := rect.W + sb.paddingX
+	height := rect.H + sb.paddingY
+
+	var newNode RectNode
+	switch sb.method {
+	case SkylineMinWasteFit:
+		newNode = sb.findPositionForNewNodeMinWaste(width, height, score2, score1, index)
+	default:
+		newNode = sb.findPositionForNewNodeBottomLeft(width, height, score1, score2, index)
+	}
+	return newNode
+}
+
+// rectangleFits reports whether a width x height rect fits with its left edge
+// at the skyline node index, and the y it would rest at.
+func (sb *SkylineBinPacker) rectangleFits(index, width, height int, y *int) bool {
+	x := sb.skyLine[index].x
+	if x+width > sb.binWidth {
+		return false
+	}
+	widthLeft := width
+	i := index
+	*y = sb.skyLine[index].y
+	for widthLeft > 0 {
+		*y = Max(*y, sb.skyLine[i].y)
+		if *y+height > sb.binHeight {
+			return false
+		}
+		widthLeft -= sb.skyLine[i].width
+		i++
+	}
+	return true
+}
+
+func (sb *SkylineBinPacker) rectangleFitsWaste(index, width, height int, y, wastedArea *int) bool {
+	if !sb.rectangleFits(index, width, height, y) {
+		return false
+	}
+	*wastedArea = 0
+	rectLeft := sb.skyLine[index].x
+	rectRight := rectLeft + width
+	for i := index; i < len(sb.skyLine) && sb.skyLine[i].x < rectRight; i++ {
+		leftSide := sb.skyLine[i].x
+		rightSide := Min(rectRight, leftSide+sb.skyLine[i].width)
+		*wastedArea += (rightSide - leftSide) * (*y - sb.skyLine[i].y)
+	}
+	return true
+}
+
+func (sb *SkylineBinPacker) findPositionForNewNodeBottomLeft(width, height int, bestHeight, bestWidth, bestIndex *int) RectNode {
+	bestNode := RectNode{}
+	*bestHeight = math.MaxInt32
+	*bestWidth = math.MaxInt32
+	*bestIndex = -1
+
+	for i := 0; i < len(sb.skyLine); i++ {
+		var y int
+		if sb.rectangleFits(i, width, height, &y) {
+			if y+height < *bestHeight || (y+height == *bestHeight && sb.skyLine[i].width < *bestWidth) {
+				*bestHeight = y + height
+				*bestIndex = i
+				*bestWidth = sb.skyLine[i].width
+				bestNode = RectNode{Rect: Rect{X: sb.skyLine[i].x, Y: y, W: width, H: height}}
+			}
+		}
+		if sb.allowRotation && sb.rectangleFits(i, height, width, &y) {
+			if y+width < *bestHeight || (y+width == *bestHeight && sb.skyLine[i].width < *bestWidth) {
+				*bestHeight = y + width
+				*bestIndex = i
+				*bestWidth = sb.skyLine[i].width
+				bestNode = RectNode{Rect: Rect{X: sb.skyLine[i].x, Y: y, W: height, H: width}, Rotated: true}
+			}
+		}
+	}
+	return bestNode
+}
+
+func (sb *SkylineBinPacker) findPositionForNewNodeMinWaste(width, height int, bestHeight, bestWastedArea, bestIndex *int) RectNode {
+	bestNode := RectNode{}
+	*bestHeight = math.MaxInt32
+	*bestWastedArea = math.MaxInt32
+	*bestIndex = -1
+
+	for i := 0; i < len(sb.skyLine); i++ {
+		var y, wastedArea int
+		if sb.rectangleFitsWaste(i, width, height, &y, &wastedArea) {
+			if wastedArea < *bestWastedArea || (wastedArea == *bestWastedArea && y+height < *bestHeight) {
+				*bestHeight = y + height
+				*bestWastedArea = wastedArea
+				*bestIndex = i
+				bestNode = RectNode{Rect: Rect{X: sb.skyLine[i].x, Y: y, W: width, H: height}}
+			}
+		}
+		if sb.allowRotation && sb.rectangleFitsWaste(i, height, width, &y, &wastedArea) {
+			if wastedArea < *bestWastedArea || (wastedArea == *bestWastedArea && y+width < *bestHeight) {
+				*bestHeight = y + width
+				*bestWastedArea = wastedArea
+				*bestIndex = i
+				bestNode = RectNode{Rect: Rect{X: sb.skyLine[i].x, Y: y, W: height, H: width}, Rotated: true}
+			}
+		}
+	}
+	return bestNode
+}
+
+func (sb *SkylineBinPacker) addSkylineLevel(index int, rect RectNode) {
+	newNode := skylineNode{x: rect.X, y: rect.Bottom(), width: rect.W}
+	sb.skyLine = append(sb.skyLine, skylineNode{})
+	copy(sb.skyLine[index+1:], sb.skyLine[index:])
+	sb.skyLine[index] = newNode
+
+	for i := index + 1; i < len(sb.skyLine); i++ {
+		prev := sb.skyLine[i-1]
+		if sb.skyLine[i].x < prev.x+prev.width {
+			shrink := prev.x + prev.width - sb.skyLine[i].x
+			sb.skyLine[i].x += shrink
+			sb.skyLine[i].width -= shrink
+			if sb.skyLine[i].width <= 0 {
+				sb.skyLine = append(sb.skyLine[:i], sb.skyLine[i+1:]...)
+				i--
+			} else {
+				break
+			}
+		} else {
+			break
+		}
+	}
+	sb.mergeSkylines()
+}
+
+func (sb *SkylineBinPacker) mergeSkylines() {
+	for i := 0; i < len(sb.skyLine)-1; i++ {
+		if sb.skyLine[i].y == sb.skyLine[i+1].y {
+			sb.skyLine[i].width += sb.skyLine[i+1].width
+			sb.skyLine = append(sb.skyLine[:i+1], sb.skyLine[i+2:]...)
+			i--
+		}
+	}
+}
@@ -11,40 +11,61 @@
 namespace fontcatalog {
 
 font_holder::font_holder()
-    : ft(msdfgen::initializeFreetype()), font(nullptr), fontFilename(nullptr) {}
+    : ft(msdfgen::initializeFreetype()), font(nullptr), faceIndex(0) {}
 
 font_holder::~font_holder() {
   if (ft) {
-    if (font)
-      msdfgen::destroyFont(font);
+    unload();
     msdfgen::deinitializeFreetype(ft);
   }
 }
 
-bool font_holder::load(const char *fontFilename) {
+void font_holder::unload() {
+  if (font) {
+    FT_Face face = msdfgen::getFreetypeFont(font);
+    msdfgen::destroyFont(font);
+    FT_Done_Face(face);
+    font = nullptr;
+  }
+  fontFilename.clear();
+}
+
+bool font_holder::load(const char *fontFilename, int faceIndex) {
   if (ft && fontFilename) {
-    if (this->fontFilename && !strcmp(this->fontFilename, fontFilename))
+    if (font && this->fontFilename == fontFilename &&
+        this->faceIndex == faceIndex)
       return true;
-    if (font)
-      msdfgen::destroyFont(font);
-    if ((font = msdfgen::loadFont(ft, fontFilename))) {
+    unload();
+    FT_Face face;
+    if (!FT_New_Face(msdfgen::getFreetypeLibrary(ft), fontFilename, faceIndex,
+                     &face)) {
+      font = msdfgen::adoptFreetypeFont(face);
       this->fontFilename = fontFilename;
+      this->faceIndex = faceIndex;
       return true;
     }
-    this->fontFilename = nullptr;
   }
   return false;
 }
 
-bool font_holder::load(const unsigned char *data, long size) {
+bool font_holder::load(const unsigned char *data, long size, int faceIndex) {
   if (ft && data) {
-    if (font)
-      msdfgen::destroyFont(font);
-    if ((font = msdfgen::loadFontData(ft, data, size))) {
+    unload();
+    FT_Face face;
+    if (!FT_New_Memory_Face(msdfgen::getFreetypeLibrary(ft), data, size,
+                            faceIndex, &face)) {
+      font = msdfgen::adoptFreetypeFont(face);
+      this->faceIndex = faceIndex;
       return true;
     }
   }
   return false;
 }
 
-} // namespace fontcatalog
\ No newline at end of file
+int font_holder::num_faces() const {
+  if (!font)
+    return 0;
+  return msdfgen::getFreetypeFont(font)->num_faces;
+}
+
+} // namespace fontcatalog
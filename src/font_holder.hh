@@ -14,16 +14,21 @@ namespace fontcatalog {
 class font_holder {
   msdfgen::FreetypeHandle *ft;
   msdfgen::FontHandle *font;
-  const char *fontFilename;
+  std::string fontFilename;
+  int faceIndex;
+
+  void unload();
 
 public:
   font_holder();
   ~font_holder();
 
-  bool load(const char *fontFilename);
-  bool load(const unsigned char *data, long size);
+  bool load(const char *fontFilename, int faceIndex = 0);
+  bool load(const unsigned char *data, long size, int faceIndex = 0);
+
+  int num_faces() const;
 
   operator msdfgen::FontHandle *() const { return font; }
 };
 
-} // namespace fontcatalog
\ No newline at end of file
+} // namespace fontcatalog
@@ -10,13 +10,17 @@
 #include FT_SFNT_NAMES_H
 #include FT_BITMAP_H
 #include FT_IMAGE_H
+#include FT_MULTIPLE_MASTERS_H
 
 #include "font_geometry.hh"
 #include "font_holder.hh"
 #include "fontcatalog_lib.h"
 #include "generator_attributes.hh"
+#include "glyph_closure.hh"
 #include "glyph_generators.hh"
 #include "glyph_geometry.hh"
+#include "layout_scripts.hh"
+#include "text_shaper.hh"
 
 #include "bitmap_blit.hh"
 #include "msdfgen-ext.h"
@@ -85,6 +89,31 @@ fc_font_holder_load_font_memory(const unsigned char *data, long size) {
   return holder;
 }
 
+FC_LIB_EXPORT fc_font_holder_t *
+fc_font_holder_load_font_memory_face(const unsigned char *data, long size,
+                                     int face) {
+  fc_font_holder_t *holder = new fc_font_holder_t{};
+  if (!holder->h.load(data, size, face)) {
+    delete holder;
+    return nullptr;
+  }
+  return holder;
+}
+
+FC_LIB_EXPORT fc_font_holder_t *fc_font_holder_load_font_file(const char *filename,
+                                                              int face) {
+  fc_font_holder_t *holder = new fc_font_holder_t{};
+  if (!holder->h.load(filename, face)) {
+    delete holder;
+    return nullptr;
+  }
+  return holder;
+}
+
+FC_LIB_EXPORT int fc_font_holder_num_faces(fc_font_holder_t *handle) {
+  return handle->h.num_faces();
+}
+
 FC_LIB_EXPORT void fc_font_holder_free(fc_font_holder_t *handle) {
   delete handle;
 }
@@ -101,6 +130,8 @@ fc_font_holder_get_font_info(fc_font_holder_t *handle) {
   metrics.lineHeight = (ft->size->metrics.height + 32) >> 6;
   TT_Header *header = (TT_Header *)FT_Get_Sfnt_Table(ft, FT_SFNT_HEAD);
   metrics.flags = (int)header->Mac_Style | header->Flags << 16;
+  TT_HoriHeader *hhea = (TT_HoriHeader *)FT_Get_Sfnt_Table(ft, FT_SFNT_HHEA);
+  metrics.lineGap = hhea ? hhea->Line_Gap : 0;
 
   FT_ULong charcode;
   FT_UInt gindex;
@@ -118,6 +149,219 @@ fc_font_holder_get_font_info(fc_font_holder_t *handle) {
   return metrics;
 }
 
+FC_LIB_EXPORT fc_shaped_glyph_t *
+fc_font_holder_shape(fc_font_holder_t *handle, double fontScale,
+                     const char *text, int length, const char *script,
+                     const char *language, int direction, size_t *si) {
+  fontcatalog::text_shaper shaper;
+  *si = 0;
+  if (!shaper.shape(handle->h, fontScale, text, length, script, language,
+                    direction))
+    return nullptr;
+  auto &glyphs = shaper.get_glyphs();
+  fc_shaped_glyph_t *data =
+      (fc_shaped_glyph_t *)malloc(sizeof(fc_shaped_glyph_t) * glyphs.size());
+  for (size_t i = 0; i < glyphs.size(); ++i) {
+    data[i] = fc_shaped_glyph_t{glyphs[i].index,    glyphs[i].cluster,
+                                glyphs[i].xAdvance, glyphs[i].yAdvance,
+                                glyphs[i].xOffset,  glyphs[i].yOffset};
+  }
+  *si = glyphs.size();
+  return data;
+}
+
+FC_LIB_EXPORT _Bool fc_font_holder_glyph_closure(fc_font_holder_t *handle,
+                                                 fc_charset_t *codepoints,
+                                                 fc_charset_t *glyphset) {
+  return fontcatalog::glyph_closure(handle->h, codepoints->c, glyphset->c);
+}
+
+// glyph_top measures the top of the outline of codepoint in font units.
+static int glyph_top(FT_Face ft, FT_ULong codepoint) {
+  FT_UInt index = FT_Get_Char_Index(ft, codepoint);
+  if (!index || FT_Load_Glyph(ft, index, FT_LOAD_NO_SCALE) ||
+      ft->glyph->format != FT_GLYPH_FORMAT_OUTLINE)
+    return 0;
+  FT_BBox box;
+  FT_Outline_Get_CBox(&ft->glyph->outline, &box);
+  return box.yMax;
+}
+
+FC_LIB_EXPORT struct _fc_font_details_t
+fc_font_holder_get_font_details(fc_font_holder_t *handle) {
+  FT_Face ft = msdfgen::getFreetypeFont(handle->h);
+
+  struct _fc_font_details_t details;
+  memset(&details, 0, sizeof(details));
+  details.numGlyphs = ft->num_glyphs;
+  details.unitsPerEm = ft->units_per_EM;
+  details.ascender = ft->ascender;
+  details.descender = ft->descender;
+  details.underlinePosition = ft->underline_position;
+  details.underlineThickness = ft->underline_thickness;
+  details.fixedPitch = FT_IS_FIXED_WIDTH(ft);
+
+  TT_HoriHeader *hhea = (TT_HoriHeader *)FT_Get_Sfnt_Table(ft, FT_SFNT_HHEA);
+  if (hhea)
+    details.lineGap = hhea->Line_Gap;
+  TT_Postscript *post = (TT_Postscript *)FT_Get_Sfnt_Table(ft, FT_SFNT_POST);
+  if (post)
+    details.italicAngle = post->italicAngle / 65536.0;
+
+  details.os2Version = -1;
+  TT_OS2 *os2 = (TT_OS2 *)FT_Get_Sfnt_Table(ft, FT_SFNT_OS2);
+  if (os2 && os2->version != 0xFFFF) {
+    details.os2Version = os2->version;
+    details.avgCharWidth = os2->xAvgCharWidth;
+    details.weightClass = os2->usWeightClass;
+    details.widthClass = os2->usWidthClass;
+    details.fsType = os2->fsType;
+    details.fsSelection = os2->fsSelection;
+    details.typoAscender = os2->sTypoAscender;
+    details.typoDescender = os2->sTypoDescender;
+    details.typoLineGap = os2->sTypoLineGap;
+    details.winAscent = os2->usWinAscent;
+    details.winDescent = os2->usWinDescent;
+    details.strikeoutSize = os2->yStrikeoutSize;
+    details.strikeoutPosition = os2->yStrikeoutPosition;
+    if (os2->version >= 2) {
+      details.xHeight = os2->sxHeight;
+      details.capHeight = os2->sCapHeight;
+    }
+    memcpy(details.panose, os2->panose, sizeof(details.panose));
+    memcpy(details.vendor, os2->achVendID, 4);
+    details.unicodeRange[0] = os2->ulUnicodeRange1;
+    details.unicodeRange[1] = os2->ulUnicodeRange2;
+    details.unicodeRange[2] = os2->ulUnicodeRange3;
+    details.unicodeRange[3] = os2->ulUnicodeRange4;
+    details.codePageRange[0] = os2->ulCodePageRange1;
+    details.codePageRange[1] = os2->ulCodePageRange2;
+  }
+  // fonts without the OS/2 version 2 fields get them from the outlines
+  if (details.xHeight == 0)
+    details.xHeight = glyph_top(ft, 'x');
+  if (details.capHeight == 0)
+    details.capHeight = glyph_top(ft, 'H');
+  return details;
+}
+
+FC_LIB_EXPORT fc_layout_tag_t *
+fc_font_holder_get_layout_tags(fc_font_holder_t *handle, size_t *si) {
+  std::vector<std::pair<uint32_t, uint32_t>> tags;
+  *si = 0;
+  if (!fontcatalog::layout_scripts(handle->h, tags) || tags.empty())
+    return nullptr;
+  fc_layout_tag_t *data =
+      (fc_layout_tag_t *)malloc(sizeof(fc_layout_tag_t) * tags.size());
+  for (size_t i = 0; i < tags.size(); ++i)
+    data[i] = fc_layout_tag_t{tags[i].first, tags[i].second};
+  *si = tags.size();
+  return data;
+}
+
+FC_LIB_EXPORT fc_sfnt_name_t *fc_font_holder_get_names(fc_font_holder_t *handle,
+                                                       size_t *si) {
+  FT_Face ft = msdfgen::getFreetypeFont(handle->h);
+  *si = 0;
+  FT_UInt count = FT_Get_Sfnt_Name_Count(ft);
+  if (count == 0)
+    return nullptr;
+  fc_sfnt_name_t *data =
+      (fc_sfnt_name_t *)malloc(sizeof(fc_sfnt_name_t) * count);
+  for (FT_UInt i = 0; i < count; ++i) {
+    FT_SfntName name;
+    if (FT_Get_Sfnt_Name(ft, i, &name))
+      continue;
+    data[*si] = fc_sfnt_name_t{name.platform_id, name.encoding_id,
+                               name.language_id, name.name_id, name.string,
+                               name.string_len};
+    ++*si;
+  }
+  return data;
+}
+
+FC_LIB_EXPORT fc_var_axis_t *fc_font_holder_get_var_axes(fc_font_holder_t *handle,
+                                                         size_t *si) {
+  FT_Face ft = msdfgen::getFreetypeFont(handle->h);
+  FT_MM_Var *mm;
+  *si = 0;
+  if (!FT_HAS_MULTIPLE_MASTERS(ft) || FT_Get_MM_Var(ft, &mm))
+    return nullptr;
+  fc_var_axis_t *data =
+      (fc_var_axis_t *)malloc(sizeof(fc_var_axis_t) * mm->num_axis);
+  for (FT_UInt i = 0; i < mm->num_axis; ++i) {
+    FT_UInt flags = 0;
+    FT_Get_Var_Axis_Flags(mm, i, &flags);
+    const FT_Var_Axis &a = mm->axis[i];
+    data[i] = fc_var_axis_t{(uint32_t)a.tag,     a.minimum / 65536.0,
+                            a.def / 65536.0,     a.maximum / 65536.0,
+                            a.strid, (flags & FT_VAR_AXIS_FLAG_HIDDEN) != 0};
+  }
+  *si = mm->num_axis;
+  FT_Done_MM_Var(ft->glyph->library, mm);
+  return data;
+}
+
+FC_LIB_EXPORT int fc_font_holder_num_named_instances(fc_font_holder_t *handle) {
+  FT_Face ft = msdfgen::getFreetypeFont(handle->h);
+  if (!FT_HAS_MULTIPLE_MASTERS(ft))
+    return 0;
+  return ft->style_flags >> 16;
+}
+
+FC_LIB_EXPORT _Bool fc_font_holder_get_named_instance(fc_font_holder_t *handle,
+                                                      int index,
+                                                      unsigned int *nameId,
+                                                      unsigned int *psNameId,
+                                                      double *coords) {
+  FT_Face ft = msdfgen::getFreetypeFont(handle->h);
+  FT_MM_Var *mm;
+  if (!FT_HAS_MULTIPLE_MASTERS(ft) || FT_Get_MM_Var(ft, &mm))
+    return false;
+  bool ok = index >= 0 && (FT_UInt)index < mm->num_namedstyles;
+  if (ok) {
+    const FT_Var_Named_Style &ns = mm->namedstyle[index];
+    *nameId = ns.strid;
+    *psNameId = ns.psid;
+    for (FT_UInt i = 0; i < mm->num_axis; ++i)
+      coords[i] = ns.coords[i] / 65536.0;
+  }
+  FT_Done_MM_Var(ft->glyph->library, mm);
+  return ok;
+}
+
+FC_LIB_EXPORT _Bool fc_font_holder_set_named_instance(fc_font_holder_t *handle,
+                                                      int index) {
+  FT_Face ft = msdfgen::getFreetypeFont(handle->h);
+  if (!FT_HAS_MULTIPLE_MASTERS(ft))
+    return false;
+  return !FT_Set_Named_Instance(ft, index + 1);
+}
+
+FC_LIB_EXPORT _Bool fc_font_holder_get_var_coords(fc_font_holder_t *handle,
+                                                  double *coords, int n) {
+  FT_Face ft = msdfgen::getFreetypeFont(handle->h);
+  if (!FT_HAS_MULTIPLE_MASTERS(ft))
+    return false;
+  std::vector<FT_Fixed> fixed(n);
+  if (FT_Get_Var_Design_Coordinates(ft, n, fixed.data()))
+    return false;
+  for (int i = 0; i < n; ++i)
+    coords[i] = fixed[i] / 65536.0;
+  return true;
+}
+
+FC_LIB_EXPORT _Bool fc_font_holder_set_var_coords(fc_font_holder_t *handle,
+                                                  const double *coords, int n) {
+  FT_Face ft = msdfgen::getFreetypeFont(handle->h);
+  if (!FT_HAS_MULTIPLE_MASTERS(ft))
+    return false;
+  std::vector<FT_Fixed> fixed(n);
+  for (int i = 0; i < n; ++i)
+    fixed[i] = (FT_Fixed)(coords[i] * 65536.0 + (coords[i] < 0 ? -0.5 : 0.5));
+  return !FT_Set_Var_Design_Coordinates(ft, n, fixed.data());
+}
+
 FC_LIB_EXPORT fc_glyph_geometry_t *fc_new_glyph_geometry_from_glyph_index(
     fc_font_holder_t *handle, double geometryScale, fc_glyph_index_t index) {
   fc_glyph_geometry_t *holder =
@@ -353,14 +597,19 @@ fc_font_geometry_get_glyphs(fc_font_geometry_t *fonts) {
 FC_LIB_EXPORT fc_glyph_geometry_t *
 fc_font_geometry_get_glyph_from_index(fc_font_geometry_t *fonts,
                                       fc_glyph_index_t index) {
-  return new fc_glyph_geometry_t{
-      fonts->g->get_glyph(msdfgen::GlyphIndex(index))};
+  auto glyph = fonts->g->get_glyph(msdfgen::GlyphIndex(index));
+  if (!glyph)
+    return nullptr;
+  return new fc_glyph_geometry_t{glyph};
 }
 
 FC_LIB_EXPORT fc_glyph_geometry_t *
 fc_font_geometry_get_glyph_from_unicode(fc_font_geometry_t *fonts,
                                         fc_unicode_t codePoint) {
-  return new fc_glyph_geometry_t{fonts->g->get_glyph(codePoint)};
+  auto glyph = fonts->g->get_glyph(codePoint);
+  if (!glyph)
+    return nullptr;
+  return new fc_glyph_geometry_t{glyph};
 }
 
 FC_LIB_EXPORT _Bool fc_font_geometry_get_advance_from_index(
@@ -394,6 +643,7 @@ FC_LIB_EXPORT fc_kerning_t *fc_kerning_map_get_kernings(fc_kerning_map_t *kmap,
       kerning : kp.second
     };
   }
+  *si = kmap->ks.size();
   return ret;
 }
 
@@ -0,0 +1,50 @@
+#include <ft2build.h>
+#include FT_FREETYPE_H
+
+#include "glyph_closure.hh"
+
+#include <hb-ft.h>
+#include <hb-ot.h>
+#include <hb.h>
+
+namespace fontcatalog {
+
+bool glyph_closure(msdfgen::FontHandle *font, const charset &codepoints,
+                   charset &glyphset) {
+  if (!font)
+    return false;
+  FT_Face ft = msdfgen::getFreetypeFont(font);
+  if (!ft)
+    return false;
+
+  hb_face_t *face = hb_ft_face_create_referenced(ft);
+  hb_set_t *glyphs = hb_set_create();
+  for (unicode_t cp : codepoints) {
+    FT_UInt index = FT_Get_Char_Index(ft, cp);
+    if (index)
+      hb_set_add(glyphs, index);
+  }
+
+  hb_set_t *lookups = hb_set_create();
+  hb_ot_layout_collect_lookups(face, HB_OT_TAG_GSUB, nullptr, nullptr, nullptr,
+                               lookups);
+
+  unsigned population;
+  do {
+    population = hb_set_get_population(glyphs);
+    hb_codepoint_t lookup = HB_SET_VALUE_INVALID;
+    while (hb_set_next(lookups, &lookup))
+      hb_ot_layout_lookup_substitute_closure(face, lookup, glyphs);
+  } while (population != hb_set_get_population(glyphs));
+
+  hb_codepoint_t glyph = HB_SET_VALUE_INVALID;
+  while (hb_set_next(glyphs, &glyph))
+    glyphset.add(glyph);
+
+  hb_set_destroy(lookups);
+  hb_set_destroy(glyphs);
+  hb_face_destroy(face);
+  return true;
+}
+
+} // namespace fontcatalog
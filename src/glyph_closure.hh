@@ -0,0 +1,12 @@
+#pragma once
+
+#include "charset.hh"
+#include <msdfgen-ext.h>
+#include <msdfgen.h>
+
+namespace fontcatalog {
+
+bool glyph_closure(msdfgen::FontHandle *font, const charset &codepoints,
+                   charset &glyphset);
+
+} // namespace fontcatalog
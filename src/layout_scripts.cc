@@ -0,0 +1,49 @@
+#include <ft2build.h>
+#include FT_FREETYPE_H
+
+#include "layout_scripts.hh"
+
+#include <hb-ft.h>
+#include <hb-ot.h>
+#include <hb.h>
+
+#include <algorithm>
+
+namespace fontcatalog {
+
+bool layout_scripts(msdfgen::FontHandle *font,
+                    std::vector<std::pair<uint32_t, uint32_t>> &tags) {
+  tags.clear();
+  if (!font)
+    return false;
+  FT_Face ft = msdfgen::getFreetypeFont(font);
+  if (!ft)
+    return false;
+
+  hb_face_t *face = hb_ft_face_create_referenced(ft);
+  const hb_tag_t tables[] = {HB_OT_TAG_GSUB, HB_OT_TAG_GPOS};
+  for (hb_tag_t table : tables) {
+    unsigned int scriptCount =
+        hb_ot_layout_table_get_script_tags(face, table, 0, nullptr, nullptr);
+    std::vector<hb_tag_t> scripts(scriptCount);
+    hb_ot_layout_table_get_script_tags(face, table, 0, &scriptCount,
+                                       scripts.data());
+    for (unsigned int s = 0; s < scriptCount; ++s) {
+      tags.emplace_back(scripts[s], 0);
+      unsigned int languageCount = hb_ot_layout_script_get_language_tags(
+          face, table, s, 0, nullptr, nullptr);
+      std::vector<hb_tag_t> languages(languageCount);
+      hb_ot_layout_script_get_language_tags(face, table, s, 0, &languageCount,
+                                            languages.data());
+      for (unsigned int l = 0; l < languageCount; ++l)
+        tags.emplace_back(scripts[s], languages[l]);
+    }
+  }
+  hb_face_destroy(face);
+
+  std::sort(tags.begin(), tags.end());
+  tags.erase(std::unique(tags.begin(), tags.end()), tags.end());
+  return true;
+}
+
+} // namespace fontcatalog
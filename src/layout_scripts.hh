@@ -0,0 +1,17 @@
+#pragma once
+
+#include <msdfgen-ext.h>
+#include <msdfgen.h>
+
+#include <cstdint>
+#include <utility>
+#include <vector>
+
+namespace fontcatalog {
+
+// layout_scripts collects the script and language tags of the GSUB and GPOS
+// tables of font, language 0 stands for the default language of a script.
+bool layout_scripts(msdfgen::FontHandle *font,
+                    std::vector<std::pair<uint32_t, uint32_t>> &tags);
+
+} // namespace fontcatalog
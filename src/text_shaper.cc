@@ -0,0 +1,82 @@
+#include <ft2build.h>
+#include FT_FREETYPE_H
+#include FT_MULTIPLE_MASTERS_H
+
+#include "text_shaper.hh"
+
+#include <hb-ft.h>
+#include <hb-ot.h>
+#include <hb.h>
+
+namespace fontcatalog {
+
+// set_variation passes the variation coordinates of ft on to hbfont.
+static void set_variation(FT_Face ft, hb_font_t *hbfont) {
+  if (!FT_HAS_MULTIPLE_MASTERS(ft))
+    return;
+  FT_MM_Var *mm;
+  if (FT_Get_MM_Var(ft, &mm))
+    return;
+  std::vector<FT_Fixed> fixed(mm->num_axis);
+  if (!FT_Get_Var_Design_Coordinates(ft, mm->num_axis, fixed.data())) {
+    std::vector<float> coords(mm->num_axis);
+    for (FT_UInt i = 0; i < mm->num_axis; ++i)
+      coords[i] = fixed[i] / 65536.0f;
+    hb_font_set_var_coords_design(hbfont, coords.data(), coords.size());
+  }
+  FT_Done_MM_Var(ft->glyph->library, mm);
+}
+
+bool text_shaper::shape(msdfgen::FontHandle *font, double fontScale,
+                        const char *text, int length, const char *script,
+                        const char *language, int direction) {
+  glyphs.clear();
+  if (!font || !text)
+    return false;
+  FT_Face ft = msdfgen::getFreetypeFont(font);
+  if (!ft || ft->units_per_EM == 0)
+    return false;
+
+  hb_face_t *face = hb_ft_face_create_referenced(ft);
+  hb_font_t *hbfont = hb_font_create(face);
+  int upem = hb_face_get_upem(face);
+  hb_font_set_scale(hbfont, upem, upem);
+  hb_ot_font_set_funcs(hbfont);
+  set_variation(ft, hbfont);
+
+  hb_buffer_t *buffer = hb_buffer_create();
+  hb_buffer_add_utf8(buffer, text, length, 0, length);
+  if (direction != HB_DIRECTION_INVALID)
+    hb_buffer_set_direction(buffer, (hb_direction_t)direction);
+  if (script && *script)
+    hb_buffer_set_script(buffer, hb_script_from_string(script, -1));
+  if (language && *language)
+    hb_buffer_set_language(buffer, hb_language_from_string(language, -1));
+  hb_buffer_guess_segment_properties(buffer);
+
+  hb_shape(hbfont, buffer, nullptr, 0);
+
+  unsigned count = 0;
+  hb_glyph_info_t *infos = hb_buffer_get_glyph_infos(buffer, &count);
+  hb_glyph_position_t *positions =
+      hb_buffer_get_glyph_positions(buffer, &count);
+  double scale = fontScale / upem;
+  glyphs.reserve(count);
+  for (unsigned i = 0; i < count; ++i) {
+    glyphs.push_back(shaped_glyph{
+        infos[i].codepoint, infos[i].cluster,
+        scale * positions[i].x_advance, scale * positions[i].y_advance,
+        scale * positions[i].x_offset, scale * positions[i].y_offset});
+  }
+
+  hb_buffer_destroy(buffer);
+  hb_font_destroy(hbfont);
+  hb_face_destroy(face);
+  return true;
+}
+
+const std::vector<shaped_glyph> &text_shaper::get_glyphs() const {
+  return glyphs;
+}
+
+} // namespace fontcatalog
@@ -0,0 +1,30 @@
+#pragma once
+
+#include "types.hh"
+#include <msdfgen-ext.h>
+#include <msdfgen.h>
+
+#include <vector>
+
+namespace fontcatalog {
+
+struct shaped_glyph {
+  unsigned index;
+  uint32_t cluster;
+  double xAdvance, yAdvance;
+  double xOffset, yOffset;
+};
+
+class text_shaper {
+public:
+  bool shape(msdfgen::FontHandle *font, double fontScale, const char *text,
+             int length, const char *script, const char *language,
+             int direction);
+
+  const std::vector<shaped_glyph> &get_glyphs() const;
+
+private:
+  std::vector<shaped_glyph> glyphs;
+};
+
+} // namespace fontcatalog
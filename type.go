@@ -5,16 +5,18 @@ import (
 	_ "embed"
 	"encoding/json"
 	"io"
+	"sync"
 )
 
 //go:embed unicode-ranges.json
 var unicode_ranges string
 
 type UnicodeBlock struct {
-	Name  string   `json:"name"`
-	Min   int      `json:"min"`
-	Max   int      `json:"max"`
-	Fonts []string `json:"fonts"`
+	Name   string            `json:"name"`
+	Min    int               `json:"min"`
+	Max    int               `json:"max"`
+	Fonts  []string          `json:"fonts"`
+	Assets map[string]string `json:"assets,omitempty"`
 }
 
 type FontMetric struct {
@@ -44,8 +46,13 @@ type FontCatalog struct {
 	MaxWidth        float64        `json:"maxWidth"`
 	MaxHeight       float64        `json:"maxHeight"`
 	DistanceRange   float64        `json:"distanceRange"`
+	Atlas           string         `json:"atlas,omitempty"`
 	Fonts           []Font         `json:"fonts"`
 	SupportedBlocks []UnicodeBlock `json:"supportedBlocks"`
+
+	// index holds the charsets of Fonts for Resolve, built once
+	indexOnce sync.Once
+	index     map[string]indexedFont
 }
 
 func (ur *FontCatalog) ToJson() (string, error) {
@@ -53,10 +60,12 @@ func (ur *FontCatalog) ToJson() (string, error) {
 	return string(b), e
 }
 
-func ReadFontCatalog(reader io.Reader) *FontCatalog {
+func ReadFontCatalog(reader io.Reader) (*FontCatalog, error) {
 	ts := &FontCatalog{}
-	json.NewDecoder(reader).Decode(&ts)
-	return ts
+	if err := json.NewDecoder(reader).Decode(&ts); err != nil {
+		return nil, err
+	}
+	return ts, nil
 }
 
 var (
@@ -93,19 +102,44 @@ func ReadUnicodeRanges() []UnicodeRanges {
 //go:embed DefaultFonts.json
 var default_fonts string
 
+// FontFile names the file of a font style relative to the fontsDir and the
+// face to use when the file is a font collection. Styles of a variable font
+// pick a named instance, set axis values, or both, the axis values then
+// override the instance.
+type FontFile struct {
+	File      string             `json:"file"`
+	Face      int                `json:"face,omitempty"`
+	Instance  string             `json:"instance,omitempty"`
+	Variation map[string]float64 `json:"variation,omitempty"`
+}
+
+// UnicodeBlockDescription describes a font and its styles, a style is read
+// from the file Files names for it, or else from <fontsDir>/<style> with the
+// first existing extension of .ttf, .otf, .ttc, .otc and .woff.
 type UnicodeBlockDescription struct {
-	Name       string   `json:"name"`
-	Bold       *string  `json:"bold,omitempty"`
-	Italic     *string  `json:"italic,omitempty"`
-	BoldItalic *string  `json:"boldItalic,omitempty"`
-	Blocks     []string `json:"blocks"`
+	Name       string              `json:"name"`
+	Bold       *string             `json:"bold,omitempty"`
+	Italic     *string             `json:"italic,omitempty"`
+	BoldItalic *string             `json:"boldItalic,omitempty"`
+	Blocks     []string            `json:"blocks"`
+	Files      map[string]FontFile `json:"files,omitempty"`
 }
 
+// Atlas modes of a catalog, ATLAS_BLOCK writes a bitmap font per unicode
+// block, ATLAS_FONT packs all blocks of a font style into shared pages and
+// ATLAS_CATALOG packs every font style of the catalog into the same pages.
+const (
+	ATLAS_BLOCK   = "block"
+	ATLAS_FONT    = "font"
+	ATLAS_CATALOG = "catalog"
+)
+
 type FontCatalogDescription struct {
 	Name     string                    `json:"name"`
 	Size     int                       `json:"size"`
 	Distance int                       `json:"distance"`
 	Type     string                    `json:"type"`
+	Atlas    string                    `json:"atlas,omitempty"`
 	FontsDir string                    `json:"fontsDir"`
 	Fonts    []UnicodeBlockDescription `json:"fonts"`
 }
@@ -115,10 +149,12 @@ func (ur *FontCatalogDescription) ToJson() (string, error) {
 	return string(b), e
 }
 
-func ReadFontCatalogDescription(reader io.Reader) *FontCatalogDescription {
+func ReadFontCatalogDescription(reader io.Reader) (*FontCatalogDescription, error) {
 	ts := &FontCatalogDescription{}
-	json.NewDecoder(reader).Decode(&ts)
-	return ts
+	if err := json.NewDecoder(reader).Decode(&ts); err != nil {
+		return nil, err
+	}
+	return ts, nil
 }
 
 var (
@@ -133,5 +169,9 @@ func init() {
 		unicodeBlockNames = append(unicodeBlockNames, unicodeBlocks[i].Category)
 	}
 	f := bytes.NewBuffer([]byte(default_fonts))
-	DefaultFontsDescription = ReadFontCatalogDescription(f)
+	var err error
+	DefaultFontsDescription, err = ReadFontCatalogDescription(f)
+	if err != nil {
+		panic(err)
+	}
 }
@@ -2,6 +2,8 @@ package fontcatalog
 
 import (
 	"os"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -20,9 +22,9 @@ func TestUnicodeRanges(t *testing.T) {
 func TestReadFontCatalog(t *testing.T) {
 	f, _ := os.Open("./data/Default_FontCatalog.json")
 
-	font := ReadFontCatalog(f)
+	font, err := ReadFontCatalog(f)
 
-	if font == nil {
+	if err != nil || font == nil {
 		t.FailNow()
 	}
 }
@@ -30,9 +32,92 @@ func TestReadFontCatalog(t *testing.T) {
 func TestReadFontCatalogDescription(t *testing.T) {
 	f, _ := os.Open("./DefaultFonts.json")
 
-	font := ReadFontCatalogDescription(f)
+	font, err := ReadFontCatalogDescription(f)
 
-	if font == nil {
+	if err != nil || font == nil {
+		t.FailNow()
+	}
+}
+
+func TestReadFontCatalogInvalid(t *testing.T) {
+	_, err := ReadFontCatalog(strings.NewReader("{"))
+
+	if err == nil {
+		t.FailNow()
+	}
+}
+
+func TestFontCatalogResolve(t *testing.T) {
+	bold := "B_Bold"
+	catalog := &FontCatalog{
+		Fonts: []Font{
+			{Name: "A", Charset: "ab"},
+			{Name: "B", Charset: "abc", Bold: &bold},
+			{Name: ReplacementFontName, Charset: "�"},
+		},
+		SupportedBlocks: []UnicodeBlock{
+			{Name: "Basic Latin", Min: 0, Max: 127, Fonts: []string{"A", "B"}},
+			{Name: "Specials", Min: 65520, Max: 65535, Fonts: []string{ReplacementFontName}},
+		},
+	}
+
+	cases := []struct {
+		r    rune
+		bold bool
+		font string
+		file string
+	}{
+		{'a', false, "A", "A"},
+		{'a', true, "A", "A"},
+		{'c', false, "B", "B"},
+		{'c', true, "B", "B_Bold"},
+		{'z', false, ReplacementFontName, ReplacementFontName},
+		{'一', false, ReplacementFontName, ReplacementFontName},
+	}
+	for _, c := range cases {
+		font, file := catalog.Resolve(c.r, c.bold, false)
+		if font == nil || font.Name != c.font || file != c.file {
+			t.Fatalf("%q: %v %s", c.r, font, file)
+		}
+	}
+
+	if font, _ := catalog.ResolveStack([]string{"B", "A"}, 'a', false, false); font.Name != "B" {
+		t.FailNow()
+	}
+
+	// fonts added after the first lookup are still found
+	catalog.Fonts = append([]Font{{Name: "C", Charset: "z"}}, catalog.Fonts...)
+	if font, _ := catalog.Resolve('c', false, false); font == nil || font.Name != "B" {
+		t.FailNow()
+	}
+	if font, _ := catalog.ResolveStack([]string{"C", "A"}, 'z', false, false); font == nil || font.Name != "C" {
+		t.FailNow()
+	}
+
+	indexed := newIndexedFont(0, "dbcxa d😀")
+	if !reflect.DeepEqual(indexed.ranges, [][2]rune{{' ', ' '}, {'a', 'd'}, {'x', 'x'}, {'😀', '😀'}}) {
+		t.Fatalf("%q", indexed.ranges)
+	}
+	for _, r := range " abcdx😀" {
+		if !indexed.covers(r) {
+			t.Fatalf("%q", r)
+		}
+	}
+	for _, r := range "\x00e`yz😁" {
+		if indexed.covers(r) {
+			t.Fatalf("%q", r)
+		}
+	}
+
+	f, _ := os.Open("./data/Default_FontCatalog.json")
+	defaults, err := ReadFontCatalog(f)
+	if err != nil {
+		t.FailNow()
+	}
+	if font, _ := defaults.Resolve('A', false, false); font.Name != "FiraGO_Map" {
+		t.FailNow()
+	}
+	if font, _ := defaults.Resolve('', false, false); font.Name != ReplacementFontName {
 		t.FailNow()
 	}
 }
@@ -0,0 +1,188 @@
+package fontcatalog
+
+// #include <stdlib.h>
+// #include "fontcatalog_lib.h"
+// #cgo CFLAGS: -I ./lib
+// #cgo linux CXXFLAGS: -I ./lib -std=c++14
+// #cgo darwin CXXFLAGS: -I ./lib  -std=gnu++14
+import "C"
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"unsafe"
+)
+
+var ErrNotVariable = errors.New("font has no variation axes")
+
+type VariationAxis struct {
+	Tag     string  `json:"tag"`
+	Name    string  `json:"name,omitempty"`
+	Min     float64 `json:"min"`
+	Default float64 `json:"default"`
+	Max     float64 `json:"max"`
+	Hidden  bool    `json:"hidden,omitempty"`
+}
+
+// NamedInstance is a predefined style of a variable font, Coords holds the
+// design coordinate of every axis in the order of VariationAxes.
+type NamedInstance struct {
+	Name           string    `json:"name"`
+	PostScriptName string    `json:"postScriptName,omitempty"`
+	Coords         []float64 `json:"coords"`
+}
+
+func axisTag(tag C.uint32_t) string {
+	return string([]byte{byte(tag >> 24), byte(tag >> 16), byte(tag >> 8), byte(tag)})
+}
+
+// VariationAxes lists the axes of a variable font, nil for static fonts.
+func (h *FontHolder) VariationAxes() []VariationAxis {
+	var si C.size_t
+	data := C.fc_font_holder_get_var_axes(h.m, &si)
+	if data == nil {
+		return nil
+	}
+	defer C.free(unsafe.Pointer(data))
+
+	var axes []C.struct__fc_var_axis_t
+	bufHeader := (*reflect.SliceHeader)((unsafe.Pointer(&axes)))
+	bufHeader.Cap = int(si)
+	bufHeader.Len = int(si)
+	bufHeader.Data = uintptr(unsafe.Pointer(data))
+
+	ret := make([]VariationAxis, len(axes))
+	for i, a := range axes {
+		ret[i] = VariationAxis{
+			Tag:     axisTag(a.tag),
+			Name:    h.name(int(a.nameId)),
+			Min:     float64(a.minimum),
+			Default: float64(a.def),
+			Max:     float64(a.maximum),
+			Hidden:  bool(a.hidden),
+		}
+	}
+	return ret
+}
+
+// NamedInstances lists the named instances of a variable font. FreeType adds
+// the default instance when the font does not name it.
+func (h *FontHolder) NamedInstances() []NamedInstance {
+	axes := len(h.VariationAxes())
+	if axes == 0 {
+		return nil
+	}
+	n := int(C.fc_font_holder_num_named_instances(h.m))
+	ret := make([]NamedInstance, 0, n)
+	for i := 0; i < n; i++ {
+		var nameID, psNameID C.uint
+		coords := make([]float64, axes)
+		if !bool(C.fc_font_holder_get_named_instance(h.m, C.int(i), &nameID, &psNameID, (*C.double)(unsafe.Pointer(&coords[0])))) {
+			break
+		}
+		ret = append(ret, NamedInstance{Name: h.name(int(nameID)), PostScriptName: h.name(int(psNameID)), Coords: coords})
+	}
+	return ret
+}
+
+// SetNamedInstance selects the named instance i of NamedInstances, glyphs
+// loaded afterwards use its outlines and metrics.
+func (h *FontHolder) SetNamedInstance(i int) error {
+	if len(h.VariationAxes()) == 0 {
+		return ErrNotVariable
+	}
+	if !bool(C.fc_font_holder_set_named_instance(h.m, C.int(i))) {
+		return fmt.Errorf("cannot set named instance %d", i)
+	}
+	h.coords = h.variationCoords()
+	return nil
+}
+
+// SetNamedInstanceByName selects the named instance with the given name or
+// PostScript name, case is ignored.
+func (h *FontHolder) SetNamedInstanceByName(name string) error {
+	for i, ni := range h.NamedInstances() {
+		if strings.EqualFold(ni.Name, name) || strings.EqualFold(ni.PostScriptName, name) {
+			return h.SetNamedInstance(i)
+		}
+	}
+	if len(h.VariationAxes()) == 0 {
+		return ErrNotVariable
+	}
+	return fmt.Errorf("no named instance %q", name)
+}
+
+// SetVariation sets the design coordinates of the axes by tag, e.g.
+// {"wght": 700}. Axes left out take their default value, values outside an
+// axis range are clamped by FreeType.
+func (h *FontHolder) SetVariation(values map[string]float64) error {
+	axes := h.VariationAxes()
+	if len(axes) == 0 {
+		return ErrNotVariable
+	}
+	coords := make([]float64, len(axes))
+	found := 0
+	for i, a := range axes {
+		coords[i] = a.Default
+		if v, ok := values[a.Tag]; ok {
+			coords[i] = v
+			found++
+		}
+	}
+	if found != len(values) {
+		for tag := range values {
+			if !hasAxis(axes, tag) {
+				return fmt.Errorf("no variation axis %q", tag)
+			}
+		}
+	}
+	if err := h.setVariationCoords(coords); err != nil {
+		return err
+	}
+	h.coords = coords
+	return nil
+}
+
+// Variation returns the current design coordinates by axis tag, nil for
+// static fonts.
+func (h *FontHolder) Variation() map[string]float64 {
+	axes := h.VariationAxes()
+	coords := h.variationCoords()
+	if coords == nil {
+		return nil
+	}
+	ret := make(map[string]float64, len(axes))
+	for i, a := range axes {
+		ret[a.Tag] = coords[i]
+	}
+	return ret
+}
+
+func hasAxis(axes []VariationAxis, tag string) bool {
+	for _, a := range axes {
+		if a.Tag == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *FontHolder) variationCoords() []float64 {
+	n := len(h.VariationAxes())
+	if n == 0 {
+		return nil
+	}
+	coords := make([]float64, n)
+	if !bool(C.fc_font_holder_get_var_coords(h.m, (*C.double)(unsafe.Pointer(&coords[0])), C.int(n))) {
+		return nil
+	}
+	return coords
+}
+
+func (h *FontHolder) setVariationCoords(coords []float64) error {
+	if len(coords) == 0 || !bool(C.fc_font_holder_set_var_coords(h.m, (*C.double)(unsafe.Pointer(&coords[0])), C.int(len(coords)))) {
+		return errors.New("cannot set variation coordinates")
+	}
+	return nil
+}
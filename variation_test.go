@@ -0,0 +1,299 @@
+package fontcatalog
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+func buildSfnt(version uint32, tables []sfntTable) []byte {
+	be := binary.BigEndian
+	sort.Slice(tables, func(i, j int) bool { return tables[i].tag < tables[j].tag })
+	out := make([]byte, 12+16*len(tables))
+	be.PutUint32(out, version)
+	be.PutUint16(out[4:], uint16(len(tables)))
+	for i, table := range tables {
+		rec := out[12+16*i:]
+		copy(rec, table.tag)
+		be.PutUint32(rec[4:], table.checksum)
+		be.PutUint32(rec[8:], uint32(len(out)))
+		be.PutUint32(rec[12:], uint32(len(table.data)))
+		out = pad4(append(out, table.data...))
+	}
+	return out
+}
+
+// buildNameTable writes windows english names.
+func buildNameTable(names map[int]string) []byte {
+	be := binary.BigEndian
+	ids := []int{}
+	for id := range names {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	out := make([]byte, 6+12*len(ids))
+	be.PutUint16(out[2:], uint16(len(ids)))
+	be.PutUint16(out[4:], uint16(len(out)))
+	strs := []byte{}
+	for i, id := range ids {
+		rec := out[6+12*i:]
+		be.PutUint16(rec, 3)
+		be.PutUint16(rec[2:], 1)
+		be.PutUint16(rec[4:], 0x409)
+		be.PutUint16(rec[6:], uint16(id))
+		u := utf16.Encode([]rune(names[id]))
+		be.PutUint16(rec[8:], uint16(2*len(u)))
+		be.PutUint16(rec[10:], uint16(len(strs)))
+		for _, c := range u {
+			strs = append(strs, byte(c>>8), byte(c))
+		}
+	}
+	return append(out, strs...)
+}
+
+// variableAdvanceDelta is added to the advance of the simple glyphs of the
+// font built by buildVariableFont at the heaviest weight, in font units.
+const variableAdvanceDelta = 200
+
+// buildVariableFont turns font into a variable font with a wght and a wdth
+// axis and three named instances. Its gvar table widens the advance of every
+// simple glyph with the weight, the outlines are the same for every instance.
+func buildVariableFont(t *testing.T, font []byte) []byte {
+	be := binary.BigEndian
+	version, tables := readSfntTables(t, font)
+	numGlyphs := 0
+	longLoca := false
+	var loca, glyf []byte
+	kept := []sfntTable{}
+	for _, table := range tables {
+		switch table.tag {
+		case "maxp":
+			numGlyphs = int(be.Uint16(table.data[4:]))
+		case "head":
+			longLoca = be.Uint16(table.data[50:]) != 0
+		case "loca":
+			loca = table.data
+		case "glyf":
+			glyf = table.data
+		case "name", "DSIG":
+			continue
+		}
+		kept = append(kept, table)
+	}
+	if loca == nil || glyf == nil {
+		t.Fatal("not a truetype font")
+	}
+
+	type axis struct {
+		tag           string
+		min, def, max float64
+		name          int
+	}
+	axes := []axis{{"wght", 100, 400, 900, 256}, {"wdth", 75, 100, 100, 257}}
+	instances := []struct {
+		name   int
+		coords []float64
+	}{{2, []float64{400, 100}}, {258, []float64{700, 100}}, {259, []float64{700, 75}}}
+
+	fixed := func(v float64) uint32 { return uint32(int32(v * 65536)) }
+	fvar := make([]byte, 16)
+	be.PutUint16(fvar, 1)
+	be.PutUint16(fvar[4:], 16)
+	be.PutUint16(fvar[6:], 2)
+	be.PutUint16(fvar[8:], uint16(len(axes)))
+	be.PutUint16(fvar[10:], 20)
+	be.PutUint16(fvar[12:], uint16(len(instances)))
+	be.PutUint16(fvar[14:], uint16(4+4*len(axes)))
+	for _, a := range axes {
+		rec := make([]byte, 20)
+		copy(rec, a.tag)
+		be.PutUint32(rec[4:], fixed(a.min))
+		be.PutUint32(rec[8:], fixed(a.def))
+		be.PutUint32(rec[12:], fixed(a.max))
+		be.PutUint16(rec[18:], uint16(a.name))
+		fvar = append(fvar, rec...)
+	}
+	for _, in := range instances {
+		rec := make([]byte, 4+4*len(axes))
+		be.PutUint16(rec, uint16(in.name))
+		for i, c := range in.coords {
+			be.PutUint32(rec[4+4*i:], fixed(c))
+		}
+		fvar = append(fvar, rec...)
+	}
+
+	// the delta moves the second phantom point of a glyph, its advance, with a
+	// peak at the maximum weight
+	glyphOffset := func(i int) int {
+		if longLoca {
+			return int(be.Uint32(loca[4*i:]))
+		}
+		return 2 * int(be.Uint16(loca[2*i:]))
+	}
+	variations := []byte{}
+	gvar := make([]byte, 20+4*(numGlyphs+1))
+	be.PutUint16(gvar, 1)
+	be.PutUint16(gvar[4:], uint16(len(axes)))
+	be.PutUint32(gvar[8:], uint32(len(gvar)))
+	be.PutUint16(gvar[12:], uint16(numGlyphs))
+	be.PutUint16(gvar[14:], 1)
+	be.PutUint32(gvar[16:], uint32(len(gvar)))
+	for i := 0; i < numGlyphs; i++ {
+		be.PutUint32(gvar[20+4*i:], uint32(len(variations)))
+		start, end := glyphOffset(i), glyphOffset(i+1)
+		if end-start < 10 || int16(be.Uint16(glyf[start:])) <= 0 {
+			continue
+		}
+		contours := int(be.Uint16(glyf[start:]))
+		points := int(be.Uint16(glyf[start+10+2*(contours-1):])) + 1
+
+		data := make([]byte, 8+2*len(axes)+8)
+		be.PutUint16(data, 1)
+		be.PutUint16(data[2:], uint16(8+2*len(axes)))
+		be.PutUint16(data[4:], 8)
+		be.PutUint16(data[6:], 0xa000)
+		be.PutUint16(data[8:], 0x4000)
+		tuple := data[8+2*len(axes):]
+		tuple[0], tuple[1] = 1, 0x80
+		be.PutUint16(tuple[2:], uint16(points+1))
+		tuple[4] = 0x40
+		be.PutUint16(tuple[5:], variableAdvanceDelta)
+		tuple[7] = 0x80
+		variations = append(variations, data...)
+	}
+	be.PutUint32(gvar[20+4*numGlyphs:], uint32(len(variations)))
+	gvar = append(gvar, variations...)
+
+	name := buildNameTable(map[int]string{1: "Test Variable", 2: "Regular", 4: "Test Variable", 6: "TestVariable-Regular",
+		256: "Weight", 257: "Width", 258: "Bold", 259: "Condensed Bold"})
+
+	kept = append(kept, sfntTable{tag: "fvar", data: fvar}, sfntTable{tag: "gvar", data: gvar}, sfntTable{tag: "name", data: name})
+	return buildSfnt(version, kept)
+}
+
+func TestVariableFont(t *testing.T) {
+	data, err := ioutil.ReadFile("./fonts/FiraGO_Map.ttf")
+	if err != nil {
+		t.FailNow()
+	}
+	if NewFontHolder(data).VariationAxes() != nil {
+		t.FailNow()
+	}
+	if err := NewFontHolder(data).SetVariation(map[string]float64{"wght": 700}); err != ErrNotVariable {
+		t.Fatal(err)
+	}
+
+	variable := buildVariableFont(t, data)
+	holder := NewFontHolder(variable)
+
+	axes := holder.VariationAxes()
+	if len(axes) != 2 || axes[0].Tag != "wght" || axes[0].Name != "Weight" || axes[0].Min != 100 || axes[0].Default != 400 || axes[0].Max != 900 || axes[1].Tag != "wdth" {
+		t.Fatalf("%+v", axes)
+	}
+	instances := holder.NamedInstances()
+	if len(instances) != 3 || instances[1].Name != "Bold" || instances[2].Coords[0] != 700 || instances[2].Coords[1] != 75 {
+		t.Fatalf("%+v", instances)
+	}
+	if v := holder.Variation(); v["wght"] != 400 || v["wdth"] != 100 {
+		t.Fatalf("%+v", v)
+	}
+
+	if err := holder.SetNamedInstanceByName("condensed bold"); err != nil {
+		t.Fatal(err)
+	}
+	clone, err := holder.clone()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := clone.Variation(); v["wght"] != 700 || v["wdth"] != 75 {
+		t.Fatalf("%+v", v)
+	}
+	if err := holder.SetVariation(map[string]float64{"wght": 550}); err != nil {
+		t.Fatal(err)
+	}
+	if v := holder.Variation(); v["wght"] != 550 || v["wdth"] != 100 {
+		t.Fatalf("%+v", v)
+	}
+	if err := holder.SetVariation(map[string]float64{"opsz": 12}); err == nil {
+		t.FailNow()
+	}
+	if err := holder.SetNamedInstanceByName("Black"); err == nil {
+		t.FailNow()
+	}
+
+	glyphs, err := Shape(holder, "AV", "", "", DirectionInvalid, 32)
+	if err != nil || len(glyphs) != 2 {
+		t.FailNow()
+	}
+
+	// the advances follow the weight, also in the holders cloned for workers
+	advances := func(instance string, workers int) map[int]int {
+		h := NewFontHolder(variable)
+		if err := h.SetNamedInstanceByName(instance); err != nil {
+			t.Fatal(err)
+		}
+		opts := DefaultBitmapFontOptions("Basic_Latin")
+		opts.Workers = workers
+		gen := NewBitmapFontGenerater(h, NewCharsetsASCII(), 32, 8, opts)
+		bmfont := gen.Generate()
+		if gen.Err() != nil || bmfont == nil {
+			t.FailNow()
+		}
+		ret := make(map[int]int)
+		for _, c := range bmfont.Chars {
+			ret[c.ID] = c.XAdvance
+		}
+		return ret
+	}
+	regular, bold, boldWorkers := advances("Regular", 1), advances("Bold", 1), advances("Bold", 4)
+	if bold['H'] <= regular['H'] || bold['o'] <= regular['o'] {
+		t.Fatalf("regular %d bold %d", regular['H'], bold['H'])
+	}
+	if !reflect.DeepEqual(bold, boldWorkers) {
+		t.FailNow()
+	}
+
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "TestVariable.ttf"), variable, 0644); err != nil {
+		t.Fatal(err)
+	}
+	fcd, err := ReadFontCatalogDescription(strings.NewReader(`{"name":"Test","size":32,"distance":8,"type":"sdf","fontsDir":"` + filepath.ToSlash(dir) + `",
+		"fonts":[{"name":"TestVariable","bold":"TestVariable_Bold","italic":"TestVariable_Wide","blocks":["Basic Latin"],
+		"files":{"TestVariable":{"file":"TestVariable.ttf"},
+		"TestVariable_Bold":{"file":"TestVariable.ttf","instance":"Bold"},
+		"TestVariable_Wide":{"file":"TestVariable.ttf","instance":"Condensed Bold","variation":{"wdth":90}}}}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := DefaultBitmapFontOptions("")
+	gen := NewFontCatalogGenerater(fcd, &opts)
+	_, styles, err := gen.loadFontStyles(fcd.Fonts[0])
+	if err != nil || len(styles) != 3 {
+		t.Fatal(err)
+	}
+	want := []map[string]float64{{"wght": 400, "wdth": 100}, {"wght": 700, "wdth": 100}, {"wght": 700, "wdth": 90}}
+	for i, fs := range styles {
+		h, err := fs.newHolder()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v := h.Variation(); v["wght"] != want[i]["wght"] || v["wdth"] != want[i]["wdth"] {
+			t.Fatalf("%s: %+v", fs.name, v)
+		}
+	}
+
+	out := NewMemoryOutput()
+	if err := gen.GenerateTo(out); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Test_Assets/TestVariable/Basic_Latin.json", "Test_BoldAssets/TestVariable/Basic_Latin.json", "Test_ItalicAssets/TestVariable/Basic_Latin.json"} {
+		if _, err := out.ReadFile(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+}